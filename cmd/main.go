@@ -1,40 +1,90 @@
 package main
 
 import (
+	"errors"
 	"log"
+	"net"
+	"os"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/golang-fiber-jwt/config"
 	"github.com/golang-fiber-jwt/internal/container"
+	"github.com/golang-fiber-jwt/internal/middleware"
+	"github.com/golang-fiber-jwt/pkg/loglevel"
+	"github.com/golang-fiber-jwt/pkg/response"
 	"github.com/golang-fiber-jwt/routes"
 )
 
+var cfg config.AppConfig
+
 func init() {
-	cfg, err := config.LoadConfig(".")
+	var err error
+	cfg, err = config.LoadConfig(".", os.Args[1:])
+	if errors.Is(err, config.ErrHelp) {
+		os.Exit(0)
+	}
 	if err != nil {
-		log.Fatalln("Failed to load environment variables! \n", err.Error())
+		log.Fatalln("Failed to load configuration! \n", err.Error())
+	}
+	// Always printed, whatever the log level
+	log.Printf("Effective configuration:\n%s", cfg.Redacted())
+
+	// Set before anything else is logged, the container keeps it in sync with reloads
+	if level, err := loglevel.Parse(cfg.LogLevel); err == nil {
+		loglevel.Set(level)
+	}
+	if err := config.ConnectDB(&cfg); err != nil {
+		log.Fatalln("Failed to connect to the Database! \n", err.Error())
 	}
-	config.ConnectDB(&cfg)
 }
 
 func main() {
-	app := fiber.New()
-
-	app.Use(logger.New())
-	app.Use(cors.New(cors.Config{
-		AllowOrigins:     "http://localhost:3334",
-		AllowHeaders:     "Origin, Content-Type, Accept",
-		AllowMethods:     "GET, POST",
-		AllowCredentials: true,
+	app := fiber.New(fiber.Config{
+		ErrorHandler: response.ErrorHandler(response.ErrorConfig{
+			ProblemDetails:     cfg.ErrorProblemDetails,
+			ProblemTypeBaseURL: cfg.ErrorTypeBaseURL,
+			HideInternalErrors: cfg.ErrorHideInternal,
+		}),
+		// c.IP() is the client behind the proxy only for requests from a trusted proxy
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableTrustedProxyCheck: cfg.ProxyHeader != "",
+		TrustedProxies:          cfg.TrustedProxies,
+	})
+
+	app.Use(middleware.RequestID)
+	app.Use(middleware.Recover)
+	app.Use(logger.New(logger.Config{
+		// Requests are logged at the info level
+		Next: func(*fiber.Ctx) bool {
+			return !loglevel.Enabled(loglevel.Info)
+		},
+		Format: "${time} | request_id=${locals:requestid} | ${status} | ${latency} | ${ip} | ${method} | ${path} | ${error}\n",
 	}))
 
+	// Reloaded on SIGHUP and when the config file changes
+	settings := config.NewManager(cfg, func() (config.AppConfig, error) {
+		return config.LoadConfig(".", os.Args[1:])
+	})
+
 	// Initialize dependency injection container
-	c := container.NewContainer(config.DB)
+	c, err := container.NewContainer(config.DB, settings)
+	if err != nil {
+		log.Fatalln("Failed to initialize dependencies! \n", err.Error())
+	}
+	app.Use(c.CORS)
+
+	if err := settings.StartWatching(nil); err != nil {
+		log.Fatalln("Failed to watch the configuration! \n", err.Error())
+	}
 
 	// Setup routes with injected handlers
-	routes.SetupRoutes(app, c.AuthHandler, c.UserHandler)
+	routes.SetupRoutes(app, c)
 
-	log.Fatal(app.Listen(":3334"))
+	addr := net.JoinHostPort(cfg.ServerHost, strconv.Itoa(cfg.ServerPort))
+	if cfg.TLSCertFile != "" {
+		log.Fatal(app.ListenTLS(addr, cfg.TLSCertFile, cfg.TLSKeyFile))
+	}
+	log.Fatal(app.Listen(addr))
 }
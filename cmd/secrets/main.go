@@ -0,0 +1,182 @@
+// Command secrets edits the encrypted secrets file read by config.LoadConfig
+//
+//	secrets keygen                     print a new key for SECRETS_KEY
+//	secrets [-file f] edit             edit the decrypted file in $EDITOR
+//	secrets [-file f] set KEY VALUE    add or replace a secret
+//	secrets [-file f] unset KEY        remove a secret
+//	secrets [-file f] list             list the keys (values are not printed)
+//
+// The file defaults to SECRETS_FILE, the key is read from SECRETS_KEY or SECRETS_KEY_FILE
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/golang-fiber-jwt/config"
+	"github.com/golang-fiber-jwt/pkg/secretbox"
+)
+
+func main() {
+	file := flag.String("file", os.Getenv("SECRETS_FILE"), "encrypted secrets file")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: secrets [-file f] keygen|edit|set KEY VALUE|unset KEY|list")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(*file, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "secrets:", err)
+		os.Exit(1)
+	}
+}
+
+func run(file string, args []string) error {
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if args[0] == "keygen" {
+		key := make([]byte, secretbox.KeySize)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		fmt.Println(base64.StdEncoding.EncodeToString(key))
+		return nil
+	}
+
+	if file == "" {
+		return errors.New("no secrets file, use -file or SECRETS_FILE")
+	}
+	box, err := config.SecretsBox()
+	if err != nil {
+		return err
+	}
+	plaintext, err := open(file, box)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case args[0] == "edit" && len(args) == 1:
+		edited, err := edit(plaintext)
+		if err != nil {
+			return err
+		}
+		return config.SealSecrets(file, box, edited)
+	case args[0] == "set" && len(args) == 3:
+		return config.SealSecrets(file, box, setLine(plaintext, strings.ToUpper(args[1]), args[2]))
+	case args[0] == "unset" && len(args) == 2:
+		return config.SealSecrets(file, box, removeLine(plaintext, strings.ToUpper(args[1])))
+	case args[0] == "list" && len(args) == 1:
+		secrets, err := config.ParseSecrets(plaintext)
+		if err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(secrets))
+		for key := range secrets {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Println(key)
+		}
+		return nil
+	default:
+		flag.Usage()
+		os.Exit(2)
+		return nil
+	}
+}
+
+// open decrypts file, a missing file is an empty one so edit and set can create it
+func open(file string, box *secretbox.Box) ([]byte, error) {
+	if _, err := os.Stat(file); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return config.OpenSecrets(file, box)
+}
+
+// edit opens plaintext in $EDITOR (vi by default) and returns the saved content
+// The plaintext only exists in a private temporary file for the duration of the edit
+func edit(plaintext []byte) ([]byte, error) {
+	tmp, err := os.CreateTemp("", "secrets-*.env")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(plaintext); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	// EDITOR may carry arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], tmp.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w", editor, err)
+	}
+	return os.ReadFile(tmp.Name())
+}
+
+// setLine replaces the KEY= line of plaintext, or appends one
+func setLine(plaintext []byte, key, value string) []byte {
+	var out bytes.Buffer
+	found := false
+	eachLine(plaintext, func(line string) {
+		if lineKey(line) == key {
+			line, found = key+"="+value, true
+		}
+		out.WriteString(line + "\n")
+	})
+	if !found {
+		out.WriteString(key + "=" + value + "\n")
+	}
+	return out.Bytes()
+}
+
+// removeLine drops the KEY= line of plaintext
+func removeLine(plaintext []byte, key string) []byte {
+	var out bytes.Buffer
+	eachLine(plaintext, func(line string) {
+		if lineKey(line) != key {
+			out.WriteString(line + "\n")
+		}
+	})
+	return out.Bytes()
+}
+
+func eachLine(plaintext []byte, fn func(line string)) {
+	scanner := bufio.NewScanner(bytes.NewReader(plaintext))
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+}
+
+// lineKey returns the key of a KEY=value line, empty for comments and blank lines
+func lineKey(line string) string {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "#") {
+		return ""
+	}
+	key, _, _ := strings.Cut(line, "=")
+	return strings.ToUpper(strings.TrimSpace(key))
+}
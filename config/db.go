@@ -3,8 +3,14 @@ package config
 import (
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/golang-fiber-jwt/pkg/loglevel"
+	"github.com/golang-fiber-jwt/pkg/requestid"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -12,25 +18,106 @@ import (
 
 var DB *gorm.DB
 
-func ConnectDB(cfg *AppConfig) {
-	var err error
-	dsn := fmt.Sprintf("host=%s user=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Shanghai", cfg.DBHost, cfg.DBUserName, cfg.DBName, cfg.DBPort)
-	// dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Shanghai", cfg.DBHost, cfg.DBUserName, cfg.DBUserPassword, cfg.DBName, cfg.DBPort)
+// gormLogLevels maps DB_LOG_LEVEL to GORM's levels, info logs every statement with its parameters
+var gormLogLevels = map[string]logger.LogLevel{
+	"silent": logger.Silent,
+	"error":  logger.Error,
+	"warn":   logger.Warn,
+	"info":   logger.Info,
+}
+
+// ConnectDB opens the database pool, retrying with exponential backoff while the
+// database is unreachable, and runs the migrations
+func ConnectDB(cfg *AppConfig) error {
+	db, err := connectWithRetry(cfg, func() (*gorm.DB, error) {
+		return gorm.Open(postgres.Open(dsn(cfg)), &gorm.Config{
+			// Queries run with a request context are logged with its request ID
+			Logger: requestid.GormLogger(logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
+				SlowThreshold:             cfg.DBSlowQueryThreshold,
+				LogLevel:                  gormLogLevels[cfg.DBLogLevel],
+				IgnoreRecordNotFoundError: true,
+			})),
+		})
+	}, time.Sleep)
+	if err != nil {
+		return err
+	}
 
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatal("Failed to connect to the Database! \n", err.Error())
-		os.Exit(1)
+		return err
+	}
+	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+	DB = db
+
+	loglevel.Print(loglevel.Info, "Running Migrations")
+	if err := RunMigrations(migrationURL(cfg)); err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+
+	loglevel.Print(loglevel.Info, "🚀 Connected Successfully to the Database")
+	return nil
+}
+
+// connectWithRetry calls open up to DB_CONNECT_ATTEMPTS times, doubling the wait between
+// attempts from DB_CONNECT_BACKOFF up to DB_CONNECT_MAX_BACKOFF
+func connectWithRetry(cfg *AppConfig, open func() (*gorm.DB, error), sleep func(time.Duration)) (*gorm.DB, error) {
+	wait := cfg.DBConnectBackoff
+	for attempt := 1; ; attempt++ {
+		db, err := open()
+		if err == nil {
+			return db, nil
+		}
+		if attempt >= cfg.DBConnectAttempts {
+			return nil, fmt.Errorf("connecting to the database failed after %d attempt(s): %w", attempt, err)
+		}
+
+		loglevel.Printf(loglevel.Warn, "database unavailable (attempt %d/%d), retrying in %s: %v", attempt, cfg.DBConnectAttempts, wait, err)
+		sleep(wait)
+		if wait *= 2; wait > cfg.DBConnectMaxBackoff {
+			wait = cfg.DBConnectMaxBackoff
+		}
 	}
+}
 
-	DB.Logger = logger.Default.LogMode(logger.Info)
+// dsn builds the key=value connection string of the database
+func dsn(cfg *AppConfig) string {
+	params := []struct{ key, value string }{
+		{"host", cfg.DBHost},
+		{"port", cfg.DBPort},
+		{"user", cfg.DBUserName},
+		{"password", cfg.DBUserPassword},
+		{"dbname", cfg.DBName},
+		{"sslmode", cfg.DBSSLMode},
+		{"TimeZone", cfg.DBTimeZone},
+		{"application_name", cfg.DBApplicationName},
+	}
 
-	log.Println("Running Migrations")
-	dbURL := fmt.Sprintf("postgres://%s@%s:%s/%s?sslmode=disable", cfg.DBUserName, cfg.DBHost, cfg.DBPort, cfg.DBName)
-	if err := RunMigrations(dbURL); err != nil {
-		log.Fatal("Migration Failed: \n", err.Error())
-		os.Exit(1)
+	pairs := make([]string, 0, len(params))
+	for _, p := range params {
+		pairs = append(pairs, p.key+"="+dsnValue(p.value))
 	}
+	return strings.Join(pairs, " ")
+}
+
+// migrationURL builds the URL form of the connection string, used by the migrations
+func migrationURL(cfg *AppConfig) string {
+	query := url.Values{}
+	query.Set("sslmode", cfg.DBSSLMode)
+	query.Set("application_name", cfg.DBApplicationName)
+	return (&url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.DBUserName, cfg.DBUserPassword),
+		Host:     net.JoinHostPort(cfg.DBHost, cfg.DBPort),
+		Path:     "/" + cfg.DBName,
+		RawQuery: query.Encode(),
+	}).String()
+}
 
-	log.Println("🚀 Connected Successfully to the Database")
+// dsnValue quotes a value of a key=value connection string, so passwords may contain spaces and quotes
+func dsnValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
 }
@@ -0,0 +1,115 @@
+package config
+
+// defaults is the lowest configuration layer, keys missing here have no default
+// Secrets and database credentials must always be configured
+var defaults = map[string]interface{}{
+	"APP_ENV":   "development",
+	"LOG_LEVEL": "info",
+
+	"SERVER_PORT": 3334,
+
+	"CORS_ALLOW_METHODS": "GET, POST, PUT, PATCH, DELETE",
+	"CORS_ALLOW_HEADERS": "Origin, Content-Type, Accept, Authorization, X-Request-ID",
+
+	"POSTGRES_HOST": "localhost",
+	"POSTGRES_PORT": "5432",
+
+	"POSTGRES_SSLMODE":          "disable",
+	"POSTGRES_TIMEZONE":         "UTC",
+	"POSTGRES_APPLICATION_NAME": "golang-fiber-jwt",
+
+	"DB_MAX_OPEN_CONNS":     25,
+	"DB_MAX_IDLE_CONNS":     5,
+	"DB_CONN_MAX_LIFETIME":  "30m",
+	"DB_CONN_MAX_IDLE_TIME": "5m",
+
+	"DB_CONNECT_ATTEMPTS":    5,
+	"DB_CONNECT_BACKOFF":     "1s",
+	"DB_CONNECT_MAX_BACKOFF": "30s",
+
+	"DB_LOG_LEVEL":            "warn",
+	"DB_SLOW_QUERY_THRESHOLD": "200ms",
+
+	"JWT_EXPIRED_IN":            "15m",
+	"JWT_MAXAGE":                15,
+	"JWT_SECRET_GRACE_PERIOD":   "24h",
+	"JWT_ALGORITHM":             "RS256",
+	"JWT_KEYS_DIR":              "keys",
+	"JWT_KEY_ROTATION_INTERVAL": "720h",
+	"JWT_CLOCK_SKEW":            "30s",
+	"REFRESH_TOKEN_EXPIRED_IN":  "168h",
+
+	"TOKEN_REVOCATION_STORE":      "memory",
+	"TOKEN_REVOCATION_CACHE_SIZE": 10000,
+
+	"LOGIN_LOCKOUT_STORE":        "memory",
+	"LOGIN_LOCKOUT_CACHE_SIZE":   10000,
+	"LOGIN_MAX_ATTEMPTS":         5,
+	"LOGIN_IP_MAX_ATTEMPTS":      20,
+	"LOGIN_BACKOFF_BASE":         "1s",
+	"LOGIN_LOCKOUT_DURATION":     "15m",
+	"LOGIN_LOCKOUT_MAX_DURATION": "24h",
+	"LOGIN_FAILURE_WINDOW":       "1h",
+
+	"RATE_LIMIT_STORE":      "memory",
+	"RATE_LIMIT_CACHE_SIZE": 100000,
+	"RATE_LIMIT_AUTH":       "sliding_window:10/1m",
+	"RATE_LIMIT_USERS":      "token_bucket:120/1m",
+
+	"REQUEST_TIMEOUT_AUTH":  "15s",
+	"REQUEST_TIMEOUT_USERS": "5s",
+
+	"CLIENT_ORIGIN": "http://localhost:3000",
+	"APP_BASE_URL":  "http://localhost:3334",
+
+	"VERIFICATION_EXPIRED_IN":      "24h",
+	"VERIFICATION_RESEND_COOLDOWN": "1m",
+	"PASSWORD_RESET_EXPIRED_IN":    "1h",
+
+	"PASSWORD_MIN_LENGTH": 8,
+	"PASSWORD_MAX_LENGTH": 72,
+
+	"PASSWORD_HASH_ALGORITHM": "bcrypt",
+	"BCRYPT_COST":             10,
+	"ARGON2_MEMORY":           65536,
+	"ARGON2_ITERATIONS":       3,
+	"ARGON2_PARALLELISM":      2,
+
+	"API_KEY_DEFAULT_EXPIRED_IN": "2160h",
+	"API_KEY_MAX_EXPIRED_IN":     "8760h",
+	"API_KEY_MAX_PER_USER":       25,
+
+	"MFA_ISSUER":               "golang-fiber-jwt",
+	"MFA_CHALLENGE_EXPIRED_IN": "5m",
+
+	"FEATURE_SIGNUP":      true,
+	"FEATURE_OAUTH_LOGIN": true,
+	"FEATURE_API_KEYS":    true,
+
+	"MAILER":    "log",
+	"MAIL_FROM": "no-reply@localhost",
+	"SMTP_PORT": "587",
+}
+
+// profiles are the defaults of each APP_ENV, applied over defaults
+// Anything deployed gets TLS to the database, secure cookies and hides internal error details
+var profiles = map[string]map[string]interface{}{
+	"development": {
+		"SERVER_HOST":         "localhost",
+		"COOKIE_SECURE":       false,
+		"COOKIE_SAME_SITE":    "Lax",
+		"ERROR_HIDE_INTERNAL": false,
+	},
+	"staging": {
+		"POSTGRES_SSLMODE":    "require",
+		"COOKIE_SECURE":       true,
+		"COOKIE_SAME_SITE":    "Lax",
+		"ERROR_HIDE_INTERNAL": true,
+	},
+	"production": {
+		"POSTGRES_SSLMODE":    "require",
+		"COOKIE_SECURE":       true,
+		"COOKIE_SAME_SITE":    "Strict",
+		"ERROR_HIDE_INTERNAL": true,
+	},
+}
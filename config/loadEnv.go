@@ -1,37 +1,290 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"time"
 
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
+// AppConfig is the application configuration
+// Fields tagged reload:"true" take effect on a reload (see Manager), the others need a restart
 type AppConfig struct {
-	DBHost         string `mapstructure:"POSTGRES_HOST"`
-	DBUserName     string `mapstructure:"POSTGRES_USER"`
-	DBUserPassword string `mapstructure:"POSTGRES_PASSWORD"`
-	DBName         string `mapstructure:"POSTGRES_DB"`
-	DBPort         string `mapstructure:"POSTGRES_PORT"`
+	// File is the config file that was read, empty when there was none
+	File string `mapstructure:"-"`
 
-	JwtSecret    string        `mapstructure:"JWT_SECRET"`
-	JwtExpiresIn time.Duration `mapstructure:"JWT_EXPIRED_IN"`
-	JwtMaxAge    int           `mapstructure:"JWT_MAXAGE"`
+	// AppEnv selects the profile of defaults, see profiles
+	AppEnv string `mapstructure:"APP_ENV" validate:"oneof=development staging production"`
 
-	ClientOrigin string `mapstructure:"CLIENT_ORIGIN"`
+	// LogLevel is the minimum level of the access log (info) and application logs, SQL logs follow DBLogLevel
+	LogLevel string `mapstructure:"LOG_LEVEL" validate:"oneof=debug info warn error" reload:"true"`
+
+	ServerHost string `mapstructure:"SERVER_HOST"`
+	ServerPort int    `mapstructure:"SERVER_PORT" validate:"min=1,max=65535"`
+	// TLS is served when both files are set
+	TLSCertFile string `mapstructure:"TLS_CERT_FILE" validate:"required_with=TLSKeyFile,omitempty,file"`
+	TLSKeyFile  string `mapstructure:"TLS_KEY_FILE" validate:"required_with=TLSCertFile,omitempty,file"`
+
+	// ProxyHeader carries the client address set by a reverse proxy, it is only read on
+	// requests from TrustedProxies (IPs or CIDRs): the IP lockout and rate limits key on it
+	ProxyHeader    string   `mapstructure:"PROXY_HEADER"`
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES" validate:"required_with=ProxyHeader,dive,ip|cidr"`
+
+	// CORSAllowOrigins defaults to ClientOrigin
+	CORSAllowOrigins []string `mapstructure:"CORS_ALLOW_ORIGINS" validate:"dive,url" reload:"true"`
+	CORSAllowMethods []string `mapstructure:"CORS_ALLOW_METHODS" validate:"min=1,dive,oneof=GET HEAD POST PUT PATCH DELETE OPTIONS" reload:"true"`
+	CORSAllowHeaders []string `mapstructure:"CORS_ALLOW_HEADERS" validate:"min=1" reload:"true"`
+
+	// An empty CookieDomain scopes cookies to the exact host that set them
+	CookieDomain   string `mapstructure:"COOKIE_DOMAIN"`
+	CookieSecure   bool   `mapstructure:"COOKIE_SECURE"`
+	CookieSameSite string `mapstructure:"COOKIE_SAME_SITE" validate:"oneof=Lax Strict None"`
+
+	DBHost         string `mapstructure:"POSTGRES_HOST" validate:"required"`
+	DBUserName     string `mapstructure:"POSTGRES_USER" validate:"required"`
+	DBUserPassword string `mapstructure:"POSTGRES_PASSWORD" secret:"true"`
+	DBName         string `mapstructure:"POSTGRES_DB" validate:"required"`
+	DBPort         string `mapstructure:"POSTGRES_PORT" validate:"required,numeric"`
+	// DBSSLMode is a libpq sslmode, staging and production default to require
+	DBSSLMode         string `mapstructure:"POSTGRES_SSLMODE" validate:"oneof=disable allow prefer require verify-ca verify-full"`
+	DBTimeZone        string `mapstructure:"POSTGRES_TIMEZONE" validate:"required,timezone"`
+	DBApplicationName string `mapstructure:"POSTGRES_APPLICATION_NAME"`
+
+	// Connection pool, zero means no limit
+	DBMaxOpenConns    int           `mapstructure:"DB_MAX_OPEN_CONNS" validate:"gte=0"`
+	DBMaxIdleConns    int           `mapstructure:"DB_MAX_IDLE_CONNS" validate:"gte=0"`
+	DBConnMaxLifetime time.Duration `mapstructure:"DB_CONN_MAX_LIFETIME" validate:"gte=0"`
+	DBConnMaxIdleTime time.Duration `mapstructure:"DB_CONN_MAX_IDLE_TIME" validate:"gte=0"`
+
+	// The initial connection is retried with exponential backoff
+	DBConnectAttempts   int           `mapstructure:"DB_CONNECT_ATTEMPTS" validate:"gt=0"`
+	DBConnectBackoff    time.Duration `mapstructure:"DB_CONNECT_BACKOFF" validate:"gt=0"`
+	DBConnectMaxBackoff time.Duration `mapstructure:"DB_CONNECT_MAX_BACKOFF" validate:"gtefield=DBConnectBackoff"`
+
+	// DBLogLevel info logs every SQL statement with its parameters, keep it for debugging
+	DBLogLevel           string        `mapstructure:"DB_LOG_LEVEL" validate:"oneof=silent error warn info"`
+	DBSlowQueryThreshold time.Duration `mapstructure:"DB_SLOW_QUERY_THRESHOLD" validate:"gt=0"`
+
+	JwtSecret    string        `mapstructure:"JWT_SECRET" validate:"required,min=32" secret:"true" reload:"true"`
+	JwtExpiresIn time.Duration `mapstructure:"JWT_EXPIRED_IN" validate:"gt=0"`
+	JwtMaxAge    int           `mapstructure:"JWT_MAXAGE" validate:"gt=0"`
+	// JwtSecretGracePeriod is how long tokens signed with a replaced JWT_SECRET keep verifying
+	JwtSecretGracePeriod time.Duration `mapstructure:"JWT_SECRET_GRACE_PERIOD" validate:"gte=0" reload:"true"`
+
+	JwtAlgorithm           string        `mapstructure:"JWT_ALGORITHM" validate:"oneof=RS256 ES256 EdDSA"`
+	JwtKeysDir             string        `mapstructure:"JWT_KEYS_DIR" validate:"required_unless=AppEnv development"`
+	JwtKeyRotationInterval time.Duration `mapstructure:"JWT_KEY_ROTATION_INTERVAL" validate:"gte=0"`
+	JwtKeyGracePeriod      time.Duration `mapstructure:"JWT_KEY_GRACE_PERIOD" validate:"gte=0"`
+	JwtIssuer              string        `mapstructure:"JWT_ISSUER"`
+	JwtAudience            string        `mapstructure:"JWT_AUDIENCE"`
+	JwtClockSkew           time.Duration `mapstructure:"JWT_CLOCK_SKEW" validate:"gte=0"`
+
+	RefreshTokenExpiresIn time.Duration `mapstructure:"REFRESH_TOKEN_EXPIRED_IN" validate:"gtfield=JwtExpiresIn"`
+
+	TokenRevocationStore     string `mapstructure:"TOKEN_REVOCATION_STORE" validate:"oneof=memory postgres"`
+	TokenRevocationCacheSize int    `mapstructure:"TOKEN_REVOCATION_CACHE_SIZE" validate:"gt=0"`
+
+	LoginLockoutStore       string        `mapstructure:"LOGIN_LOCKOUT_STORE" validate:"oneof=memory postgres"`
+	LoginLockoutCacheSize   int           `mapstructure:"LOGIN_LOCKOUT_CACHE_SIZE" validate:"gt=0"`
+	LoginMaxAttempts        int           `mapstructure:"LOGIN_MAX_ATTEMPTS" validate:"gt=0"`
+	LoginIPMaxAttempts      int           `mapstructure:"LOGIN_IP_MAX_ATTEMPTS" validate:"gt=0"`
+	LoginBackoffBase        time.Duration `mapstructure:"LOGIN_BACKOFF_BASE" validate:"gt=0"`
+	LoginLockoutDuration    time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION" validate:"gt=0"`
+	LoginLockoutMaxDuration time.Duration `mapstructure:"LOGIN_LOCKOUT_MAX_DURATION" validate:"gtefield=LoginLockoutDuration"`
+	LoginFailureWindow      time.Duration `mapstructure:"LOGIN_FAILURE_WINDOW" validate:"gt=0"`
+
+	RateLimitStore     string `mapstructure:"RATE_LIMIT_STORE" validate:"oneof=memory postgres"`
+	RateLimitCacheSize int    `mapstructure:"RATE_LIMIT_CACHE_SIZE" validate:"gt=0"`
+	RateLimitAuth      string `mapstructure:"RATE_LIMIT_AUTH" validate:"ratelimit" reload:"true"`
+	RateLimitUsers     string `mapstructure:"RATE_LIMIT_USERS" validate:"ratelimit" reload:"true"`
+
+	RequestTimeoutAuth  time.Duration `mapstructure:"REQUEST_TIMEOUT_AUTH" validate:"gt=0"`
+	RequestTimeoutUsers time.Duration `mapstructure:"REQUEST_TIMEOUT_USERS" validate:"gt=0"`
+
+	RBACPolicyFile string `mapstructure:"RBAC_POLICY_FILE" validate:"omitempty,file"`
+
+	ClientOrigin string `mapstructure:"CLIENT_ORIGIN" validate:"required,url"`
+	AppBaseURL   string `mapstructure:"APP_BASE_URL" validate:"required,url"`
+
+	VerificationSecret         string        `mapstructure:"VERIFICATION_SECRET" validate:"omitempty,min=32" secret:"true" reload:"true"`
+	VerificationExpiresIn      time.Duration `mapstructure:"VERIFICATION_EXPIRED_IN" validate:"gt=0"`
+	VerificationResendCooldown time.Duration `mapstructure:"VERIFICATION_RESEND_COOLDOWN" validate:"gt=0"`
+	RequireEmailVerification   bool          `mapstructure:"REQUIRE_EMAIL_VERIFICATION"`
+
+	PasswordResetExpiresIn time.Duration `mapstructure:"PASSWORD_RESET_EXPIRED_IN" validate:"gt=0"`
+
+	PasswordMinLength     int  `mapstructure:"PASSWORD_MIN_LENGTH" validate:"gt=0"`
+	PasswordMaxLength     int  `mapstructure:"PASSWORD_MAX_LENGTH" validate:"gtefield=PasswordMinLength"`
+	PasswordRequireUpper  bool `mapstructure:"PASSWORD_REQUIRE_UPPER"`
+	PasswordRequireLower  bool `mapstructure:"PASSWORD_REQUIRE_LOWER"`
+	PasswordRequireDigit  bool `mapstructure:"PASSWORD_REQUIRE_DIGIT"`
+	PasswordRequireSymbol bool `mapstructure:"PASSWORD_REQUIRE_SYMBOL"`
+
+	PasswordHashAlgorithm string `mapstructure:"PASSWORD_HASH_ALGORITHM" validate:"oneof=bcrypt argon2id"`
+	BcryptCost            int    `mapstructure:"BCRYPT_COST" validate:"min=4,max=31"`
+	Argon2Memory          uint32 `mapstructure:"ARGON2_MEMORY" validate:"gt=0"`
+	Argon2Iterations      uint32 `mapstructure:"ARGON2_ITERATIONS" validate:"gt=0"`
+	Argon2Parallelism     uint8  `mapstructure:"ARGON2_PARALLELISM" validate:"gt=0"`
+
+	GoogleClientID       string `mapstructure:"GOOGLE_CLIENT_ID"`
+	GoogleClientSecret   string `mapstructure:"GOOGLE_CLIENT_SECRET" validate:"required_with=GoogleClientID" secret:"true"`
+	FacebookClientID     string `mapstructure:"FACEBOOK_CLIENT_ID"`
+	FacebookClientSecret string `mapstructure:"FACEBOOK_CLIENT_SECRET" validate:"required_with=FacebookClientID" secret:"true"`
+
+	APIKeyDefaultExpiresIn time.Duration `mapstructure:"API_KEY_DEFAULT_EXPIRED_IN" validate:"gt=0,ltefield=APIKeyMaxExpiresIn"`
+	APIKeyMaxExpiresIn     time.Duration `mapstructure:"API_KEY_MAX_EXPIRED_IN" validate:"gt=0"`
+	APIKeyMaxPerUser       int           `mapstructure:"API_KEY_MAX_PER_USER" validate:"gt=0"`
+
+	MFAIssuer             string        `mapstructure:"MFA_ISSUER" validate:"required"`
+	MFAEncryptionKey      string        `mapstructure:"MFA_ENCRYPTION_KEY" validate:"required,min=32" secret:"true"`
+	MFAChallengeExpiresIn time.Duration `mapstructure:"MFA_CHALLENGE_EXPIRED_IN" validate:"gt=0"`
+
+	MailerBackend string `mapstructure:"MAILER" validate:"oneof=log smtp"`
+	MailerLogFile string `mapstructure:"MAILER_LOG_FILE"`
+	MailFrom      string `mapstructure:"MAIL_FROM" validate:"required"`
+	SMTPHost      string `mapstructure:"SMTP_HOST" validate:"required_if=MailerBackend smtp"`
+	SMTPPort      string `mapstructure:"SMTP_PORT" validate:"omitempty,numeric"`
+	SMTPUsername  string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword  string `mapstructure:"SMTP_PASSWORD" secret:"true"`
+
+	// Feature toggles, disabled features answer 404
+	FeatureSignup     bool `mapstructure:"FEATURE_SIGNUP" reload:"true"`
+	FeatureOAuthLogin bool `mapstructure:"FEATURE_OAUTH_LOGIN" reload:"true"`
+	FeatureAPIKeys    bool `mapstructure:"FEATURE_API_KEYS" reload:"true"`
+
+	ErrorProblemDetails bool   `mapstructure:"ERROR_PROBLEM_DETAILS"`
+	ErrorTypeBaseURL    string `mapstructure:"ERROR_TYPE_BASE_URL" validate:"omitempty,url"`
+	// ErrorHideInternal replaces the text of unexpected errors with a generic message
+	ErrorHideInternal bool `mapstructure:"ERROR_HIDE_INTERNAL"`
 }
 
-func LoadConfig(path string) (config AppConfig, err error) {
-	viper.AddConfigPath(path)
-	viper.SetConfigType("env")
-	viper.SetConfigName(".env")
+// ErrHelp is returned by LoadConfig when the command line asks for --help
+var ErrHelp = pflag.ErrHelp
 
-	viper.AutomaticEnv()
+// LoadConfig builds the configuration from, in increasing precedence: the defaults,
+// an optional config file, an optional encrypted secrets file, environment variables
+// and command line flags (args)
+// The file is --config, CONFIG_FILE or path/.env when it exists; .yaml, .yml and .json
+// files are read by extension, anything else as KEY=value lines
+// The secrets file is --secrets or SECRETS_FILE (see SealSecrets), secrets can also be
+// read from the files named by KEY_FILE variables (e.g. JWT_SECRET_FILE)
+// Flags are the keys in lower case with dashes (JWT_SECRET is --jwt-secret)
+// The result is validated, every misconfigured key is reported at once
+func LoadConfig(path string, args []string) (config AppConfig, err error) {
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
 
-	err = viper.ReadInConfig()
-	if err != nil {
+	flags := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "config file (YAML, JSON or KEY=value lines)")
+	secretsFile := flags.String("secrets", os.Getenv("SECRETS_FILE"), "encrypted secrets file, decrypted with "+SecretsKeyEnv)
+	for _, key := range keys() {
+		// AutomaticEnv only resolves keys viper already knows, so every key is bound explicitly
+		if err = v.BindEnv(key); err != nil {
+			return
+		}
+		flags.String(flagName(key), "", "overrides "+key)
+		if err = v.BindPFlag(key, flags.Lookup(flagName(key))); err != nil {
+			return
+		}
+	}
+	if err = flags.Parse(args); err != nil {
 		return
 	}
 
-	err = viper.Unmarshal(&config)
+	if err = readConfigFile(v, path, *configFile); err != nil {
+		return
+	}
+	if err = readSecretsFile(v, *secretsFile); err != nil {
+		return
+	}
+	if err = readSecretFiles(v, flags); err != nil {
+		return
+	}
+	// The profile is only known once every other source is read
+	for key, value := range profiles[v.GetString("APP_ENV")] {
+		v.SetDefault(key, value)
+	}
+
+	if err = v.Unmarshal(&config, viper.DecodeHook(decodeHook)); err != nil {
+		return config, fmt.Errorf("config: %w", err)
+	}
+	config.File = v.ConfigFileUsed()
+	if len(config.CORSAllowOrigins) == 0 && config.ClientOrigin != "" {
+		config.CORSAllowOrigins = []string{config.ClientOrigin}
+	}
+	err = config.Validate()
 	return
 }
+
+// decodeHook decodes durations and comma separated lists, empty values (KEY= lines) are zero values
+var decodeHook = mapstructure.ComposeDecodeHookFunc(
+	func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() == reflect.String && to.Kind() != reflect.String && data == "" {
+			return reflect.Zero(to).Interface(), nil
+		}
+		return data, nil
+	},
+	mapstructure.StringToTimeDurationHookFunc(),
+	// Lists are comma separated, "GET, POST" included
+	func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to.Kind() != reflect.Slice {
+			return data, nil
+		}
+		var items []string
+		for _, item := range strings.Split(data.(string), ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	},
+)
+
+// readConfigFile reads file, or path/.env when no file is given and it exists
+func readConfigFile(v *viper.Viper, path, file string) error {
+	if file == "" {
+		file = filepath.Join(path, ".env")
+		if _, err := os.Stat(file); errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(file)); ext {
+	case ".yaml", ".yml", ".json":
+		v.SetConfigType(strings.TrimPrefix(ext, "."))
+	default:
+		v.SetConfigType("env")
+	}
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("config: reading %s: %w", file, err)
+	}
+	return nil
+}
+
+// keys returns the configuration keys in declaration order
+func keys() []string {
+	t := reflect.TypeOf(AppConfig{})
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if key := t.Field(i).Tag.Get("mapstructure"); key != "-" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// flagName returns the command line flag of a key
+func flagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// setRequiredEnv sets the keys that have no default
+func setRequiredEnv(t *testing.T) {
+	t.Setenv("POSTGRES_USER", "admin")
+	t.Setenv("POSTGRES_DB", "app")
+	t.Setenv("JWT_SECRET", testSecret)
+	t.Setenv("MFA_ENCRYPTION_KEY", testSecret)
+}
+
+// Test LoadConfig - Defaults and env vars without a config file
+func TestLoadConfig_DefaultsAndEnv(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("JWT_EXPIRED_IN", "5m")
+
+	cfg, err := LoadConfig(t.TempDir(), nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", cfg.DBUserName)
+	assert.Equal(t, testSecret, cfg.JwtSecret)
+	assert.Equal(t, 5*time.Minute, cfg.JwtExpiresIn)
+	assert.Equal(t, "5432", cfg.DBPort)
+	assert.Equal(t, "sliding_window:10/1m", cfg.RateLimitAuth)
+	assert.Equal(t, 168*time.Hour, cfg.RefreshTokenExpiresIn)
+}
+
+// Test LoadConfig - Each layer overrides the one below it
+func TestLoadConfig_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("postgres_user: from-file\npostgres_db: from-file\npostgres_host: file-host\njwt_secret: "+testSecret+"\nmfa_encryption_key: "+testSecret+"\n"), 0o600))
+	t.Setenv("POSTGRES_DB", "from-env")
+	t.Setenv("POSTGRES_HOST", "env-host")
+
+	cfg, err := LoadConfig(dir, []string{"--config", file, "--postgres-host", "flag-host"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "from-file", cfg.DBUserName)
+	assert.Equal(t, "from-env", cfg.DBName)
+	assert.Equal(t, "flag-host", cfg.DBHost)
+}
+
+// Test LoadConfig - path/.env is read when present
+func TestLoadConfig_DotEnv(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("POSTGRES_USER=admin\nPOSTGRES_DB=app\nJWT_SECRET="+testSecret+"\nMFA_ENCRYPTION_KEY="+testSecret+"\nLOGIN_MAX_ATTEMPTS=3\nJWT_KEY_GRACE_PERIOD=\n"), 0o600))
+
+	cfg, err := LoadConfig(dir, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "app", cfg.DBName)
+	assert.Equal(t, 3, cfg.LoginMaxAttempts)
+	assert.Zero(t, cfg.JwtKeyGracePeriod)
+}
+
+// Test LoadConfig - An explicit config file must exist
+func TestLoadConfig_MissingConfigFile(t *testing.T) {
+	setRequiredEnv(t)
+
+	_, err := LoadConfig(t.TempDir(), []string{"--config", "missing.yaml"})
+
+	assert.ErrorContains(t, err, "missing.yaml")
+}
+
+// Test LoadConfig - Every invalid key is reported
+func TestLoadConfig_Invalid(t *testing.T) {
+	t.Setenv("POSTGRES_DB", "app")
+	t.Setenv("JWT_SECRET", "short")
+	t.Setenv("REQUEST_TIMEOUT_AUTH", "-1s")
+	t.Setenv("RATE_LIMIT_USERS", "fast")
+	t.Setenv("MAILER", "smtp")
+
+	_, err := LoadConfig(t.TempDir(), nil)
+
+	assert.Error(t, err)
+	for _, msg := range []string{
+		"POSTGRES_USER is required",
+		"JWT_SECRET must be at least 32 characters long",
+		"MFA_ENCRYPTION_KEY is required",
+		"REQUEST_TIMEOUT_AUTH must be greater than 0",
+		"RATE_LIMIT_USERS must be",
+		`SMTP_HOST is required when MAILER is "smtp"`,
+	} {
+		assert.ErrorContains(t, err, msg)
+	}
+}
+
+// Test LoadConfig - Malformed values fail instead of being zeroed
+func TestLoadConfig_Malformed(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("JWT_EXPIRED_IN", "soon")
+
+	_, err := LoadConfig(t.TempDir(), nil)
+
+	assert.ErrorContains(t, err, "JWT_EXPIRED_IN")
+}
+
+// Test LoadConfig - APP_ENV selects secure defaults, explicit settings still win
+func TestLoadConfig_Profiles(t *testing.T) {
+	tests := []struct {
+		env          string
+		secure       bool
+		sameSite     string
+		hideInternal bool
+		sslMode      string
+	}{
+		{env: "development", secure: false, sameSite: "Lax", hideInternal: false, sslMode: "disable"},
+		{env: "staging", secure: true, sameSite: "Lax", hideInternal: true, sslMode: "require"},
+		{env: "production", secure: true, sameSite: "Strict", hideInternal: true, sslMode: "require"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("APP_ENV", tt.env)
+
+			cfg, err := LoadConfig(t.TempDir(), nil)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.secure, cfg.CookieSecure)
+			assert.Equal(t, tt.sameSite, cfg.CookieSameSite)
+			assert.Equal(t, tt.hideInternal, cfg.ErrorHideInternal)
+			assert.Equal(t, tt.sslMode, cfg.DBSSLMode)
+		})
+	}
+
+	t.Run("Override", func(t *testing.T) {
+		setRequiredEnv(t)
+		t.Setenv("APP_ENV", "production")
+
+		cfg, err := LoadConfig(t.TempDir(), []string{"--cookie-same-site", "Lax"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Lax", cfg.CookieSameSite)
+		assert.True(t, cfg.CookieSecure)
+	})
+
+	// Keys kept in memory only would not survive a restart or be shared between instances
+	t.Run("KeysDirRequired", func(t *testing.T) {
+		setRequiredEnv(t)
+		t.Setenv("APP_ENV", "production")
+
+		_, err := LoadConfig(t.TempDir(), []string{"--jwt-keys-dir="})
+
+		assert.ErrorContains(t, err, `JWT_KEYS_DIR is required unless APP_ENV is "development"`)
+	})
+}
+
+// Test LoadConfig - A proxy header is only honored with the proxies it may come from
+func TestLoadConfig_TrustedProxies(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("PROXY_HEADER", "X-Real-IP")
+
+	_, err := LoadConfig(t.TempDir(), nil)
+	assert.ErrorContains(t, err, "TRUSTED_PROXIES is required when PROXY_HEADER is set")
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.10")
+	cfg, err := LoadConfig(t.TempDir(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.10"}, cfg.TrustedProxies)
+
+	t.Setenv("TRUSTED_PROXIES", "proxy.internal")
+	_, err = LoadConfig(t.TempDir(), nil)
+	assert.ErrorContains(t, err, "TRUSTED_PROXIES[0] must be an IP address or CIDR range")
+}
+
+// Test LoadConfig - CORS origins are a list, defaulting to the client origin
+func TestLoadConfig_CORS(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("CLIENT_ORIGIN", "https://app.example.com")
+
+	cfg, err := LoadConfig(t.TempDir(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://app.example.com"}, cfg.CORSAllowOrigins)
+
+	t.Setenv("CORS_ALLOW_ORIGINS", "https://app.example.com, https://admin.example.com")
+	t.Setenv("CORS_ALLOW_METHODS", "GET,POST")
+
+	cfg, err = LoadConfig(t.TempDir(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://app.example.com", "https://admin.example.com"}, cfg.CORSAllowOrigins)
+	assert.Equal(t, []string{"GET", "POST"}, cfg.CORSAllowMethods)
+}
+
+// Test LoadConfig - Server, cookie and TLS settings are checked together
+func TestLoadConfig_InvalidServer(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("SERVER_PORT", "70000")
+	t.Setenv("CORS_ALLOW_METHODS", "GET,FETCH")
+	t.Setenv("COOKIE_SAME_SITE", "None")
+	t.Setenv("TLS_CERT_FILE", "missing.pem")
+
+	_, err := LoadConfig(t.TempDir(), nil)
+
+	assert.Error(t, err)
+	for _, msg := range []string{
+		"SERVER_PORT must be at most 65535",
+		"CORS_ALLOW_METHODS[1] must be one of",
+		"TLS_CERT_FILE must be an existing file",
+		"TLS_KEY_FILE is required when TLS_CERT_FILE is set",
+		`COOKIE_SECURE must be true when COOKIE_SAME_SITE is "None"`,
+	} {
+		assert.ErrorContains(t, err, msg)
+	}
+}
+
+// Test Redacted - Secrets are masked, other values are shown
+func TestAppConfig_Redacted(t *testing.T) {
+	cfg := AppConfig{DBUserName: "admin", DBUserPassword: "hunter2", JwtSecret: testSecret, JwtExpiresIn: 15 * time.Minute}
+
+	dump := cfg.Redacted()
+
+	assert.Contains(t, dump, "POSTGRES_USER=admin\n")
+	assert.Contains(t, dump, "POSTGRES_PASSWORD=******\n")
+	assert.Contains(t, dump, "JWT_SECRET=******\n")
+	assert.Contains(t, dump, "JWT_EXPIRED_IN=15m0s\n")
+	assert.Contains(t, dump, "SMTP_PASSWORD=\n")
+	assert.NotContains(t, dump, "hunter2")
+	assert.NotContains(t, dump, testSecret)
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang-fiber-jwt/pkg/loglevel"
+)
+
+// reloadDebounce groups the burst of events editors produce when saving a file
+const reloadDebounce = 200 * time.Millisecond
+
+// Subscriber is notified after a reload swapped the configuration
+type Subscriber func(prev, next *AppConfig)
+
+// Manager holds the configuration snapshot in effect and reloads it
+// Snapshots are never modified, a reload swaps in a new one and notifies the subscribers
+type Manager struct {
+	current atomic.Pointer[AppConfig]
+	load    func() (AppConfig, error)
+
+	// mu serializes reloads and guards subscribers
+	mu          sync.Mutex
+	subscribers []Subscriber
+}
+
+// NewManager creates a manager starting from cfg, load rebuilds the configuration on reload
+func NewManager(cfg AppConfig, load func() (AppConfig, error)) *Manager {
+	m := &Manager{load: load}
+	m.current.Store(&cfg)
+	return m
+}
+
+// Current returns the configuration in effect, callers must not modify it
+func (m *Manager) Current() *AppConfig {
+	return m.current.Load()
+}
+
+// Subscribe registers fn to be called after every successful reload
+func (m *Manager) Subscribe(fn Subscriber) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers = append(m.subscribers, fn)
+}
+
+// Reload loads and validates the configuration again and swaps it in
+// Only fields tagged reload:"true" change, changes to the others are logged and need a restart
+// An invalid configuration is rejected and the current one stays in effect
+func (m *Manager) Reload() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	loaded, err := m.load()
+	if err != nil {
+		loglevel.Printf(loglevel.Warn, "config: reload rejected, keeping the current configuration: %v", err)
+		return err
+	}
+
+	prev := m.Current()
+	next, changed, ignored := merge(prev, &loaded)
+	if len(ignored) > 0 {
+		loglevel.Printf(loglevel.Warn, "config: %s changed but need a restart", strings.Join(ignored, ", "))
+	}
+	if len(changed) == 0 {
+		loglevel.Print(loglevel.Info, "config: reloaded, nothing changed")
+		return nil
+	}
+
+	m.current.Store(next)
+	loglevel.Printf(loglevel.Info, "config: reloaded %s", strings.Join(changed, ", "))
+	for _, fn := range m.subscribers {
+		fn(prev, next)
+	}
+	return nil
+}
+
+// StartWatching reloads on SIGHUP and whenever the config file changes, until stop is closed
+func (m *Manager) StartWatching(stop <-chan struct{}) error {
+	var events chan fsnotify.Event
+	var watchErrors chan error
+	var watcher *fsnotify.Watcher
+	if file := m.Current().File; file != "" {
+		var err error
+		if watcher, err = fsnotify.NewWatcher(); err != nil {
+			return err
+		}
+		// The directory is watched because editors replace files instead of writing them
+		if err = watcher.Add(filepath.Dir(file)); err != nil {
+			watcher.Close()
+			return err
+		}
+		events, watchErrors = watcher.Events, watcher.Errors
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hangup)
+		if watcher != nil {
+			defer watcher.Close()
+		}
+
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-stop:
+				return
+			case <-hangup:
+				_ = m.Reload()
+			case event := <-events:
+				if filepath.Clean(event.Name) == filepath.Clean(m.Current().File) && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					debounce = time.After(reloadDebounce)
+				}
+			case err := <-watchErrors:
+				loglevel.Printf(loglevel.Error, "config: watching %s: %v", m.Current().File, err)
+			case <-debounce:
+				debounce = nil
+				_ = m.Reload()
+			}
+		}
+	}()
+	return nil
+}
+
+// merge returns prev with the reloadable fields of loaded, the keys that changed
+// and the keys of other fields that differ
+func merge(prev, loaded *AppConfig) (next *AppConfig, changed, ignored []string) {
+	merged := *prev
+	target, source := reflect.ValueOf(&merged).Elem(), reflect.ValueOf(loaded).Elem()
+	t := target.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("mapstructure")
+		if key == "-" || reflect.DeepEqual(target.Field(i).Interface(), source.Field(i).Interface()) {
+			continue
+		}
+		if t.Field(i).Tag.Get("reload") != "true" {
+			ignored = append(ignored, key)
+			continue
+		}
+		target.Field(i).Set(source.Field(i))
+		changed = append(changed, key)
+	}
+	return &merged, changed, ignored
+}
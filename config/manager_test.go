@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test Manager - Reloadable fields are swapped in and subscribers see both snapshots
+func TestManager_Reload(t *testing.T) {
+	start := AppConfig{LogLevel: "info", RateLimitAuth: "10/1m", DBHost: "db-1"}
+	loaded := start
+	loaded.LogLevel, loaded.RateLimitAuth, loaded.DBHost = "debug", "5/1m", "db-2"
+	m := NewManager(start, func() (AppConfig, error) { return loaded, nil })
+
+	var prevSeen, nextSeen *AppConfig
+	m.Subscribe(func(prev, next *AppConfig) { prevSeen, nextSeen = prev, next })
+	original := m.Current()
+
+	assert.NoError(t, m.Reload())
+
+	assert.Equal(t, "debug", m.Current().LogLevel)
+	assert.Equal(t, "5/1m", m.Current().RateLimitAuth)
+	assert.Equal(t, "db-1", m.Current().DBHost, "settings needing a restart keep their value")
+	assert.Same(t, original, prevSeen)
+	assert.Same(t, m.Current(), nextSeen)
+	assert.Equal(t, "info", original.LogLevel, "snapshots are never modified")
+}
+
+// Test Manager - An invalid reload is rejected and the last good config stays in effect
+func TestManager_ReloadRejected(t *testing.T) {
+	m := NewManager(AppConfig{LogLevel: "info"}, func() (AppConfig, error) {
+		return AppConfig{}, errors.New("invalid configuration")
+	})
+	notified := false
+	m.Subscribe(func(prev, next *AppConfig) { notified = true })
+
+	assert.Error(t, m.Reload())
+
+	assert.Equal(t, "info", m.Current().LogLevel)
+	assert.False(t, notified)
+}
+
+// Test Manager - Reloads without reloadable changes notify nobody
+func TestManager_ReloadUnchanged(t *testing.T) {
+	m := NewManager(AppConfig{LogLevel: "info"}, func() (AppConfig, error) {
+		return AppConfig{LogLevel: "info", DBHost: "other"}, nil
+	})
+	notified := false
+	m.Subscribe(func(prev, next *AppConfig) { notified = true })
+
+	assert.NoError(t, m.Reload())
+
+	assert.False(t, notified)
+	assert.Empty(t, m.Current().DBHost)
+}
+
+// Test Manager - Editing the config file reloads it, invalid edits are ignored
+func TestManager_WatchFile(t *testing.T) {
+	setRequiredEnv(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("log_level: info\n"), 0o600))
+	args := []string{"--config", file}
+
+	cfg, err := LoadConfig(dir, args)
+	assert.NoError(t, err)
+	m := NewManager(cfg, func() (AppConfig, error) { return LoadConfig(dir, args) })
+
+	stop := make(chan struct{})
+	defer close(stop)
+	assert.NoError(t, m.StartWatching(stop))
+
+	assert.NoError(t, os.WriteFile(file, []byte("log_level: verbose\n"), 0o600))
+	time.Sleep(3 * reloadDebounce)
+	assert.Equal(t, "info", m.Current().LogLevel)
+
+	assert.NoError(t, os.WriteFile(file, []byte("log_level: warn\n"), 0o600))
+	assert.Eventually(t, func() bool {
+		return m.Current().LogLevel == "warn"
+	}, 2*time.Second, 20*time.Millisecond)
+}
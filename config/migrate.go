@@ -1,8 +1,7 @@
 package config
 
 import (
-	"log"
-
+	"github.com/golang-fiber-jwt/pkg/loglevel"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
@@ -19,6 +18,6 @@ func RunMigrations(dbURL string) error {
 		return err
 	}
 
-	log.Println("✅ Migrations completed successfully")
+	loglevel.Print(loglevel.Info, "✅ Migrations completed successfully")
 	return nil
 }
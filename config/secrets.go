@@ -0,0 +1,172 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/golang-fiber-jwt/pkg/secretbox"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// SecretsKeyEnv holds the key of the encrypted secrets file: base64 of 32 random bytes
+// (or a passphrase, stretched with SHA-256). SecretsKeyEnv + "_FILE" names a file holding it
+const SecretsKeyEnv = "SECRETS_KEY"
+
+// secretKeys returns the keys of the fields tagged secret:"true"
+func secretKeys() []string {
+	t := reflect.TypeOf(AppConfig{})
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("secret") == "true" {
+			keys = append(keys, t.Field(i).Tag.Get("mapstructure"))
+		}
+	}
+	return keys
+}
+
+// readSecretFiles sets every secret whose KEY_FILE variable names a file (Docker and
+// Kubernetes secrets) to the content of that file, trailing newlines removed
+// The file takes the place of the KEY variable, so a KEY flag still overrides it
+func readSecretFiles(v *viper.Viper, flags *pflag.FlagSet) error {
+	for _, key := range secretKeys() {
+		file := os.Getenv(key + "_FILE")
+		if file == "" || flags.Changed(flagName(key)) {
+			continue
+		}
+		if _, set := os.LookupEnv(key); set {
+			return fmt.Errorf("config: both %s and %s_FILE are set", key, key)
+		}
+		value, err := readSecretFile(file)
+		if err != nil {
+			return fmt.Errorf("config: %s_FILE: %w", key, err)
+		}
+		v.Set(key, value)
+	}
+	return nil
+}
+
+// readSecretFile returns the content of a secret file without its trailing newlines
+func readSecretFile(file string) (string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// readSecretsFile merges the encrypted secrets file over the config file, below env vars
+func readSecretsFile(v *viper.Viper, file string) error {
+	if file == "" {
+		return nil
+	}
+	box, err := SecretsBox()
+	if err != nil {
+		return err
+	}
+	plaintext, err := OpenSecrets(file, box)
+	if err != nil {
+		return err
+	}
+	secrets, err := ParseSecrets(plaintext)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", file, err)
+	}
+
+	values := make(map[string]interface{}, len(secrets))
+	for key, value := range secrets {
+		values[key] = value
+	}
+	return v.MergeConfigMap(values)
+}
+
+// SecretsBox opens the secrets file key from SECRETS_KEY or the file named by SECRETS_KEY_FILE
+func SecretsBox() (*secretbox.Box, error) {
+	key := os.Getenv(SecretsKeyEnv)
+	if file := os.Getenv(SecretsKeyEnv + "_FILE"); file != "" && key == "" {
+		var err error
+		if key, err = readSecretFile(file); err != nil {
+			return nil, fmt.Errorf("config: %s_FILE: %w", SecretsKeyEnv, err)
+		}
+	}
+	if key == "" {
+		return nil, fmt.Errorf("config: %s is required to read the secrets file", SecretsKeyEnv)
+	}
+	return secretbox.NewFromString(key)
+}
+
+// OpenSecrets decrypts the secrets file, an empty file decrypts to nothing
+func OpenSecrets(file string, box *secretbox.Box) ([]byte, error) {
+	sealed, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("config: reading %s: %w", file, err)
+	}
+	if len(bytes.TrimSpace(sealed)) == 0 {
+		return nil, nil
+	}
+	plaintext, err := box.Open(string(bytes.TrimSpace(sealed)))
+	if errors.Is(err, secretbox.ErrDecrypt) {
+		return nil, fmt.Errorf("config: %s cannot be decrypted with %s", file, SecretsKeyEnv)
+	}
+	return plaintext, err
+}
+
+// SealSecrets encrypts plaintext (KEY=value lines) into the secrets file
+// The file is replaced atomically and only readable by its owner
+func SealSecrets(file string, box *secretbox.Box, plaintext []byte) error {
+	if _, err := ParseSecrets(plaintext); err != nil {
+		return err
+	}
+	sealed, err := box.Seal(plaintext)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".secrets-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(sealed + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// ParseSecrets parses KEY=value lines, blank lines and # comments are skipped
+// Values are taken verbatim, keys must be configuration keys
+func ParseSecrets(plaintext []byte) (map[string]string, error) {
+	known := make(map[string]bool)
+	for _, key := range keys() {
+		known[key] = true
+	}
+
+	secrets := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(plaintext))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=value", line)
+		}
+		if !known[key] {
+			return nil, fmt.Errorf("line %d: unknown key %s", line, key)
+		}
+		secrets[key] = value
+	}
+	return secrets, scanner.Err()
+}
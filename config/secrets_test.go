@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-fiber-jwt/pkg/secretbox"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecretsKey = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+
+// Test LoadConfig - Secrets are read from the files named by KEY_FILE
+func TestLoadConfig_SecretFiles(t *testing.T) {
+	setRequiredEnv(t)
+	os.Unsetenv("JWT_SECRET")
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "jwt_secret")
+	assert.NoError(t, os.WriteFile(secretFile, []byte(testSecret+"\n"), 0o600))
+	passwordFile := filepath.Join(dir, "db_password")
+	assert.NoError(t, os.WriteFile(passwordFile, []byte("s3cret\n"), 0o600))
+	t.Setenv("JWT_SECRET_FILE", secretFile)
+	t.Setenv("POSTGRES_PASSWORD_FILE", passwordFile)
+
+	cfg, err := LoadConfig(dir, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testSecret, cfg.JwtSecret)
+	assert.Equal(t, "s3cret", cfg.DBUserPassword)
+
+	// A flag still wins over the file
+	cfg, err = LoadConfig(dir, []string{"--postgres-password", "from-flag"})
+	assert.NoError(t, err)
+	assert.Equal(t, "from-flag", cfg.DBUserPassword)
+}
+
+// Test LoadConfig - A secret set both directly and from a file is ambiguous
+func TestLoadConfig_SecretFileConflict(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("JWT_SECRET_FILE", filepath.Join(t.TempDir(), "jwt_secret"))
+
+	_, err := LoadConfig(t.TempDir(), nil)
+
+	assert.ErrorContains(t, err, "both JWT_SECRET and JWT_SECRET_FILE are set")
+}
+
+// Test LoadConfig - The encrypted secrets file sits between the config file and env vars
+func TestLoadConfig_SecretsFile(t *testing.T) {
+	setRequiredEnv(t)
+	os.Unsetenv("JWT_SECRET")
+	t.Setenv("POSTGRES_PASSWORD", "from-env")
+	t.Setenv(SecretsKeyEnv, testSecretsKey)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "secrets.enc")
+	box, err := SecretsBox()
+	assert.NoError(t, err)
+	assert.NoError(t, SealSecrets(file, box, []byte("# rotated 2026-10\nJWT_SECRET="+testSecret+"\nPOSTGRES_PASSWORD=from-secrets\n")))
+
+	cfg, err := LoadConfig(dir, []string{"--secrets", file})
+
+	assert.NoError(t, err)
+	assert.Equal(t, testSecret, cfg.JwtSecret)
+	assert.Equal(t, "from-env", cfg.DBUserPassword)
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), testSecret)
+}
+
+// Test LoadConfig - The secrets file needs the key it was sealed with
+func TestLoadConfig_SecretsFileWrongKey(t *testing.T) {
+	setRequiredEnv(t)
+	file := filepath.Join(t.TempDir(), "secrets.enc")
+	box, err := secretbox.NewFromString("another passphrase")
+	assert.NoError(t, err)
+	assert.NoError(t, SealSecrets(file, box, []byte("SMTP_PASSWORD=x\n")))
+
+	_, err = LoadConfig(t.TempDir(), []string{"--secrets", file})
+	assert.ErrorContains(t, err, "SECRETS_KEY is required")
+
+	t.Setenv(SecretsKeyEnv, testSecretsKey)
+	_, err = LoadConfig(t.TempDir(), []string{"--secrets", file})
+	assert.ErrorContains(t, err, "cannot be decrypted")
+}
+
+// Test ParseSecrets - Comments and blank lines are skipped, unknown keys fail
+func TestParseSecrets(t *testing.T) {
+	secrets, err := ParseSecrets([]byte("# comment\n\nsmtp_password=p=ss word\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"SMTP_PASSWORD": "p=ss word"}, secrets)
+
+	_, err = ParseSecrets([]byte("JWT_SECRT=typo\n"))
+	assert.ErrorContains(t, err, "line 1: unknown key JWT_SECRT")
+
+	_, err = ParseSecrets([]byte("JWT_SECRET\n"))
+	assert.ErrorContains(t, err, "expected KEY=value")
+}
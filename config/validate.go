@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-fiber-jwt/pkg/ratelimit"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	// Errors name the configuration key instead of the struct field
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return field.Tag.Get("mapstructure")
+	})
+	_ = v.RegisterValidation("ratelimit", func(fl validator.FieldLevel) bool {
+		_, err := ratelimit.ParseLimit(fl.Field().String())
+		return err == nil
+	})
+	return v
+}
+
+// Validate checks every field against its rule and reports all the failures
+func (c AppConfig) Validate() error {
+	var problems []string
+	err := validate.Struct(c)
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		for _, fe := range fieldErrs {
+			problems = append(problems, fe.Field()+" "+describe(fe))
+		}
+	} else if err != nil {
+		return err
+	}
+
+	// Browsers drop SameSite=None cookies that are not Secure
+	if c.CookieSameSite == "None" && !c.CookieSecure {
+		problems = append(problems, `COOKIE_SECURE must be true when COOKIE_SAME_SITE is "None"`)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+}
+
+// describe explains the rule a field failed
+func describe(fe validator.FieldError) string {
+	param := fe.Param()
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_with":
+		return "is required when " + keyOf(param) + " is set"
+	case "required_if":
+		field, value, _ := strings.Cut(param, " ")
+		return fmt.Sprintf("is required when %s is %q", keyOf(field), value)
+	case "required_unless":
+		field, value, _ := strings.Cut(param, " ")
+		return fmt.Sprintf("is required unless %s is %q", keyOf(field), value)
+	case "min":
+		if fe.Kind() == reflect.String {
+			return "must be at least " + param + " characters long"
+		}
+		if fe.Kind() == reflect.Slice {
+			return "must list at least " + param + " value(s)"
+		}
+		return "must be at least " + param
+	case "max":
+		return "must be at most " + param
+	case "gt":
+		return "must be greater than " + param
+	case "gte":
+		return "must not be negative"
+	case "gtfield":
+		return "must be greater than " + keyOf(param)
+	case "gtefield":
+		return "must be greater than or equal to " + keyOf(param)
+	case "ltefield":
+		return "must not exceed " + keyOf(param)
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "numeric":
+		return "must be a number"
+	case "url":
+		return "must be an absolute URL"
+	case "timezone":
+		return "must be an IANA time zone, e.g. UTC or Europe/Paris"
+	case "file":
+		return "must be an existing file"
+	case "ip|cidr":
+		return "must be an IP address or CIDR range"
+	case "ratelimit":
+		return `must be "off" or <token_bucket|sliding_window>:<requests>/<window>`
+	default:
+		return "failed the " + fe.Tag() + " rule"
+	}
+}
+
+// keyOf returns the configuration key of a struct field
+func keyOf(field string) string {
+	if f, ok := reflect.TypeOf(AppConfig{}).FieldByName(field); ok {
+		return f.Tag.Get("mapstructure")
+	}
+	return field
+}
+
+// Redacted lists the effective configuration as KEY=value lines, secrets are masked
+func (c AppConfig) Redacted() string {
+	var b strings.Builder
+	v, t := reflect.ValueOf(c), reflect.TypeOf(c)
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("mapstructure") == "-" {
+			continue
+		}
+		value := fmt.Sprint(v.Field(i).Interface())
+		if list, ok := v.Field(i).Interface().([]string); ok {
+			value = strings.Join(list, ",")
+		}
+		if t.Field(i).Tag.Get("secret") == "true" && value != "" {
+			value = "******"
+		}
+		fmt.Fprintf(&b, "%s=%s\n", t.Field(i).Tag.Get("mapstructure"), value)
+	}
+	return b.String()
+}
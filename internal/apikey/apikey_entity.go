@@ -0,0 +1,76 @@
+package apikey
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-fiber-jwt/pkg/apperror"
+	"github.com/google/uuid"
+)
+
+// Config holds tunable settings for the API key service
+type Config struct {
+	// DefaultTTL is the lifetime of keys created without an explicit expiry
+	DefaultTTL time.Duration
+	// MaxTTL caps the lifetime a user may request
+	MaxTTL time.Duration
+	// MaxKeysPerUser limits how many active keys a user may hold
+	MaxKeysPerUser int
+}
+
+// APIKey represents a user's API key for domain layer
+// Only a hash of the secret is stored, Prefix identifies the key in listings
+type APIKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// CreateKeyData represents API key creation data for domain layer
+type CreateKeyData struct {
+	Name   string
+	Scopes []string
+	// ExpiresIn is the requested lifetime, zero means Config.DefaultTTL
+	ExpiresIn time.Duration
+}
+
+// ScopeError reports a requested scope a key cannot be given
+type ScopeError struct {
+	Scope  string
+	Reason string
+}
+
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf("scope %q %s", e.Scope, e.Reason)
+}
+
+// AppError maps the scope error to a validation error
+func (e *ScopeError) AppError() *apperror.Error {
+	return apperror.Validation("invalid_scope", e.Error())
+}
+
+// APIKeyModel represents the database model with GORM tags (infrastructure concern)
+type APIKeyModel struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key"`
+	UserID     uuid.UUID `gorm:"type:uuid;index;not null"`
+	Name       string    `gorm:"type:varchar(100);not null"`
+	Prefix     string    `gorm:"type:varchar(16);index;not null"`
+	KeyHash    string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+	Scopes     string    `gorm:"type:text;not null"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time `gorm:"not null;default:now()"`
+}
+
+// TableName specifies the table name for GORM
+func (APIKeyModel) TableName() string {
+	return "api_keys"
+}
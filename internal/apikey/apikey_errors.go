@@ -0,0 +1,16 @@
+package apikey
+
+import "github.com/golang-fiber-jwt/pkg/apperror"
+
+// Errors returned by the API key service, their messages are shown to the client
+var (
+	ErrUserNotFound  = apperror.NotFound("user_not_found", "user not found")
+	ErrNameRequired  = apperror.Validation("name_required", "name is required")
+	ErrScopeRequired = apperror.Validation("scope_required", "at least one scope is required")
+	ErrExpiryTooLong = apperror.Validation("expiry_too_long", "expiry exceeds the maximum api key lifetime")
+	ErrKeyLimit      = apperror.Conflict("api_key_limit", "api key limit reached")
+	ErrKeyNotFound   = apperror.NotFound("api_key_not_found", "api key not found")
+	ErrInvalidKey    = apperror.Unauthorized("invalid_api_key", "invalid api key")
+	ErrKeyRevoked    = apperror.Unauthorized("api_key_revoked", "api key has been revoked")
+	ErrKeyExpired    = apperror.Unauthorized("api_key_expired", "api key expired")
+)
@@ -0,0 +1,95 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-fiber-jwt/pkg/handler"
+	"github.com/golang-fiber-jwt/pkg/response"
+)
+
+// Handler handles HTTP requests for API key domain
+type Handler struct {
+	service Service
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// keyToResponse maps a domain APIKey to the response DTO
+func (h *Handler) keyToResponse(key *APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// CreateKey handles POST /users/me/api-keys - the secret is only returned here
+func (h *Handler) CreateKey(c *fiber.Ctx) error {
+	claims, ok := handler.Claims(c)
+	if !ok {
+		return handler.ErrUnauthenticated
+	}
+
+	var req CreateKeyRequest
+	if err := handler.ParseAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	key, rawKey, err := h.service.CreateKey(c.UserContext(), claims.Subject, &CreateKeyData{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresIn: time.Duration(req.ExpiresInDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		return err
+	}
+
+	return response.Created(c, CreatedKeyResponse{
+		APIKeyResponse: h.keyToResponse(key),
+		Key:            rawKey,
+	})
+}
+
+// ListKeys handles GET /users/me/api-keys
+func (h *Handler) ListKeys(c *fiber.Ctx) error {
+	claims, ok := handler.Claims(c)
+	if !ok {
+		return handler.ErrUnauthenticated
+	}
+
+	keys, err := h.service.ListKeys(c.UserContext(), claims.Subject)
+	if err != nil {
+		return err
+	}
+
+	items := make([]APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, h.keyToResponse(key))
+	}
+	return response.OK(c, APIKeyListResponse{Items: items})
+}
+
+// RevokeKey handles DELETE /users/me/api-keys/:id
+func (h *Handler) RevokeKey(c *fiber.Ctx) error {
+	claims, ok := handler.Claims(c)
+	if !ok {
+		return handler.ErrUnauthenticated
+	}
+
+	if err := h.service.RevokeKey(c.UserContext(), claims.Subject, c.Params("id")); err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, fiber.StatusOK, "API key revoked")
+}
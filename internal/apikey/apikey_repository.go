@@ -0,0 +1,123 @@
+package apikey
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Repository defines the interface for API key persistence
+type Repository interface {
+	// CreateKey stores a new API key
+	CreateKey(ctx context.Context, key *APIKey) error
+
+	// GetKeyByHash retrieves an API key by the hash of its secret
+	GetKeyByHash(ctx context.Context, hash string) (*APIKey, error)
+
+	// ListKeys retrieves every API key of a user, newest first
+	ListKeys(ctx context.Context, userID uuid.UUID) ([]*APIKey, error)
+
+	// CountActiveKeys counts a user's keys that are neither revoked nor expired
+	CountActiveKeys(ctx context.Context, userID uuid.UUID) (int64, error)
+
+	// RevokeKey revokes one of the user's keys
+	RevokeKey(ctx context.Context, userID, keyID uuid.UUID) error
+
+	// TouchKey records that the key was just used
+	TouchKey(ctx context.Context, keyID uuid.UUID, usedAt time.Time) error
+}
+
+// apiKeyRepository implements Repository interface with GORM
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *gorm.DB) Repository {
+	return &apiKeyRepository{db: db}
+}
+
+// CreateKey stores a new API key
+func (r *apiKeyRepository) CreateKey(ctx context.Context, key *APIKey) error {
+	return r.db.WithContext(ctx).Create(&APIKeyModel{
+		ID:        key.ID,
+		UserID:    key.UserID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		KeyHash:   key.KeyHash,
+		Scopes:    strings.Join(key.Scopes, " "),
+		ExpiresAt: key.ExpiresAt,
+		CreatedAt: key.CreatedAt,
+	}).Error
+}
+
+// GetKeyByHash retrieves an API key by the hash of its secret
+func (r *apiKeyRepository) GetKeyByHash(ctx context.Context, hash string) (*APIKey, error) {
+	var model APIKeyModel
+	if err := r.db.WithContext(ctx).Where("key_hash = ?", hash).First(&model).Error; err != nil {
+		return nil, err
+	}
+	return toDomain(&model), nil
+}
+
+// ListKeys retrieves every API key of a user, newest first
+func (r *apiKeyRepository) ListKeys(ctx context.Context, userID uuid.UUID) ([]*APIKey, error) {
+	var models []APIKeyModel
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&models).Error; err != nil {
+		return nil, err
+	}
+
+	keys := make([]*APIKey, 0, len(models))
+	for i := range models {
+		keys = append(keys, toDomain(&models[i]))
+	}
+	return keys, nil
+}
+
+// CountActiveKeys counts a user's keys that are neither revoked nor expired
+func (r *apiKeyRepository) CountActiveKeys(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&APIKeyModel{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Count(&count).Error
+	return count, err
+}
+
+// RevokeKey revokes one of the user's keys
+// Scoped to the owner so users cannot revoke each other's keys
+func (r *apiKeyRepository) RevokeKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Model(&APIKeyModel{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TouchKey records that the key was just used
+func (r *apiKeyRepository) TouchKey(ctx context.Context, keyID uuid.UUID, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&APIKeyModel{}).Where("id = ?", keyID).Update("last_used_at", usedAt).Error
+}
+
+// toDomain maps the database model to the domain entity
+func toDomain(model *APIKeyModel) *APIKey {
+	return &APIKey{
+		ID:         model.ID,
+		UserID:     model.UserID,
+		Name:       model.Name,
+		Prefix:     model.Prefix,
+		KeyHash:    model.KeyHash,
+		Scopes:     strings.Fields(model.Scopes),
+		ExpiresAt:  model.ExpiresAt,
+		LastUsedAt: model.LastUsedAt,
+		RevokedAt:  model.RevokedAt,
+		CreatedAt:  model.CreatedAt,
+	}
+}
@@ -0,0 +1,248 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-fiber-jwt/internal/user"
+	"github.com/golang-fiber-jwt/pkg/hashing"
+	"github.com/golang-fiber-jwt/pkg/loglevel"
+	"github.com/golang-fiber-jwt/pkg/rbac"
+	"github.com/golang-fiber-jwt/pkg/requestid"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Service defines the interface for API key business logic
+type Service interface {
+	// CreateKey creates a key for the user and returns it with its secret, which is never shown again
+	CreateKey(ctx context.Context, userID string, data *CreateKeyData) (*APIKey, string, error)
+
+	// ListKeys returns the user's keys (metadata only)
+	ListKeys(ctx context.Context, userID string) ([]*APIKey, error)
+
+	// RevokeKey revokes one of the user's keys
+	RevokeKey(ctx context.Context, userID, keyID string) error
+
+	// Authenticate resolves a presented key to the key and its owner
+	Authenticate(ctx context.Context, rawKey string) (*APIKey, *user.User, error)
+}
+
+// UserLoader loads the owner of a key
+type UserLoader interface {
+	GetUserByID(ctx context.Context, id string) (*user.User, error)
+}
+
+// KeyPrefix starts every API key so leaked keys are easy to recognise (e.g. by secret scanners)
+const KeyPrefix = "gfk_"
+
+// Defaults used when the corresponding Config field is not set
+const (
+	defaultTTL            = 90 * 24 * time.Hour
+	defaultMaxTTL         = 365 * 24 * time.Hour
+	defaultMaxKeysPerUser = 25
+)
+
+// lastUsedResolution throttles last-used writes to one per key per interval
+const lastUsedResolution = time.Minute
+
+// service implements the Service interface
+type service struct {
+	repo   Repository
+	users  UserLoader
+	policy *rbac.Policy
+	cfg    Config
+}
+
+// NewAPIKeyService creates a new API key service
+// Scopes are checked against the owner's role in policy when a key is created
+func NewAPIKeyService(repo Repository, users UserLoader, policy *rbac.Policy, cfg Config) Service {
+	if cfg.DefaultTTL <= 0 {
+		cfg.DefaultTTL = defaultTTL
+	}
+	if cfg.MaxTTL <= 0 {
+		cfg.MaxTTL = defaultMaxTTL
+	}
+	if cfg.DefaultTTL > cfg.MaxTTL {
+		cfg.DefaultTTL = cfg.MaxTTL
+	}
+	if cfg.MaxKeysPerUser <= 0 {
+		cfg.MaxKeysPerUser = defaultMaxKeysPerUser
+	}
+	return &service{repo: repo, users: users, policy: policy, cfg: cfg}
+}
+
+// CreateKey creates a key for the user and returns it with its secret, which is never shown again
+func (s *service) CreateKey(ctx context.Context, userID string, data *CreateKeyData) (*APIKey, string, error) {
+	owner, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, "", ErrUserNotFound
+	}
+
+	name := strings.TrimSpace(data.Name)
+	if name == "" {
+		return nil, "", ErrNameRequired
+	}
+
+	scopes, err := s.validateScopes(owner.Role, data.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ttl := data.ExpiresIn
+	if ttl == 0 {
+		ttl = s.cfg.DefaultTTL
+	}
+	if ttl < 0 || ttl > s.cfg.MaxTTL {
+		return nil, "", ErrExpiryTooLong
+	}
+
+	active, err := s.repo.CountActiveKeys(ctx, owner.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to count api keys: %w", err)
+	}
+	if active >= int64(s.cfg.MaxKeysPerUser) {
+		return nil, "", ErrKeyLimit
+	}
+
+	rawKey, prefix, err := generateKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	now := time.Now()
+	key := &APIKey{
+		ID:        uuid.New(),
+		UserID:    owner.ID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hashing.HashToken(rawKey),
+		Scopes:    scopes,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	if err := s.repo.CreateKey(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("failed to store api key: %w", err)
+	}
+
+	return key, rawKey, nil
+}
+
+// ListKeys returns the user's keys (metadata only)
+func (s *service) ListKeys(ctx context.Context, userID string) ([]*APIKey, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	keys, err := s.repo.ListKeys(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeKey revokes one of the user's keys
+func (s *service) RevokeKey(ctx context.Context, userID, keyID string) error {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	kid, err := uuid.Parse(keyID)
+	if err != nil {
+		return ErrKeyNotFound
+	}
+
+	if err := s.repo.RevokeKey(ctx, uid, kid); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrKeyNotFound
+		}
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return nil
+}
+
+// Authenticate resolves a presented key to the key and its owner
+func (s *service) Authenticate(ctx context.Context, rawKey string) (*APIKey, *user.User, error) {
+	if !strings.HasPrefix(rawKey, KeyPrefix) {
+		return nil, nil, ErrInvalidKey
+	}
+
+	key, err := s.repo.GetKeyByHash(ctx, hashing.HashToken(rawKey))
+	if err != nil {
+		return nil, nil, ErrInvalidKey
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil {
+		return nil, nil, ErrKeyRevoked
+	}
+	if now.After(key.ExpiresAt) {
+		return nil, nil, ErrKeyExpired
+	}
+
+	// Deleted users lose their keys with them
+	owner, err := s.users.GetUserByID(ctx, key.UserID.String())
+	if err != nil {
+		return nil, nil, ErrUserNotFound
+	}
+
+	// Best effort, a failed write must not fail the request
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.TouchKey(ctx, key.ID, now); err != nil {
+			requestid.Printf(ctx, loglevel.Warn, "apikey: failed to record last use of %s: %v", key.Prefix, err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+
+	return key, owner, nil
+}
+
+// validateScopes checks that every scope is well formed and granted to the owner's role
+// A key can never do more than its owner, this is enforced again on every request
+func (s *service) validateScopes(role string, scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	valid := make([]string, 0, len(scopes))
+
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		if !rbac.ValidPermission(scope) {
+			return nil, &ScopeError{Scope: scope, Reason: "is not a valid permission"}
+		}
+		if !s.policy.Allows(role, rbac.Permission(scope)) {
+			return nil, &ScopeError{Scope: scope, Reason: "exceeds your permissions"}
+		}
+		seen[scope] = true
+		valid = append(valid, scope)
+	}
+
+	if len(valid) == 0 {
+		return nil, ErrScopeRequired
+	}
+	return valid, nil
+}
+
+// generateKey returns a new key and its public prefix: gfk_<8 hex chars>_<secret>
+func generateKey() (rawKey, prefix string, err error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+
+	secret, err := hashing.GenerateToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	prefix = KeyPrefix + hex.EncodeToString(id)
+	return prefix + "_" + strings.TrimRight(secret, "="), prefix, nil
+}
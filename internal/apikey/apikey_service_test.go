@@ -0,0 +1,208 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-fiber-jwt/internal/user"
+	"github.com/golang-fiber-jwt/pkg/hashing"
+	"github.com/golang-fiber-jwt/pkg/rbac"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockRepository is a mock implementation of Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreateKey(ctx context.Context, key *APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetKeyByHash(ctx context.Context, hash string) (*APIKey, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*APIKey), args.Error(1)
+}
+
+func (m *MockRepository) ListKeys(ctx context.Context, userID uuid.UUID) ([]*APIKey, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*APIKey), args.Error(1)
+}
+
+func (m *MockRepository) CountActiveKeys(ctx context.Context, userID uuid.UUID) (int64, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) RevokeKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	args := m.Called(ctx, userID, keyID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) TouchKey(ctx context.Context, keyID uuid.UUID, usedAt time.Time) error {
+	args := m.Called(ctx, keyID, usedAt)
+	return args.Error(0)
+}
+
+// fakeUsers is an in-memory UserLoader
+type fakeUsers map[string]*user.User
+
+func (f fakeUsers) GetUserByID(ctx context.Context, id string) (*user.User, error) {
+	if u, ok := f[id]; ok {
+		return u, nil
+	}
+	return nil, errors.New("user not found")
+}
+
+// newTestService builds a service for a single user with the given role
+func newTestService(repo Repository, role string) (Service, *user.User) {
+	owner := &user.User{ID: uuid.New(), Email: "ci@example.com", Role: role}
+	return NewAPIKeyService(repo, fakeUsers{owner.ID.String(): owner}, rbac.DefaultPolicy(), Config{}), owner
+}
+
+// Test CreateKey Service - Secret is returned once and only its hash is stored
+func TestService_CreateKey_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, owner := newTestService(mockRepo, rbac.RoleAdmin)
+
+	var stored *APIKey
+	mockRepo.On("CountActiveKeys", mock.Anything, owner.ID).Return(int64(0), nil)
+	mockRepo.On("CreateKey", mock.Anything, mock.AnythingOfType("*apikey.APIKey")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*APIKey)
+	}).Return(nil)
+
+	key, rawKey, err := service.CreateKey(context.Background(), owner.ID.String(), &CreateKeyData{
+		Name:   " ci ",
+		Scopes: []string{"users:read", "users:read", "users:stats"},
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(rawKey, key.Prefix+"_"))
+	assert.True(t, strings.HasPrefix(key.Prefix, KeyPrefix))
+	assert.Equal(t, hashing.HashToken(rawKey), stored.KeyHash)
+	assert.NotContains(t, stored.KeyHash, rawKey)
+	assert.Equal(t, "ci", key.Name)
+	assert.Equal(t, []string{"users:read", "users:stats"}, key.Scopes)
+	assert.WithinDuration(t, time.Now().Add(defaultTTL), key.ExpiresAt, time.Minute)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test CreateKey Service - Scopes, expiry and key limit are enforced
+func TestService_CreateKey_Rejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     CreateKeyData
+		active   int64
+		expected string
+	}{
+		{name: "Scope Beyond Role", data: CreateKeyData{Name: "ci", Scopes: []string{"users:delete"}}, expected: `scope "users:delete" exceeds your permissions`},
+		{name: "Malformed Scope", data: CreateKeyData{Name: "ci", Scopes: []string{"read"}}, expected: `scope "read" is not a valid permission`},
+		{name: "No Scope", data: CreateKeyData{Name: "ci", Scopes: []string{" "}}, expected: "at least one scope is required"},
+		{name: "No Name", data: CreateKeyData{Scopes: []string{"users:read"}}, expected: "name is required"},
+		{name: "Expiry Too Long", data: CreateKeyData{Name: "ci", Scopes: []string{"users:read"}, ExpiresIn: 2 * defaultMaxTTL}, expected: "expiry exceeds the maximum api key lifetime"},
+		{name: "Limit Reached", data: CreateKeyData{Name: "ci", Scopes: []string{"users:read"}}, active: defaultMaxKeysPerUser, expected: "api key limit reached"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service, owner := newTestService(mockRepo, rbac.RoleUser)
+			mockRepo.On("CountActiveKeys", mock.Anything, owner.ID).Return(tt.active, nil).Maybe()
+
+			key, rawKey, err := service.CreateKey(context.Background(), owner.ID.String(), &tt.data)
+
+			assert.EqualError(t, err, tt.expected)
+			assert.Nil(t, key)
+			assert.Empty(t, rawKey)
+			mockRepo.AssertNotCalled(t, "CreateKey", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// Test Authenticate Service - Valid key resolves to its owner and records last use
+func TestService_Authenticate_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, owner := newTestService(mockRepo, rbac.RoleUser)
+
+	rawKey := KeyPrefix + "0a1b2c3d_secret"
+	stored := &APIKey{ID: uuid.New(), UserID: owner.ID, Prefix: KeyPrefix + "0a1b2c3d", Scopes: []string{"users:read"}, ExpiresAt: time.Now().Add(time.Hour)}
+	mockRepo.On("GetKeyByHash", mock.Anything, hashing.HashToken(rawKey)).Return(stored, nil)
+	mockRepo.On("TouchKey", mock.Anything, stored.ID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	key, caller, err := service.Authenticate(context.Background(), rawKey)
+
+	assert.NoError(t, err)
+	assert.Equal(t, stored.ID, key.ID)
+	assert.Equal(t, owner.ID, caller.ID)
+	assert.NotNil(t, key.LastUsedAt)
+
+	// Last use is only written once per resolution window
+	_, _, err = service.Authenticate(context.Background(), rawKey)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test Authenticate Service - Unknown, revoked, expired and orphaned keys
+func TestService_Authenticate_Invalid(t *testing.T) {
+	revokedAt := time.Now()
+	tests := []struct {
+		name     string
+		rawKey   string
+		stored   *APIKey
+		expected string
+	}{
+		{name: "Wrong Format", rawKey: "Bearer abc", expected: "invalid api key"},
+		{name: "Unknown Key", rawKey: KeyPrefix + "unknown", expected: "invalid api key"},
+		{name: "Revoked Key", rawKey: KeyPrefix + "revoked", stored: &APIKey{ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt}, expected: "api key has been revoked"},
+		{name: "Expired Key", rawKey: KeyPrefix + "expired", stored: &APIKey{ExpiresAt: time.Now().Add(-time.Minute)}, expected: "api key expired"},
+		{name: "Deleted Owner", rawKey: KeyPrefix + "orphan", stored: &APIKey{UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}, expected: "user not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service, _ := newTestService(mockRepo, rbac.RoleUser)
+			if tt.stored != nil {
+				mockRepo.On("GetKeyByHash", mock.Anything, hashing.HashToken(tt.rawKey)).Return(tt.stored, nil)
+			} else {
+				mockRepo.On("GetKeyByHash", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound).Maybe()
+			}
+
+			key, caller, err := service.Authenticate(context.Background(), tt.rawKey)
+
+			assert.EqualError(t, err, tt.expected)
+			assert.Nil(t, key)
+			assert.Nil(t, caller)
+			mockRepo.AssertNotCalled(t, "TouchKey", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// Test RevokeKey Service - Only the owner's keys can be revoked
+func TestService_RevokeKey(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, owner := newTestService(mockRepo, rbac.RoleUser)
+	keyID := uuid.New()
+	otherKeyID := uuid.New()
+
+	mockRepo.On("RevokeKey", mock.Anything, owner.ID, keyID).Return(nil)
+	mockRepo.On("RevokeKey", mock.Anything, owner.ID, otherKeyID).Return(gorm.ErrRecordNotFound)
+
+	assert.NoError(t, service.RevokeKey(context.Background(), owner.ID.String(), keyID.String()))
+	assert.EqualError(t, service.RevokeKey(context.Background(), owner.ID.String(), otherKeyID.String()), "api key not found")
+	assert.EqualError(t, service.RevokeKey(context.Background(), owner.ID.String(), "not-a-uuid"), "api key not found")
+	mockRepo.AssertExpectations(t)
+}
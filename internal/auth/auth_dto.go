@@ -8,21 +8,24 @@ import (
 
 // UserResponse represents user data for HTTP responses
 type UserResponse struct {
-	ID        uuid.UUID `json:"id,omitempty"`
-	Name      string    `json:"name,omitempty"`
-	Email     string    `json:"email,omitempty"`
-	Role      string    `json:"role,omitempty"`
-	Photo     string    `json:"photo,omitempty"`
-	Provider  string    `json:"provider"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         uuid.UUID `json:"id,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	Email      string    `json:"email,omitempty"`
+	Role       string    `json:"role,omitempty"`
+	Photo      string    `json:"photo,omitempty"`
+	Provider   string    `json:"provider"`
+	MFAEnabled bool      `json:"mfa_enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 // AuthResponse represents authentication response with token
 type AuthResponse struct {
-	Status string            `json:"status"`
-	Token  string            `json:"token,omitempty"`
-	Data   *UserDataResponse `json:"data,omitempty"`
+	Status       string            `json:"status"`
+	Token        string            `json:"token,omitempty"`
+	RefreshToken string            `json:"refresh_token,omitempty"`
+	MFAToken     string            `json:"mfa_token,omitempty"`
+	Data         *UserDataResponse `json:"data,omitempty"`
 }
 
 // UserDataResponse wraps user data for responses
@@ -43,3 +46,63 @@ type SignInRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
 }
+
+// RefreshTokenRequest represents token refresh HTTP request
+// The refresh token may also be sent through the refresh_token cookie
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ResendVerificationRequest represents verification email resend HTTP request
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ForgotPasswordRequest represents password reset request HTTP request
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest represents password reset HTTP request
+type ResetPasswordRequest struct {
+	Token           string `json:"token" validate:"required"`
+	Password        string `json:"password" validate:"required,min=8"`
+	PasswordConfirm string `json:"passwordConfirm" validate:"required,min=8"`
+}
+
+// MFACodeRequest represents an HTTP request carrying a TOTP or recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// MFAVerifyRequest represents the second sign-in step HTTP request
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// MFAEnrollResponse represents a pending TOTP enrollment
+type MFAEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+// MFARecoveryCodesResponse represents the one-time recovery codes, shown once
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// SessionResponse represents a signed-in device for HTTP responses
+type SessionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+// SessionListResponse represents a user's active sessions
+type SessionListResponse struct {
+	Items []SessionResponse `json:"items"`
+}
@@ -1,5 +1,60 @@
 package auth
 
+import (
+	"time"
+
+	"github.com/golang-fiber-jwt/pkg/hashing"
+	"github.com/golang-fiber-jwt/pkg/lockout"
+	"github.com/golang-fiber-jwt/pkg/oauth"
+	"github.com/golang-fiber-jwt/pkg/passwordpolicy"
+	"github.com/golang-fiber-jwt/pkg/secretbox"
+	"github.com/golang-fiber-jwt/pkg/signedtoken"
+	"github.com/google/uuid"
+)
+
+// Config holds tunable settings for the auth service
+type Config struct {
+	// RefreshTokenTTL is how long an issued refresh token stays valid
+	RefreshTokenTTL time.Duration
+
+	// VerificationSecret signs email verification tokens, OAuth login state and MFA challenges
+	VerificationSecret string
+	// VerificationKeys replaces VerificationSecret when set, so the secret can rotate while serving
+	VerificationKeys *signedtoken.Keyring
+	// VerificationTTL is how long a verification link stays valid
+	VerificationTTL time.Duration
+	// VerificationResendCooldown is the minimum delay between two verification emails
+	VerificationResendCooldown time.Duration
+	// VerificationURL is the base URL the verification token is appended to
+	VerificationURL string
+	// RequireVerifiedEmail blocks SignIn until the email address is verified
+	RequireVerifiedEmail bool
+
+	// PasswordResetTTL is how long a password reset link stays valid
+	PasswordResetTTL time.Duration
+	// PasswordResetURL is the client page the reset token is passed to
+	PasswordResetURL string
+
+	// PasswordPolicy is enforced on every new password (sign up and reset)
+	PasswordPolicy passwordpolicy.Policy
+	// PasswordHasher hashes new passwords, outdated hashes are upgraded on SignIn
+	PasswordHasher hashing.Hasher
+	// Lockout throttles failed SignIn attempts per account and IP address
+	Lockout *lockout.Guard
+
+	// OAuthProviders are the enabled social login providers
+	OAuthProviders *oauth.Registry
+
+	// MFAIssuer is the account issuer shown by authenticator apps
+	MFAIssuer string
+	// MFASecretBox encrypts TOTP secrets at rest
+	MFASecretBox *secretbox.Box
+	// MFAChallengeTTL is how long the sign-in challenge waits for the second factor
+	MFAChallengeTTL time.Duration
+	// MFAMaxAttempts is the number of failed attempts after which an MFA challenge is invalidated
+	MFAMaxAttempts int
+}
+
 // SignUpData represents user registration data for domain layer
 type SignUpData struct {
 	Name            string
@@ -14,3 +69,128 @@ type SignInData struct {
 	Email    string
 	Password string
 }
+
+// ResetPasswordData represents password reset data for domain layer
+type ResetPasswordData struct {
+	Token           string
+	Password        string
+	PasswordConfirm string
+}
+
+// RefreshToken represents a persisted refresh token for domain layer
+// Tokens issued from the same login share a FamilyID so the whole chain
+// can be revoked when a rotated token is replayed
+type RefreshToken struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	FamilyID   uuid.UUID
+	TokenHash  string
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+	ReplacedBy *uuid.UUID
+	CreatedAt  time.Time
+}
+
+// RefreshTokenModel represents the database model with GORM tags (infrastructure concern)
+type RefreshTokenModel struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key"`
+	UserID     uuid.UUID `gorm:"type:uuid;index;not null"`
+	FamilyID   uuid.UUID `gorm:"type:uuid;index;not null"`
+	TokenHash  string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt  time.Time `gorm:"not null"`
+	RevokedAt  *time.Time
+	ReplacedBy *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt  time.Time  `gorm:"not null;default:now()"`
+}
+
+// TableName specifies the table name for GORM
+func (RefreshTokenModel) TableName() string {
+	return "refresh_tokens"
+}
+
+// PasswordResetToken represents a single-use password reset token for domain layer
+type PasswordResetToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// PasswordResetTokenModel represents the database model with GORM tags (infrastructure concern)
+type PasswordResetTokenModel struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key"`
+	UserID    uuid.UUID  `gorm:"type:uuid;index;not null"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time `gorm:"default:null"`
+	CreatedAt time.Time  `gorm:"not null;default:now()"`
+}
+
+// TableName specifies the table name for GORM
+func (PasswordResetTokenModel) TableName() string {
+	return "password_reset_tokens"
+}
+
+// MFAEnrollment is a pending TOTP enrollment shown to the user once
+type MFAEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// MFARecoveryCodeModel represents the database model with GORM tags (infrastructure concern)
+// Only the SHA-256 hash of each code is stored
+type MFARecoveryCodeModel struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key"`
+	UserID    uuid.UUID  `gorm:"type:uuid;index;not null"`
+	CodeHash  string     `gorm:"type:varchar(64);not null"`
+	UsedAt    *time.Time `gorm:"default:null"`
+	CreatedAt time.Time  `gorm:"not null;default:now()"`
+}
+
+// TableName specifies the table name for GORM
+func (MFARecoveryCodeModel) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+// Device describes the client a session was started from
+type Device struct {
+	UserAgent string
+	IP        string
+}
+
+// Session is a signed-in device for domain layer
+// Its ID is also the family ID of the session's refresh tokens and the sid claim of its access tokens
+type Session struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	RevokedAt  *time.Time
+}
+
+// SessionModel represents the database model with GORM tags (infrastructure concern)
+type SessionModel struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key"`
+	UserID     uuid.UUID `gorm:"type:uuid;index;not null"`
+	UserAgent  string    `gorm:"type:varchar(255);not null;default:''"`
+	IP         string    `gorm:"type:varchar(45);not null;default:''"`
+	CreatedAt  time.Time `gorm:"not null;default:now()"`
+	LastSeenAt time.Time `gorm:"not null;default:now()"`
+	RevokedAt  *time.Time
+}
+
+// TableName specifies the table name for GORM
+func (SessionModel) TableName() string {
+	return "sessions"
+}
+
+// Tokens are the credentials of a started or refreshed session
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	SessionID    uuid.UUID
+}
@@ -0,0 +1,37 @@
+package auth
+
+import "github.com/golang-fiber-jwt/pkg/apperror"
+
+// Errors returned by the auth service, their messages are shown to the client
+var (
+	ErrPasswordMismatch         = apperror.Validation("password_mismatch", "passwords do not match")
+	ErrNameRequired             = apperror.Validation("name_required", "name is required")
+	ErrEmailRequired            = apperror.Validation("email_required", "email is required")
+	ErrUserExists               = apperror.Conflict("user_exists", "user with that email already exists")
+	ErrInvalidCredentials       = apperror.Unauthorized("invalid_credentials", "invalid email or password")
+	ErrEmailNotVerified         = apperror.Forbidden("email_not_verified", "email address is not verified")
+	ErrUserNotFound             = apperror.NotFound("user_not_found", "user not found")
+	ErrSessionNotFound          = apperror.NotFound("session_not_found", "session not found")
+	ErrRefreshTokenRequired     = apperror.Unauthorized("refresh_token_required", "refresh token is required")
+	ErrInvalidRefreshToken      = apperror.Unauthorized("invalid_refresh_token", "invalid refresh token")
+	ErrRefreshTokenExpired      = apperror.Unauthorized("refresh_token_expired", "refresh token expired")
+	ErrRefreshTokenReuse        = apperror.Unauthorized("refresh_token_reuse", "refresh token reuse detected")
+	ErrInvalidToken             = apperror.Unauthorized("invalid_token", "invalid token")
+	ErrTokenRevoked             = apperror.Unauthorized("token_revoked", "token has been revoked")
+	ErrInvalidVerificationToken = apperror.Validation("invalid_verification_token", "invalid verification token")
+	ErrVerificationTokenExpired = apperror.Validation("verification_token_expired", "verification token expired")
+	ErrInvalidResetToken        = apperror.Validation("invalid_reset_token", "invalid or expired reset token")
+	ErrUnsupportedProvider      = apperror.NotFound("unsupported_provider", "unsupported provider")
+	ErrInvalidOAuthState        = apperror.Validation("invalid_oauth_state", "invalid oauth state")
+	ErrOAuthLoginFailed         = apperror.Unauthorized("oauth_login_failed", "oauth login failed")
+	ErrProviderEmailMissing     = apperror.Validation("provider_email_missing", "provider did not return an email address")
+	ErrProviderEmailNotVerified = apperror.Forbidden("provider_email_not_verified", "email address is not verified by provider")
+	ErrProviderMismatch         = apperror.Conflict("provider_mismatch", "account is registered with a different sign-in method")
+	ErrMFANotConfigured         = apperror.New(apperror.KindInternal, "mfa_not_configured", "mfa is not configured")
+	ErrMFAEnabled               = apperror.Conflict("mfa_enabled", "mfa is already enabled")
+	ErrMFANotEnabled            = apperror.Validation("mfa_not_enabled", "mfa is not enabled")
+	ErrMFAEnrollmentNotStarted  = apperror.Validation("mfa_enrollment_not_started", "mfa enrollment not started")
+	ErrInvalidMFACode           = apperror.Unauthorized("invalid_mfa_code", "invalid mfa code")
+	ErrInvalidMFAToken          = apperror.Unauthorized("invalid_mfa_token", "invalid mfa token")
+	ErrMFATokenExpired          = apperror.Unauthorized("mfa_token_expired", "mfa token expired")
+)
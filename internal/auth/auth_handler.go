@@ -4,55 +4,52 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-fiber-jwt/config"
 	"github.com/golang-fiber-jwt/internal/user"
+	"github.com/golang-fiber-jwt/pkg/apperror"
 	"github.com/golang-fiber-jwt/pkg/handler"
+	"github.com/golang-fiber-jwt/pkg/jwt"
 	"github.com/golang-fiber-jwt/pkg/response"
-	"github.com/golang-jwt/jwt"
 )
 
 // Handler handles HTTP requests for auth domain
 // This layer is allowed to import Fiber for HTTP handling
 type Handler struct {
 	service Service
+	keys    *jwt.KeySet
+	cookies CookieConfig
+}
+
+// CookieConfig holds the lifetimes and attributes of the auth cookies
+type CookieConfig struct {
+	AccessTokenMaxAge  time.Duration
+	RefreshTokenMaxAge time.Duration
+	// Domain is empty for host-only cookies
+	Domain   string
+	Secure   bool
+	SameSite string
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(service Service) *Handler {
+func NewAuthHandler(service Service, keys *jwt.KeySet, cookies CookieConfig) *Handler {
 	return &Handler{
 		service: service,
-	}
-}
-
-// Helper function to handle service errors with appropriate HTTP status codes
-func (h *Handler) handleServiceError(c *fiber.Ctx, err error) error {
-	errorMessage := err.Error()
-
-	switch errorMessage {
-	case "user with that email already exists":
-		return response.Conflict(c, errorMessage)
-	case "passwords do not match", "name is required", "email is required", "password is required", "password must be at least 8 characters":
-		return response.BadRequest(c, errorMessage)
-	case "invalid email or password":
-		return response.BadRequest(c, errorMessage)
-	case "user not found":
-		return response.NotFound(c, errorMessage)
-	default:
-		return response.Error(c, fiber.StatusBadGateway, errorMessage)
+		keys:    keys,
+		cookies: cookies,
 	}
 }
 
 // Helper function to map domain User to transport UserResponse
 func (h *Handler) userToResponse(user *user.User) UserResponse {
 	return UserResponse{
-		ID:        user.ID,
-		Name:      user.Name,
-		Email:     user.Email,
-		Role:      user.Role,
-		Photo:     user.Photo,
-		Provider:  user.Provider,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:         user.ID,
+		Name:       user.Name,
+		Email:      user.Email,
+		Role:       user.Role,
+		Photo:      user.Photo,
+		Provider:   user.Provider,
+		MFAEnabled: user.MFAEnabled,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
 	}
 }
 
@@ -61,13 +58,13 @@ func (h *Handler) SignUpUser(c *fiber.Ctx) error {
 	// Parse, validate, and map request to domain
 	signUpData, err := handler.ParseValidateAndMap[SignUpRequest, SignUpData](c)
 	if err != nil {
-		return nil // Response already sent by helper
+		return err
 	}
 
 	// Call service
-	user, err := h.service.SignUp(signUpData)
+	user, err := h.service.SignUp(c.UserContext(), signUpData)
 	if err != nil {
-		return h.handleServiceError(c, err)
+		return err
 	}
 
 	// Map to response and return success
@@ -87,70 +84,381 @@ func (h *Handler) SignInUser(c *fiber.Ctx) error {
 	}
 
 	// Call service
-	_, user, err := h.service.SignIn(req.Email, req.Password)
+	tokens, user, err := h.service.SignIn(c.UserContext(), req.Email, req.Password, deviceOf(c))
 	if err != nil {
-		return h.handleServiceError(c, err)
+		return err
+	}
+
+	// MFA enabled: the session starts once the second factor is verified
+	if tokens == nil {
+		return h.requireMFA(c, user)
 	}
 
-	// Generate JWT token (technical concern - stays in handler)
-	cfg, err := config.LoadConfig(".")
+	return h.sendTokens(c, tokens)
+}
+
+// oauthStateCookiePath covers every provider's callback
+// It is fixed so the callback never writes the unvalidated provider param into a header
+const oauthStateCookiePath = "/api/auth"
+
+// OAuthLogin redirects to the provider's consent page
+func (h *Handler) OAuthLogin(c *fiber.Ctx) error {
+	provider := c.Params("provider")
+
+	authURL, state, err := h.service.BeginOAuthLogin(c.UserContext(), provider)
 	if err != nil {
-		return response.InternalError(c, "Failed to load config")
+		return err
 	}
 
-	tokenByte := jwt.New(jwt.SigningMethodHS256)
-	now := time.Now().UTC()
-	claims := tokenByte.Claims.(jwt.MapClaims)
+	// Lax so the cookie comes back on the provider's top-level redirect
+	h.setCookie(c, &fiber.Cookie{
+		Name:     "oauth_state",
+		Value:    state,
+		Path:     oauthStateCookiePath,
+		MaxAge:   10 * 60,
+		SameSite: "Lax",
+	})
 
-	claims["sub"] = user.ID.String()
-	claims["exp"] = now.Add(cfg.JwtExpiresIn).Unix()
-	claims["iat"] = now.Unix()
-	claims["nbf"] = now.Unix()
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// OAuthCallback completes a provider login and issues our tokens
+func (h *Handler) OAuthCallback(c *fiber.Ctx) error {
+	provider := c.Params("provider")
+	savedState := c.Cookies("oauth_state")
 
-	tokenString, err := tokenByte.SignedString([]byte(cfg.JwtSecret))
+	// State is single-use
+	h.setCookie(c, &fiber.Cookie{
+		Name:     "oauth_state",
+		Value:    "",
+		Path:     oauthStateCookiePath,
+		Expires:  time.Now().Add(-time.Hour),
+		SameSite: "Lax",
+	})
+
+	// The user denied consent or the provider rejected the request
+	if providerError := c.Query("error"); providerError != "" {
+		return apperror.Validation("oauth_login_failed", "oauth login failed: "+providerError)
+	}
+
+	user, err := h.service.CompleteOAuthLogin(c.UserContext(), provider, c.Query("code"), c.Query("state"), savedState)
 	if err != nil {
-		return response.InternalError(c, "Failed to generate token")
-	}
-
-	// Set cookie (HTTP concern - stays in handler)
-	c.Cookie(&fiber.Cookie{
-		Name:     "token",
-		Value:    tokenString,
-		Path:     "/",
-		MaxAge:   cfg.JwtMaxAge * 60,
-		Secure:   false,
-		HTTPOnly: true,
-		Domain:   "localhost",
+		return err
+	}
+
+	return h.startSession(c, user)
+}
+
+// startSession signs a user in, or asks for the second factor when MFA is enabled
+func (h *Handler) startSession(c *fiber.Ctx, user *user.User) error {
+	if user.MFAEnabled {
+		return h.requireMFA(c, user)
+	}
+
+	return h.issueSession(c, user)
+}
+
+// requireMFA answers a correct password with a challenge for the second factor
+func (h *Handler) requireMFA(c *fiber.Ctx, user *user.User) error {
+	challenge, err := h.service.CreateMFAChallenge(c.UserContext(), user)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(AuthResponse{
+		Status:   "mfa_required",
+		MFAToken: challenge,
 	})
+}
+
+// issueSession records a session for a signed-in user and returns its tokens
+func (h *Handler) issueSession(c *fiber.Ctx, user *user.User) error {
+	tokens, err := h.service.StartSession(c.UserContext(), user, deviceOf(c))
+	if err != nil {
+		return err
+	}
+
+	return h.sendTokens(c, tokens)
+}
+
+// sendTokens sets the token cookies and returns the tokens in the body
+func (h *Handler) sendTokens(c *fiber.Ctx, tokens *Tokens) error {
+	// Set cookies (HTTP concern - stays in handler)
+	h.setAuthCookies(c, tokens.AccessToken, tokens.RefreshToken)
 
 	return c.Status(fiber.StatusOK).JSON(AuthResponse{
-		Status: "success",
-		Token:  tokenString,
+		Status:       "success",
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+// deviceOf describes the client of the request for the session list
+func deviceOf(c *fiber.Ctx) Device {
+	return Device{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+	}
+}
+
+// RefreshAccessToken exchanges a refresh token for a new access/refresh token pair
+func (h *Handler) RefreshAccessToken(c *fiber.Ctx) error {
+	var req RefreshTokenRequest
+
+	// Body is optional: browser clients rely on the refresh_token cookie instead
+	if len(c.Body()) > 0 {
+		if err := handler.ParseBody(c, &req); err != nil {
+			return err
+		}
+	}
+	if req.RefreshToken == "" {
+		req.RefreshToken = c.Cookies("refresh_token")
+	}
+	if req.RefreshToken == "" {
+		return ErrRefreshTokenRequired
+	}
+
+	// Call service (rotates the refresh token)
+	tokens, _, err := h.service.RefreshTokens(c.UserContext(), req.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return h.sendTokens(c, tokens)
+}
+
+// setAuthCookies writes the access and refresh token cookies
+func (h *Handler) setAuthCookies(c *fiber.Ctx, accessToken, refreshToken string) {
+	h.setCookie(c, &fiber.Cookie{
+		Name:   "token",
+		Value:  accessToken,
+		Path:   "/",
+		MaxAge: int(h.cookies.AccessTokenMaxAge.Seconds()),
+	})
+
+	// Refresh token is only ever needed by the auth endpoints
+	h.setCookie(c, &fiber.Cookie{
+		Name:   "refresh_token",
+		Value:  refreshToken,
+		Path:   "/api/auth",
+		MaxAge: int(h.cookies.RefreshTokenMaxAge.Seconds()),
 	})
 }
 
+// setCookie writes an HTTP-only cookie with the configured domain and security attributes
+// SameSite is the configured one unless cookie sets its own
+func (h *Handler) setCookie(c *fiber.Ctx, cookie *fiber.Cookie) {
+	cookie.Domain = h.cookies.Domain
+	cookie.Secure = h.cookies.Secure
+	cookie.HTTPOnly = true
+	if cookie.SameSite == "" {
+		cookie.SameSite = h.cookies.SameSite
+	}
+	c.Cookie(cookie)
+}
+
 // LogoutUser handles user logout requests
 func (h *Handler) LogoutUser(c *fiber.Ctx) error {
+	// Revoke the current access token so copies of it stop working immediately
+	if claims, ok := handler.Claims(c); ok {
+		if err := h.service.RevokeAccessToken(c.UserContext(), claims.Id, claims.ExpiresAtTime()); err != nil {
+			return err
+		}
+
+		// End the session too, clients sending tokens in headers may not have the refresh cookie
+		if claims.SessionID != "" {
+			_ = h.service.RevokeSession(c.UserContext(), claims.Subject, claims.SessionID)
+		}
+	}
+
+	// Revoke the refresh token family so the session cannot be renewed
+	if refreshToken := c.Cookies("refresh_token"); refreshToken != "" {
+		// Best effort: an unknown or already revoked token must not block logout
+		_ = h.service.RevokeRefreshToken(c.UserContext(), refreshToken)
+	}
+
 	expired := time.Now().Add(-time.Hour * 24)
-	c.Cookie(&fiber.Cookie{
+	// Same name, path and domain as when set, otherwise browsers keep the originals
+	h.setCookie(c, &fiber.Cookie{
 		Name:    "token",
 		Value:   "",
+		Path:    "/",
+		Expires: expired,
+	})
+	h.setCookie(c, &fiber.Cookie{
+		Name:    "refresh_token",
+		Value:   "",
+		Path:    "/api/auth",
 		Expires: expired,
 	})
 	return response.SuccessWithMessage(c, fiber.StatusOK, "Logged out successfully")
 }
 
+// LogoutAllUser invalidates every token of the current user on all devices
+func (h *Handler) LogoutAllUser(c *fiber.Ctx) error {
+	claims, ok := handler.Claims(c)
+	if !ok {
+		return handler.ErrUnauthenticated
+	}
+
+	if err := h.service.LogoutEverywhere(c.UserContext(), claims.Subject); err != nil {
+		return err
+	}
+
+	return h.LogoutUser(c)
+}
+
+// VerifyEmail handles verification links sent by email
+func (h *Handler) VerifyEmail(c *fiber.Ctx) error {
+	if err := h.service.VerifyEmail(c.UserContext(), c.Params("token")); err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, fiber.StatusOK, "Email verified successfully")
+}
+
+// ResendVerification handles verification email resend requests
+func (h *Handler) ResendVerification(c *fiber.Ctx) error {
+	var req ResendVerificationRequest
+
+	// Parse and validate request
+	if err := handler.ParseAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.service.ResendVerification(c.UserContext(), req.Email); err != nil {
+		return err
+	}
+
+	// Same answer whether or not the address belongs to an unverified account
+	return response.SuccessWithMessage(c, fiber.StatusOK, "If the account exists and is not verified yet, a verification email has been sent")
+}
+
+// ForgotPassword handles password reset requests
+func (h *Handler) ForgotPassword(c *fiber.Ctx) error {
+	var req ForgotPasswordRequest
+
+	// Parse and validate request
+	if err := handler.ParseAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.service.ForgotPassword(c.UserContext(), req.Email); err != nil {
+		return err
+	}
+
+	// Same answer whether or not the email belongs to an account
+	return response.SuccessWithMessage(c, fiber.StatusOK, "If an account with that email exists, a password reset link has been sent")
+}
+
+// ResetPassword handles password resets using an emailed token
+func (h *Handler) ResetPassword(c *fiber.Ctx) error {
+	var req ResetPasswordRequest
+
+	// Parse and validate request
+	if err := handler.ParseAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	err := h.service.ResetPassword(c.UserContext(), &ResetPasswordData{
+		Token:           req.Token,
+		Password:        req.Password,
+		PasswordConfirm: req.PasswordConfirm,
+	})
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, fiber.StatusOK, "Password has been reset, please log in again")
+}
+
+// VerifyMFA handles the second sign-in step
+func (h *Handler) VerifyMFA(c *fiber.Ctx) error {
+	var req MFAVerifyRequest
+
+	// Parse and validate request
+	if err := handler.ParseAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	user, err := h.service.VerifyMFA(c.UserContext(), req.MFAToken, req.Code, c.IP())
+	if err != nil {
+		return err
+	}
+
+	return h.issueSession(c, user)
+}
+
+// EnrollMFA starts TOTP enrollment for the current user
+func (h *Handler) EnrollMFA(c *fiber.Ctx) error {
+	claims, ok := handler.Claims(c)
+	if !ok {
+		return handler.ErrUnauthenticated
+	}
+
+	enrollment, err := h.service.EnrollMFA(c.UserContext(), claims.Subject)
+	if err != nil {
+		return err
+	}
+
+	return response.OK(c, MFAEnrollResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURL: enrollment.URI,
+	})
+}
+
+// ConfirmMFA enables MFA with a code from the newly enrolled authenticator
+func (h *Handler) ConfirmMFA(c *fiber.Ctx) error {
+	claims, ok := handler.Claims(c)
+	if !ok {
+		return handler.ErrUnauthenticated
+	}
+
+	var req MFACodeRequest
+	if err := handler.ParseAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	codes, err := h.service.ConfirmMFA(c.UserContext(), claims.Subject, req.Code)
+	if err != nil {
+		return err
+	}
+
+	return response.OK(c, MFARecoveryCodesResponse{
+		RecoveryCodes: codes,
+	})
+}
+
+// DisableMFA turns MFA off for the current user
+func (h *Handler) DisableMFA(c *fiber.Ctx) error {
+	claims, ok := handler.Claims(c)
+	if !ok {
+		return handler.ErrUnauthenticated
+	}
+
+	var req MFACodeRequest
+	if err := handler.ParseAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.service.DisableMFA(c.UserContext(), claims.Subject, req.Code); err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, fiber.StatusOK, "Two-factor authentication disabled")
+}
+
 // GetMe returns the current authenticated user
 func (h *Handler) GetMe(c *fiber.Ctx) error {
-	// Get user ID from context (set by middleware)
-	userID := c.Locals("userId")
-	if userID == nil {
-		return response.Unauthorized(c, "Unauthorized")
+	// Get the caller from the token claims (set by middleware)
+	claims, ok := handler.Claims(c)
+	if !ok {
+		return handler.ErrUnauthenticated
 	}
 
-	user, err := h.service.GetUserByID(userID.(string))
+	user, err := h.service.GetUserByID(c.UserContext(), claims.Subject)
 	if err != nil {
-		return h.handleServiceError(c, err)
+		return err
 	}
 
 	// Map to response
@@ -159,3 +467,84 @@ func (h *Handler) GetMe(c *fiber.Ctx) error {
 		User: userResponse,
 	})
 }
+
+// JWKS publishes the public keys that verify access tokens
+// Retired keys stay listed until their grace period ends
+func (h *Handler) JWKS(c *fiber.Ctx) error {
+	// Short cache so clients notice a rotation within minutes
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.Status(fiber.StatusOK).JSON(h.keys.JWKS())
+}
+
+// sessionsToResponse maps domain sessions to the response DTO, flagging the caller's own
+func (h *Handler) sessionsToResponse(sessions []*Session, currentID string) SessionListResponse {
+	items := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		items = append(items, SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID.String() == currentID,
+		})
+	}
+	return SessionListResponse{Items: items}
+}
+
+// ListSessions returns the devices the current user is signed in on
+func (h *Handler) ListSessions(c *fiber.Ctx) error {
+	claims, ok := handler.Claims(c)
+	if !ok {
+		return handler.ErrUnauthenticated
+	}
+
+	sessions, err := h.service.ListSessions(c.UserContext(), claims.Subject)
+	if err != nil {
+		return err
+	}
+
+	return response.OK(c, h.sessionsToResponse(sessions, claims.SessionID))
+}
+
+// RevokeSession signs the current user out of one device
+func (h *Handler) RevokeSession(c *fiber.Ctx) error {
+	claims, ok := handler.Claims(c)
+	if !ok {
+		return handler.ErrUnauthenticated
+	}
+
+	if err := h.service.RevokeSession(c.UserContext(), claims.Subject, c.Params("id")); err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, fiber.StatusOK, "Session revoked")
+}
+
+// ListUserSessions returns the sessions of any user (admin)
+func (h *Handler) ListUserSessions(c *fiber.Ctx) error {
+	sessions, err := h.service.ListSessions(c.UserContext(), c.Params("userId"))
+	if err != nil {
+		return err
+	}
+
+	return response.OK(c, h.sessionsToResponse(sessions, ""))
+}
+
+// RevokeUserSession ends one session of any user (admin)
+func (h *Handler) RevokeUserSession(c *fiber.Ctx) error {
+	if err := h.service.RevokeSession(c.UserContext(), c.Params("userId"), c.Params("id")); err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, fiber.StatusOK, "Session revoked")
+}
+
+// RevokeUserSessions ends every session of any user (admin)
+func (h *Handler) RevokeUserSessions(c *fiber.Ctx) error {
+	if err := h.service.RevokeAllSessions(c.UserContext(), c.Params("userId")); err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, fiber.StatusOK, "All sessions revoked")
+}
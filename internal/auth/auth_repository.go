@@ -1,22 +1,103 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/golang-fiber-jwt/internal/user"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// ErrRefreshTokenRevoked is returned when rotating a refresh token that was already revoked
+var ErrRefreshTokenRevoked = errors.New("refresh token already revoked")
+
+// ErrPasswordResetTokenUsed is returned when consuming a reset token that was already used
+var ErrPasswordResetTokenUsed = errors.New("password reset token already used")
+
+// ErrMFAAlreadyEnabled is returned when changing the MFA secret of a user that has MFA enabled
+var ErrMFAAlreadyEnabled = errors.New("mfa already enabled")
+
 // Repository defines the interface for auth data persistence
 // This is a pure interface with no implementation details
 // Infrastructure layer will implement this interface
 type Repository interface {
 	// GetUserByEmail retrieves a user by their email address
-	GetUserByEmail(email string) (*user.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*user.User, error)
 
 	// CreateUser creates a new user in the system
-	CreateUser(user *user.User) error
+	CreateUser(ctx context.Context, user *user.User) error
 
 	// GetUserByID retrieves a user by their ID
-	GetUserByID(id string) (*user.User, error)
+	GetUserByID(ctx context.Context, id string) (*user.User, error)
+
+	// CreateSession persists a new session together with the first refresh token of its family
+	CreateSession(ctx context.Context, session *Session, token *RefreshToken) error
+
+	// GetSession retrieves a session by ID
+	GetSession(ctx context.Context, id uuid.UUID) (*Session, error)
+
+	// ListSessions retrieves a user's active sessions, most recently seen first
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]*Session, error)
+
+	// TouchSession records activity on a session
+	TouchSession(ctx context.Context, id uuid.UUID, seenAt time.Time) error
+
+	// GetRefreshTokenByHash retrieves a refresh token by its hash
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*RefreshToken, error)
+
+	// RotateRefreshToken revokes the current token and stores its replacement atomically
+	RotateRefreshToken(ctx context.Context, currentID uuid.UUID, next *RefreshToken) error
+
+	// RevokeRefreshTokenFamily revokes every active token in a family and the session it belongs to
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+
+	// RevokeUserRefreshTokens revokes every active refresh token and session of a user
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error
+
+	// SignOutOtherSessions bumps the user's token version and revokes every active refresh
+	// token and session but keepSessionID's (uuid.Nil keeps none), in one transaction
+	SignOutOtherSessions(ctx context.Context, userID, keepSessionID uuid.UUID) error
+
+	// IncrementTokenVersion bumps the user's token version, invalidating older access tokens
+	IncrementTokenVersion(ctx context.Context, userID uuid.UUID) error
+
+	// MarkUserVerified flags the user's email address as verified
+	MarkUserVerified(ctx context.Context, userID uuid.UUID) error
+
+	// ClaimVerificationSend records a verification email unless one was sent within cooldown
+	// Returns false when the cooldown has not elapsed yet
+	ClaimVerificationSend(ctx context.Context, userID uuid.UUID, cooldown time.Duration) (bool, error)
+
+	// CreatePasswordResetToken stores a reset token, invalidating the user's previous ones
+	CreatePasswordResetToken(ctx context.Context, token *PasswordResetToken) error
+
+	// GetPasswordResetTokenByHash retrieves a reset token by its hash
+	GetPasswordResetTokenByHash(ctx context.Context, hash string) (*PasswordResetToken, error)
+
+	// UpdatePasswordHash swaps the password hash if it still equals currentHash
+	UpdatePasswordHash(ctx context.Context, userID uuid.UUID, currentHash, newHash string) error
+
+	// SetPendingMFASecret stores an encrypted TOTP secret awaiting confirmation
+	// It fails with ErrMFAAlreadyEnabled once MFA is active
+	SetPendingMFASecret(ctx context.Context, userID uuid.UUID, encryptedSecret string) error
+
+	// EnableMFA activates MFA and replaces the user's recovery codes
+	EnableMFA(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error
+
+	// DisableMFA deactivates MFA and deletes the secret and recovery codes
+	DisableMFA(ctx context.Context, userID uuid.UUID) error
+
+	// ClaimMFAStep records a used TOTP step, returning false if it (or a later one) was already used
+	ClaimMFAStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+
+	// UseRecoveryCode consumes an unused recovery code, returning false if there is none
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+
+	// ResetPassword consumes the reset token, stores the new password hash and
+	// revokes every existing session of the user, all in one transaction
+	ResetPassword(ctx context.Context, tokenID, userID uuid.UUID, passwordHash string) error
 }
 
 // authRepository implements Repository interface
@@ -30,9 +111,9 @@ func NewAuthRepository(db *gorm.DB) Repository {
 }
 
 // GetUserByEmail retrieves a user by email
-func (r *authRepository) GetUserByEmail(email string) (*user.User, error) {
+func (r *authRepository) GetUserByEmail(ctx context.Context, email string) (*user.User, error) {
 	var model user.User
-	result := r.db.Where("email = ?", email).First(&model)
+	result := r.db.WithContext(ctx).Where("email = ? AND deleted_at IS NULL", email).First(&model)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -40,17 +121,418 @@ func (r *authRepository) GetUserByEmail(email string) (*user.User, error) {
 }
 
 // CreateUser creates a new user
-func (r *authRepository) CreateUser(user *user.User) error {
-	result := r.db.Create(user)
+func (r *authRepository) CreateUser(ctx context.Context, user *user.User) error {
+	result := r.db.WithContext(ctx).Create(user)
 	return result.Error
 }
 
 // GetUserByID retrieves a user by ID
-func (r *authRepository) GetUserByID(id string) (*user.User, error) {
+func (r *authRepository) GetUserByID(ctx context.Context, id string) (*user.User, error) {
 	var model user.User
-	result := r.db.Where("id = ?", id).First(&model)
+	result := r.db.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", id).First(&model)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &model, nil
 }
+
+// CreateSession creates the session row and its first refresh token in one transaction
+func (r *authRepository) CreateSession(ctx context.Context, session *Session, token *RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&SessionModel{
+			ID:         session.ID,
+			UserID:     session.UserID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(toRefreshTokenModel(token)).Error
+	})
+}
+
+// GetSession retrieves a session by ID
+func (r *authRepository) GetSession(ctx context.Context, id uuid.UUID) (*Session, error) {
+	var model SessionModel
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&model).Error; err != nil {
+		return nil, err
+	}
+	return toSessionDomain(&model), nil
+}
+
+// ListSessions retrieves a user's active sessions, most recently seen first
+func (r *authRepository) ListSessions(ctx context.Context, userID uuid.UUID) ([]*Session, error) {
+	var models []SessionModel
+	err := r.db.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("last_seen_at DESC").
+		Find(&models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(models))
+	for i := range models {
+		sessions = append(sessions, toSessionDomain(&models[i]))
+	}
+	return sessions, nil
+}
+
+// TouchSession records activity on a session
+func (r *authRepository) TouchSession(ctx context.Context, id uuid.UUID, seenAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&SessionModel{}).Where("id = ?", id).Update("last_seen_at", seenAt).Error
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by hash
+func (r *authRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*RefreshToken, error) {
+	var model RefreshTokenModel
+	result := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&model)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return toRefreshTokenDomain(&model), nil
+}
+
+// RotateRefreshToken marks the current token as replaced and creates the next one
+// The update is conditional on the token still being active, so two concurrent
+// refreshes with the same token cannot both succeed
+func (r *authRepository) RotateRefreshToken(ctx context.Context, currentID uuid.UUID, next *RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&RefreshTokenModel{}).
+			Where("id = ? AND revoked_at IS NULL", currentID).
+			Updates(map[string]interface{}{
+				"revoked_at":  time.Now(),
+				"replaced_by": next.ID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenRevoked
+		}
+
+		return tx.Create(toRefreshTokenModel(next)).Error
+	})
+}
+
+// RevokeRefreshTokenFamily revokes all active tokens sharing a family ID and their session
+func (r *authRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		if err := tx.Model(&RefreshTokenModel{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&SessionModel{}).
+			Where("id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// RevokeUserRefreshTokens revokes all active refresh tokens and sessions of a user
+func (r *authRepository) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return revokeUserSessions(tx, userID, uuid.Nil, time.Now())
+	})
+}
+
+// SignOutOtherSessions bumps the token version and revokes the other sessions of a user
+func (r *authRepository) SignOutOtherSessions(ctx context.Context, userID, keepSessionID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&user.User{}).
+			Where("id = ? AND deleted_at IS NULL", userID).
+			UpdateColumn("token_version", gorm.Expr("token_version + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return revokeUserSessions(tx, userID, keepSessionID, time.Now())
+	})
+}
+
+// IncrementTokenVersion bumps the token version of a user
+func (r *authRepository) IncrementTokenVersion(ctx context.Context, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Model(&user.User{}).
+		Where("id = ?", userID).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// MarkUserVerified sets verified to true for a user
+func (r *authRepository) MarkUserVerified(ctx context.Context, userID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Model(&user.User{}).
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"verified":   true,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ClaimVerificationSend stamps verification_sent_at if the cooldown has elapsed
+// The check and the write happen in one statement so concurrent resends cannot both pass
+func (r *authRepository) ClaimVerificationSend(ctx context.Context, userID uuid.UUID, cooldown time.Duration) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&user.User{}).
+		Where("id = ? AND (verification_sent_at IS NULL OR verification_sent_at <= ?)", userID, now.Add(-cooldown)).
+		UpdateColumn("verification_sent_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdatePasswordHash replaces the password hash of a user
+// Matching on the current hash keeps a concurrent password change from being overwritten
+func (r *authRepository) UpdatePasswordHash(ctx context.Context, userID uuid.UUID, currentHash, newHash string) error {
+	result := r.db.WithContext(ctx).Model(&user.User{}).
+		Where("id = ? AND password = ?", userID, currentHash).
+		UpdateColumn("password", newHash)
+	return result.Error
+}
+
+// CreatePasswordResetToken creates a reset token and marks older unused ones as used
+func (r *authRepository) CreatePasswordResetToken(ctx context.Context, token *PasswordResetToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&PasswordResetTokenModel{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Create(&PasswordResetTokenModel{
+			ID:        token.ID,
+			UserID:    token.UserID,
+			TokenHash: token.TokenHash,
+			ExpiresAt: token.ExpiresAt,
+			CreatedAt: token.CreatedAt,
+		}).Error
+	})
+}
+
+// GetPasswordResetTokenByHash retrieves a reset token by hash
+func (r *authRepository) GetPasswordResetTokenByHash(ctx context.Context, hash string) (*PasswordResetToken, error) {
+	var model PasswordResetTokenModel
+	result := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&model)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &PasswordResetToken{
+		ID:        model.ID,
+		UserID:    model.UserID,
+		TokenHash: model.TokenHash,
+		ExpiresAt: model.ExpiresAt,
+		UsedAt:    model.UsedAt,
+		CreatedAt: model.CreatedAt,
+	}, nil
+}
+
+// ResetPassword consumes a reset token and replaces the user's password
+func (r *authRepository) ResetPassword(ctx context.Context, tokenID, userID uuid.UUID, passwordHash string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// Conditional update makes the token single-use even under concurrency
+		result := tx.Model(&PasswordResetTokenModel{}).
+			Where("id = ? AND used_at IS NULL", tokenID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrPasswordResetTokenUsed
+		}
+
+		result = tx.Model(&user.User{}).
+			Where("id = ? AND deleted_at IS NULL", userID).
+			Updates(map[string]interface{}{
+				"password":      passwordHash,
+				"token_version": gorm.Expr("token_version + 1"),
+				"updated_at":    now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return revokeUserSessions(tx, userID, uuid.Nil, now)
+	})
+}
+
+// SetPendingMFASecret stores a new unconfirmed TOTP secret
+func (r *authRepository) SetPendingMFASecret(ctx context.Context, userID uuid.UUID, encryptedSecret string) error {
+	result := r.db.WithContext(ctx).Model(&user.User{}).
+		Where("id = ? AND mfa_enabled = ?", userID, false).
+		UpdateColumn("mfa_secret", encryptedSecret)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+
+	return nil
+}
+
+// EnableMFA turns MFA on and stores a fresh set of recovery codes
+func (r *authRepository) EnableMFA(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&user.User{}).
+			Where("id = ? AND mfa_enabled = ?", userID, false).
+			Updates(map[string]interface{}{
+				"mfa_enabled":   true,
+				"mfa_last_step": step,
+				"updated_at":    time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrMFAAlreadyEnabled
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&MFARecoveryCodeModel{}).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		codes := make([]MFARecoveryCodeModel, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, MFARecoveryCodeModel{
+				ID:        uuid.New(),
+				UserID:    userID,
+				CodeHash:  hash,
+				CreatedAt: now,
+			})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// DisableMFA turns MFA off and removes its secret material
+func (r *authRepository) DisableMFA(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&user.User{}).
+			Where("id = ?", userID).
+			Updates(map[string]interface{}{
+				"mfa_enabled":   false,
+				"mfa_secret":    "",
+				"mfa_last_step": 0,
+				"updated_at":    time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Where("user_id = ?", userID).Delete(&MFARecoveryCodeModel{}).Error
+	})
+}
+
+// ClaimMFAStep advances mfa_last_step so each TOTP code is accepted only once
+func (r *authRepository) ClaimMFAStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&user.User{}).
+		Where("id = ? AND mfa_last_step < ?", userID, step).
+		UpdateColumn("mfa_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UseRecoveryCode marks a recovery code as used
+func (r *authRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&MFARecoveryCodeModel{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// toRefreshTokenDomain converts database model to domain model
+func toRefreshTokenDomain(model *RefreshTokenModel) *RefreshToken {
+	return &RefreshToken{
+		ID:         model.ID,
+		UserID:     model.UserID,
+		FamilyID:   model.FamilyID,
+		TokenHash:  model.TokenHash,
+		ExpiresAt:  model.ExpiresAt,
+		RevokedAt:  model.RevokedAt,
+		ReplacedBy: model.ReplacedBy,
+		CreatedAt:  model.CreatedAt,
+	}
+}
+
+// toRefreshTokenModel converts domain model to database model
+func toRefreshTokenModel(token *RefreshToken) *RefreshTokenModel {
+	return &RefreshTokenModel{
+		ID:         token.ID,
+		UserID:     token.UserID,
+		FamilyID:   token.FamilyID,
+		TokenHash:  token.TokenHash,
+		ExpiresAt:  token.ExpiresAt,
+		RevokedAt:  token.RevokedAt,
+		ReplacedBy: token.ReplacedBy,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+// revokeUserSessions revokes every active refresh token and session of a user within tx,
+// except the session keepSessionID and its refresh token family unless it is uuid.Nil
+func revokeUserSessions(tx *gorm.DB, userID, keepSessionID uuid.UUID, now time.Time) error {
+	if err := tx.Model(&RefreshTokenModel{}).
+		Where("user_id = ? AND revoked_at IS NULL AND family_id <> ?", userID, keepSessionID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&SessionModel{}).
+		Where("user_id = ? AND revoked_at IS NULL AND id <> ?", userID, keepSessionID).
+		Update("revoked_at", now).Error
+}
+
+// toSessionDomain converts database model to domain model
+func toSessionDomain(model *SessionModel) *Session {
+	return &Session{
+		ID:         model.ID,
+		UserID:     model.UserID,
+		UserAgent:  model.UserAgent,
+		IP:         model.IP,
+		CreatedAt:  model.CreatedAt,
+		LastSeenAt: model.LastSeenAt,
+		RevokedAt:  model.RevokedAt,
+	}
+}
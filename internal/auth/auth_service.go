@@ -1,35 +1,139 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/golang-fiber-jwt/internal/user"
 	"github.com/golang-fiber-jwt/pkg/hashing"
+	"github.com/golang-fiber-jwt/pkg/jwt"
+	"github.com/golang-fiber-jwt/pkg/lockout"
+	"github.com/golang-fiber-jwt/pkg/loglevel"
+	"github.com/golang-fiber-jwt/pkg/mailer"
+	"github.com/golang-fiber-jwt/pkg/oauth"
+	"github.com/golang-fiber-jwt/pkg/requestid"
+	"github.com/golang-fiber-jwt/pkg/revocation"
+	"github.com/golang-fiber-jwt/pkg/signedtoken"
+	"github.com/golang-fiber-jwt/pkg/totp"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // Service defines the interface for auth business logic
 type Service interface {
-	SignUp(data *SignUpData) (*user.User, error)
-	SignIn(email, password string) (token string, user *user.User, err error)
-	GetUserByID(id string) (*user.User, error)
+	SignUp(ctx context.Context, data *SignUpData) (*user.User, error)
+	SignIn(ctx context.Context, email, password string, device Device) (*Tokens, *user.User, error)
+	GetUserByID(ctx context.Context, id string) (*user.User, error)
+	StartSession(ctx context.Context, user *user.User, device Device) (*Tokens, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (*Tokens, *user.User, error)
+	RevokeRefreshToken(ctx context.Context, refreshToken string) error
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	LogoutEverywhere(ctx context.Context, userID string) error
+	ValidateAccessToken(ctx context.Context, jti, userID, sessionID string, tokenVersion int) error
+	ListSessions(ctx context.Context, userID string) ([]*Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID string) error
+	SignOutOtherSessions(ctx context.Context, userID, keepSessionID string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, data *ResetPasswordData) error
+	BeginOAuthLogin(ctx context.Context, provider string) (authURL, state string, err error)
+	CompleteOAuthLogin(ctx context.Context, provider, code, state, savedState string) (*user.User, error)
+	EnrollMFA(ctx context.Context, userID string) (*MFAEnrollment, error)
+	ConfirmMFA(ctx context.Context, userID, code string) (recoveryCodes []string, err error)
+	DisableMFA(ctx context.Context, userID, code string) error
+	CreateMFAChallenge(ctx context.Context, user *user.User) (string, error)
+	VerifyMFA(ctx context.Context, challenge, code, ip string) (*user.User, error)
 }
 
+// Defaults used when the corresponding Config field is not set
+const (
+	defaultRefreshTokenTTL            = 7 * 24 * time.Hour
+	defaultVerificationTTL            = 24 * time.Hour
+	defaultVerificationResendCooldown = time.Minute
+	defaultPasswordResetTTL           = time.Hour
+	defaultMFAChallengeTTL            = 5 * time.Minute
+	defaultMFAMaxAttempts             = 3
+	defaultMFAIssuer                  = "golang-fiber-jwt"
+)
+
+// verificationPurpose scopes signed tokens to email verification
+const verificationPurpose = "email_verification"
+
+// oauthStatePurpose scopes signed OAuth login state, suffixed with the provider name
+const oauthStatePurpose = "oauth_state:"
+
+// oauthStateTTL bounds how long the user may take on the provider's consent page
+const oauthStateTTL = 10 * time.Minute
+
+// mfaChallengePurpose scopes signed tokens to the second sign-in step
+const mfaChallengePurpose = "mfa_challenge"
+
+// recoveryCodeCount is how many one-time recovery codes a user gets when enabling MFA
+const recoveryCodeCount = 10
+
+// sessionLastSeenResolution throttles last-seen writes to one per session per interval
+const sessionLastSeenResolution = time.Minute
+
+// maxUserAgentLength is the size of the sessions.user_agent column
+const maxUserAgentLength = 255
+
 // service implements the Service interface
 // Pure business logic - no framework dependencies
 type service struct {
-	repo Repository
+	repo        Repository
+	revocations revocation.Store
+	tokens      jwt.TokenService
+	mailer      mailer.Mailer
+	cfg         Config
 }
 
 // NewAuthService creates a new auth service
-func NewAuthService(repo Repository) Service {
-	return &service{repo: repo}
+func NewAuthService(repo Repository, revocations revocation.Store, tokens jwt.TokenService, mail mailer.Mailer, cfg Config) Service {
+	if cfg.RefreshTokenTTL <= 0 {
+		cfg.RefreshTokenTTL = defaultRefreshTokenTTL
+	}
+	if cfg.VerificationTTL <= 0 {
+		cfg.VerificationTTL = defaultVerificationTTL
+	}
+	if cfg.VerificationResendCooldown <= 0 {
+		cfg.VerificationResendCooldown = defaultVerificationResendCooldown
+	}
+	if cfg.PasswordResetTTL <= 0 {
+		cfg.PasswordResetTTL = defaultPasswordResetTTL
+	}
+	if cfg.VerificationKeys == nil {
+		cfg.VerificationKeys = signedtoken.NewKeyring([]byte(cfg.VerificationSecret))
+	}
+	if cfg.PasswordHasher == nil {
+		cfg.PasswordHasher = hashing.Default()
+	}
+	if cfg.Lockout == nil {
+		cfg.Lockout = lockout.NewGuard(lockout.NewMemoryStore(0), lockout.Config{})
+	}
+	if cfg.MFAChallengeTTL <= 0 {
+		cfg.MFAChallengeTTL = defaultMFAChallengeTTL
+	}
+	if cfg.MFAMaxAttempts <= 0 {
+		cfg.MFAMaxAttempts = defaultMFAMaxAttempts
+	}
+	if cfg.MFAIssuer == "" {
+		cfg.MFAIssuer = defaultMFAIssuer
+	}
+	return &service{repo: repo, revocations: revocations, tokens: tokens, mailer: mail, cfg: cfg}
 }
 
 // SignUp handles user registration business logic
-func (s *service) SignUp(data *SignUpData) (*user.User, error) {
+func (s *service) SignUp(ctx context.Context, data *SignUpData) (*user.User, error) {
 	// Validate input
 	if err := s.validateSignUpData(data); err != nil {
 		return nil, err
@@ -37,11 +141,11 @@ func (s *service) SignUp(data *SignUpData) (*user.User, error) {
 
 	// Check if passwords match
 	if data.Password != data.PasswordConfirm {
-		return nil, fmt.Errorf("passwords do not match")
+		return nil, ErrPasswordMismatch
 	}
 
 	// Hash password
-	hashedPassword, err := hashing.HashPassword(data.Password)
+	hashedPassword, err := s.cfg.PasswordHasher.Hash(data.Password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -62,58 +166,914 @@ func (s *service) SignUp(data *SignUpData) (*user.User, error) {
 	}
 
 	// Save to repository
-	if err := s.repo.CreateUser(user); err != nil {
+	if err := s.repo.CreateUser(ctx, user); err != nil {
 		if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
-			return nil, fmt.Errorf("user with that email already exists")
+			return nil, ErrUserExists
 		}
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	// Delivery problems must not fail the signup: the user can ask for a resend
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		requestid.Printf(ctx, loglevel.Error, "failed to send verification email to user %s: %v", user.ID, err)
+	}
+
 	return user, nil
 }
 
 // SignIn handles user authentication business logic
-func (s *service) SignIn(email, password string) (string, *user.User, error) {
+// It starts a session on device, unless MFA is enabled: then no tokens are returned
+// and the session starts once the second factor is verified
+// Failed attempts are throttled per account and IP address (see lockout.Guard)
+func (s *service) SignIn(ctx context.Context, email, password string, device Device) (*Tokens, *user.User, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	// Checked first so a locked account cannot be probed, even with the right password
+	if err := s.cfg.Lockout.Check(email, device.IP); err != nil {
+		return nil, nil, err
+	}
+
 	// Get user by email
-	user, err := s.repo.GetUserByEmail(strings.ToLower(strings.TrimSpace(email)))
+	user, err := s.repo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return "", nil, fmt.Errorf("invalid email or password")
+		// A failed or canceled lookup is not a failed attempt
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+		s.recordFailedSignIn(ctx, email, device.IP)
+		return nil, nil, ErrInvalidCredentials
 	}
 
 	// Verify password
-	if err := hashing.VerifyPassword(user.Password, password); err != nil {
-		return "", nil, fmt.Errorf("invalid email or password")
+	if err := s.cfg.PasswordHasher.Verify(user.Password, password); err != nil {
+		s.recordFailedSignIn(ctx, email, device.IP)
+		return nil, nil, ErrInvalidCredentials
 	}
 
-	// Generate token (simple random token for now - will be enhanced in handler layer with JWT)
-	token, err := hashing.GenerateToken()
+	// Best effort, the password was right
+	// With MFA the failures are only cleared by VerifyMFA, or the password step would reset the code guessing
+	if !user.MFAEnabled {
+		if err := s.cfg.Lockout.Succeed(email); err != nil {
+			requestid.Printf(ctx, loglevel.Warn, "auth: %v", err)
+		}
+	}
+
+	// Upgrade hashes made with an outdated algorithm or cost while the plain password is at hand
+	if s.cfg.PasswordHasher.NeedsRehash(user.Password) {
+		s.rehashPassword(ctx, user, password)
+	}
+
+	// Checked after the password so unverified accounts cannot be probed
+	if s.cfg.RequireVerifiedEmail && !user.Verified {
+		return nil, nil, ErrEmailNotVerified
+	}
+
+	// No tokens until the second factor is verified
+	if user.MFAEnabled {
+		return nil, user, nil
+	}
+
+	tokens, err := s.StartSession(ctx, user, device)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, nil, err
 	}
 
-	return token, user, nil
+	return tokens, user, nil
+}
+
+// recordFailedSignIn counts a failed attempt, unknown emails included so they cannot be told apart
+func (s *service) recordFailedSignIn(ctx context.Context, email, ip string) {
+	if err := s.cfg.Lockout.Fail(email, ip); err != nil {
+		requestid.Printf(ctx, loglevel.Warn, "auth: %v", err)
+	}
+}
+
+// StartSession records a session for a freshly authenticated user and issues its tokens
+// The session ID is the refresh token family and the sid claim of the access token
+func (s *service) StartSession(ctx context.Context, user *user.User, device Device) (*Tokens, error) {
+	now := time.Now()
+	session := &Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		UserAgent:  truncate(device.UserAgent, maxUserAgentLength),
+		IP:         device.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	refreshToken, record, err := s.newRefreshToken(user.ID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateSession(ctx, session, record); err != nil {
+		return nil, fmt.Errorf("failed to store session: %w", err)
+	}
+
+	accessToken, err := s.issueAccessToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tokens{AccessToken: accessToken, RefreshToken: refreshToken, SessionID: session.ID}, nil
+}
+
+// issueAccessToken signs a short-lived access token bound to a session
+func (s *service) issueAccessToken(user *user.User, sessionID uuid.UUID) (string, error) {
+	token, _, err := s.tokens.Issue(jwt.Claims{
+		StandardClaims: jwt.StandardClaims{Subject: user.ID.String()},
+		Role:           user.Role,
+		TokenVersion:   user.TokenVersion,
+		SessionID:      sessionID.String(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return token, nil
 }
 
 // GetUserByID retrieves a user by their ID
-func (s *service) GetUserByID(id string) (*user.User, error) {
-	return s.repo.GetUserByID(id)
+func (s *service) GetUserByID(ctx context.Context, id string) (*user.User, error) {
+	return s.repo.GetUserByID(ctx, id)
+}
+
+// RefreshTokens exchanges a refresh token for a new one (rotation) and a new access token
+// Presenting a token that was already rotated is treated as theft and
+// revokes every token in its family, forcing the user to log in again
+func (s *service) RefreshTokens(ctx context.Context, refreshToken string) (*Tokens, *user.User, error) {
+	if refreshToken == "" {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	current, err := s.repo.GetRefreshTokenByHash(ctx, hashing.HashToken(refreshToken))
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	if current.RevokedAt != nil {
+		if err := s.repo.RevokeRefreshTokenFamily(ctx, current.FamilyID); err != nil {
+			return nil, nil, fmt.Errorf("failed to revoke refresh token family: %w", err)
+		}
+		return nil, nil, ErrRefreshTokenReuse
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, nil, ErrRefreshTokenExpired
+	}
+
+	user, err := s.repo.GetUserByID(ctx, current.UserID.String())
+	if err != nil {
+		return nil, nil, ErrUserNotFound
+	}
+
+	token, next, err := s.newRefreshToken(current.UserID, current.FamilyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.repo.RotateRefreshToken(ctx, current.ID, next); err != nil {
+		// Lost a race against another refresh with the same token
+		if errors.Is(err, ErrRefreshTokenRevoked) {
+			if err := s.repo.RevokeRefreshTokenFamily(ctx, current.FamilyID); err != nil {
+				return nil, nil, fmt.Errorf("failed to revoke refresh token family: %w", err)
+			}
+			return nil, nil, ErrRefreshTokenReuse
+		}
+		return nil, nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	// Best effort, the refresh itself already succeeded
+	if err := s.repo.TouchSession(ctx, current.FamilyID, time.Now()); err != nil {
+		requestid.Printf(ctx, loglevel.Warn, "auth: failed to record session activity: %v", err)
+	}
+
+	accessToken, err := s.issueAccessToken(user, current.FamilyID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &Tokens{AccessToken: accessToken, RefreshToken: token, SessionID: current.FamilyID}, user, nil
+}
+
+// RevokeRefreshToken revokes the family of the given refresh token (used on logout)
+func (s *service) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	current, err := s.repo.GetRefreshTokenByHash(ctx, hashing.HashToken(refreshToken))
+	if err != nil {
+		return ErrInvalidRefreshToken
+	}
+
+	if err := s.repo.RevokeRefreshTokenFamily(ctx, current.FamilyID); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+// RevokeAccessToken revokes a single access token until it would have expired
+func (s *service) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return ErrInvalidToken
+	}
+
+	if err := s.revocations.Revoke(jti, expiresAt); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// LogoutEverywhere invalidates every access and refresh token issued to the user
+func (s *service) LogoutEverywhere(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if err := s.repo.IncrementTokenVersion(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to bump token version: %w", err)
+	}
+
+	if err := s.repo.RevokeUserRefreshTokens(ctx, id); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// ValidateAccessToken checks that an otherwise valid access token was not revoked,
+// either individually (jti), with its session or by a token version bump
+func (s *service) ValidateAccessToken(ctx context.Context, jti, userID, sessionID string, tokenVersion int) error {
+	if jti == "" {
+		return ErrInvalidToken
+	}
+
+	revoked, err := s.revocations.IsRevoked(jti)
+	if err != nil {
+		return fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return ErrUserNotFound
+	}
+	if user.TokenVersion != tokenVersion {
+		return ErrTokenRevoked
+	}
+
+	// Tokens issued before sessions were recorded carry no sid and expire on their own
+	if sessionID == "" {
+		return nil
+	}
+
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	session, err := s.repo.GetSession(ctx, id)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err != nil || session.UserID != user.ID || session.RevokedAt != nil {
+		return ErrTokenRevoked
+	}
+
+	// Best effort and throttled, last seen is informational
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) >= sessionLastSeenResolution {
+		if err := s.repo.TouchSession(ctx, session.ID, now); err != nil {
+			requestid.Printf(ctx, loglevel.Warn, "auth: failed to record session activity: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// ListSessions returns the user's active sessions
+func (s *service) ListSessions(ctx context.Context, userID string) ([]*Session, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	sessions, err := s.repo.ListSessions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs one of the user's devices out
+// Its refresh tokens stop working and its access tokens are rejected by ValidateAccessToken
+func (s *service) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+
+	// Scoped to the owner so users cannot probe or revoke each other's sessions
+	session, err := s.repo.GetSession(ctx, id)
+	if err != nil || session.UserID.String() != userID || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+
+	if err := s.repo.RevokeRefreshTokenFamily(ctx, session.ID); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// SignOutOtherSessions invalidates every access token of the user and revokes every session
+// but keepSessionID, which renews its access token with its refresh token (used after a
+// password change); an empty or unknown keepSessionID keeps none
+func (s *service) SignOutOtherSessions(ctx context.Context, userID, keepSessionID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	keep, _ := uuid.Parse(keepSessionID)
+
+	if err := s.repo.SignOutOtherSessions(ctx, id, keep); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to sign out other sessions: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllSessions signs the user out of every device without bumping the token version
+func (s *service) RevokeAllSessions(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if err := s.repo.RevokeUserRefreshTokens(ctx, id); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail marks the user behind a verification token as verified
+func (s *service) VerifyEmail(ctx context.Context, token string) error {
+	userID, err := s.cfg.VerificationKeys.Verify(verificationPurpose, token)
+	if err != nil {
+		if errors.Is(err, signedtoken.ErrExpired) {
+			return ErrVerificationTokenExpired
+		}
+		return ErrInvalidVerificationToken
+	}
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+
+	user, err := s.repo.GetUserByID(ctx, id.String())
+	if err != nil {
+		return ErrInvalidVerificationToken
+	}
+
+	// Verification links may be clicked more than once
+	if user.Verified {
+		return nil
+	}
+
+	if err := s.repo.MarkUserVerified(ctx, id); err != nil {
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+	return nil
+}
+
+// ResendVerification sends a new verification email, at most once per cooldown
+// Unknown, already verified and throttled addresses are silently ignored so the
+// endpoint cannot be used to discover accounts
+func (s *service) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.repo.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil || user.Verified {
+		return nil
+	}
+
+	return s.sendVerificationEmail(ctx, user)
+}
+
+// ForgotPassword emails a single-use reset link to local accounts
+// It returns nil for unknown emails and non-local accounts so callers
+// can answer identically whether or not the account exists
+func (s *service) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.repo.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil || user.Provider != "local" {
+		return nil
+	}
+
+	token, err := hashing.GenerateToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	// Only the hash is stored, the plain token only ever exists in the email
+	now := time.Now()
+	record := &PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashing.HashToken(token),
+		ExpiresAt: now.Add(s.cfg.PasswordResetTTL),
+		CreatedAt: now,
+	}
+	if err := s.repo.CreatePasswordResetToken(ctx, record); err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	if err := s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password:\n\n%s?token=%s\n\nThe link expires in %s. If you did not ask for a reset, you can ignore this email.",
+			user.Name, s.cfg.PasswordResetURL, url.QueryEscape(token), s.cfg.PasswordResetTTL),
+	}); err != nil {
+		// Logged rather than returned so delivery failures do not reveal the account
+		requestid.Printf(ctx, loglevel.Error, "failed to send password reset email to user %s: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token and signs the user out everywhere
+func (s *service) ResetPassword(ctx context.Context, data *ResetPasswordData) error {
+	if data.Password != data.PasswordConfirm {
+		return ErrPasswordMismatch
+	}
+
+	record, err := s.repo.GetPasswordResetTokenByHash(ctx, hashing.HashToken(data.Token))
+	if err != nil || record.UsedAt != nil || time.Now().After(record.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.repo.GetUserByID(ctx, record.UserID.String())
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+
+	if err := s.cfg.PasswordPolicy.Validate(data.Password, user.Email, user.Name); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.cfg.PasswordHasher.Hash(data.Password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.repo.ResetPassword(ctx, record.ID, record.UserID, hashedPassword); err != nil {
+		if errors.Is(err, ErrPasswordResetTokenUsed) || errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	return nil
+}
+
+// BeginOAuthLogin builds the provider consent URL with a fresh state and PKCE verifier
+// The returned state is signed and must be handed back to CompleteOAuthLogin (e.g. via a cookie)
+func (s *service) BeginOAuthLogin(ctx context.Context, provider string) (string, string, error) {
+	p, ok := s.cfg.OAuthProviders.Get(provider)
+	if !ok {
+		return "", "", ErrUnsupportedProvider
+	}
+
+	state, err := oauth.GenerateState()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate state: %w", err)
+	}
+	verifier, err := oauth.GenerateVerifier()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate verifier: %w", err)
+	}
+
+	// Neither value contains a space (base64url), so it can separate them
+	savedState, err := s.cfg.VerificationKeys.Sign(oauthStatePurpose+p.Name,
+		state+" "+verifier, time.Now().Add(oauthStateTTL))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign state: %w", err)
+	}
+
+	return p.AuthCodeURL(state, verifier), savedState, nil
+}
+
+// CompleteOAuthLogin checks the state, exchanges the code and signs the provider's user in,
+// creating the account on first login
+func (s *service) CompleteOAuthLogin(ctx context.Context, provider, code, state, savedState string) (*user.User, error) {
+	p, ok := s.cfg.OAuthProviders.Get(provider)
+	if !ok {
+		return nil, ErrUnsupportedProvider
+	}
+
+	subject, err := s.cfg.VerificationKeys.Verify(oauthStatePurpose+p.Name, savedState)
+	if err != nil {
+		return nil, ErrInvalidOAuthState
+	}
+	expectedState, verifier, ok := strings.Cut(subject, " ")
+	if !ok || state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(expectedState)) != 1 {
+		return nil, ErrInvalidOAuthState
+	}
+
+	token, err := p.Exchange(ctx, code, verifier)
+	if err != nil {
+		requestid.Printf(ctx, loglevel.Warn, "oauth code exchange with %s failed: %v", p.Name, err)
+		return nil, ErrOAuthLoginFailed
+	}
+
+	profile, err := p.FetchProfile(ctx, token)
+	if err != nil {
+		if errors.Is(err, oauth.ErrEmailMissing) {
+			return nil, ErrProviderEmailMissing
+		}
+		requestid.Printf(ctx, loglevel.Warn, "oauth profile request to %s failed: %v", p.Name, err)
+		return nil, ErrOAuthLoginFailed
+	}
+
+	// An unverified address could belong to someone else's account
+	if !profile.EmailVerified {
+		return nil, ErrProviderEmailNotVerified
+	}
+
+	return s.upsertOAuthUser(ctx, p.Name, profile)
+}
+
+// upsertOAuthUser returns the user registered with the profile's email, creating it if needed
+func (s *service) upsertOAuthUser(ctx context.Context, provider string, profile *oauth.Profile) (*user.User, error) {
+	existing, err := s.repo.GetUserByEmail(ctx, profile.Email)
+	if err == nil {
+		// Accounts are not linked implicitly: that would let a provider login take over a local account
+		if existing.Provider != provider {
+			return nil, ErrProviderMismatch
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+
+	now := time.Now()
+	created := &user.User{
+		ID:        uuid.New(),
+		Name:      profile.Name,
+		Email:     profile.Email,
+		Role:      "user",
+		Provider:  provider,
+		Photo:     s.getPhotoOrDefault(profile.Picture),
+		Verified:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if created.Name == "" {
+		created.Name = profile.Email
+	}
+
+	// Social accounts have no password: the empty hash never verifies
+	if err := s.repo.CreateUser(ctx, created); err != nil {
+		if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "unique") {
+			return nil, ErrProviderMismatch
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return created, nil
+}
+
+// EnrollMFA generates a TOTP secret and stores it encrypted until ConfirmMFA
+// Enrolling again before confirming replaces the pending secret
+func (s *service) EnrollMFA(ctx context.Context, userID string) (*MFAEnrollment, error) {
+	if s.cfg.MFASecretBox == nil {
+		return nil, ErrMFANotConfigured
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate mfa secret: %w", err)
+	}
+
+	encrypted, err := s.cfg.MFASecretBox.Seal([]byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt mfa secret: %w", err)
+	}
+
+	if err := s.repo.SetPendingMFASecret(ctx, user.ID, encrypted); err != nil {
+		if errors.Is(err, ErrMFAAlreadyEnabled) {
+			return nil, ErrMFAEnabled
+		}
+		return nil, fmt.Errorf("failed to store mfa secret: %w", err)
+	}
+
+	return &MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.cfg.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmMFA enables MFA once the user proves their authenticator produces valid codes
+// The returned recovery codes are only ever shown here
+func (s *service) ConfirmMFA(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAEnabled
+	}
+	if user.MFASecret == "" {
+		return nil, ErrMFAEnrollmentNotStarted
+	}
+
+	secret, err := s.openMFASecret(user)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		recoveryCode, err := generateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+		}
+		codes = append(codes, recoveryCode)
+		hashes = append(hashes, hashing.HashToken(normalizeRecoveryCode(recoveryCode)))
+	}
+
+	// The confirming step is recorded so that code cannot be replayed at sign in
+	if err := s.repo.EnableMFA(ctx, user.ID, step, hashes); err != nil {
+		if errors.Is(err, ErrMFAAlreadyEnabled) {
+			return nil, ErrMFAEnabled
+		}
+		return nil, fmt.Errorf("failed to enable mfa: %w", err)
+	}
+
+	return codes, nil
+}
+
+// DisableMFA turns MFA off after checking a current TOTP or recovery code
+func (s *service) DisableMFA(ctx context.Context, userID, code string) error {
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+
+	if err := s.verifyMFACode(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := s.repo.DisableMFA(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+	return nil
+}
+
+// CreateMFAChallenge returns the short-lived token exchanged for real tokens by VerifyMFA
+func (s *service) CreateMFAChallenge(ctx context.Context, user *user.User) (string, error) {
+	token, err := s.cfg.VerificationKeys.Sign(mfaChallengePurpose,
+		user.ID.String(), time.Now().Add(s.cfg.MFAChallengeTTL))
+	if err != nil {
+		return "", fmt.Errorf("failed to create mfa challenge: %w", err)
+	}
+	return token, nil
+}
+
+// VerifyMFA completes a sign in that returned an MFA challenge
+// Wrong codes count as failed sign-in attempts (see lockout.Guard) and the challenge
+// is invalidated once the account has MFAMaxAttempts failures
+func (s *service) VerifyMFA(ctx context.Context, challenge, code, ip string) (*user.User, error) {
+	userID, err := s.cfg.VerificationKeys.Verify(mfaChallengePurpose, challenge)
+	if err != nil {
+		if errors.Is(err, signedtoken.ErrExpired) {
+			return nil, ErrMFATokenExpired
+		}
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil || !user.MFAEnabled {
+		return nil, ErrInvalidMFAToken
+	}
+
+	challengeID := mfaChallengeRevocationID(challenge)
+	revoked, err := s.revocations.IsRevoked(challengeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check mfa challenge revocation: %w", err)
+	}
+	if revoked {
+		return nil, ErrInvalidMFAToken
+	}
+
+	if err := s.cfg.Lockout.Check(user.Email, ip); err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyMFACode(ctx, user, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			s.recordFailedMFACode(ctx, user.Email, ip, challengeID)
+		}
+		return nil, err
+	}
+
+	// Best effort, the second factor was right
+	if err := s.cfg.Lockout.Succeed(user.Email); err != nil {
+		requestid.Printf(ctx, loglevel.Warn, "auth: %v", err)
+	}
+
+	return user, nil
+}
+
+// mfaChallengeRevocationID is the revocation store ID of a challenge
+// The purpose is hashed in so it cannot collide with access token IDs, and the ID stays
+// within revocation.MaxIDLength
+func mfaChallengeRevocationID(challenge string) string {
+	return hashing.HashToken(mfaChallengePurpose + ":" + challenge)
+}
+
+// recordFailedMFACode counts a wrong code and revokes the challenge once too many codes were tried
+func (s *service) recordFailedMFACode(ctx context.Context, email, ip, challengeID string) {
+	s.recordFailedSignIn(ctx, email, ip)
+
+	statuses, err := s.cfg.Lockout.Status(email)
+	if err != nil {
+		requestid.Printf(ctx, loglevel.Warn, "auth: %v", err)
+		return
+	}
+	if statuses[email].Failures < s.cfg.MFAMaxAttempts {
+		return
+	}
+
+	// Challenges are never valid longer than MFAChallengeTTL, so neither is their revocation
+	if err := s.revocations.Revoke(challengeID, time.Now().Add(s.cfg.MFAChallengeTTL)); err != nil {
+		requestid.Printf(ctx, loglevel.Error, "auth: failed to revoke mfa challenge: %v", err)
+	}
+}
+
+// verifyMFACode accepts a TOTP code (each step once) or an unused recovery code
+func (s *service) verifyMFACode(ctx context.Context, user *user.User, code string) error {
+	code = strings.TrimSpace(code)
+
+	if isTOTPCode(code) {
+		secret, err := s.openMFASecret(user)
+		if err != nil {
+			return err
+		}
+
+		step, ok := totp.Validate(secret, code, time.Now())
+		if !ok {
+			return ErrInvalidMFACode
+		}
+
+		claimed, err := s.repo.ClaimMFAStep(ctx, user.ID, step)
+		if err != nil {
+			return fmt.Errorf("failed to record mfa code: %w", err)
+		}
+		if !claimed {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(ctx, user.ID, hashing.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return fmt.Errorf("failed to record recovery code: %w", err)
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// openMFASecret decrypts the user's stored TOTP secret
+func (s *service) openMFASecret(user *user.User) (string, error) {
+	if s.cfg.MFASecretBox == nil {
+		return "", ErrMFANotConfigured
+	}
+
+	secret, err := s.cfg.MFASecretBox.Open(user.MFASecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt mfa secret: %w", err)
+	}
+	return string(secret), nil
+}
+
+// isTOTPCode reports whether code has the shape of a TOTP code rather than a recovery code
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// generateRecoveryCode returns a random code formatted as xxxxx-xxxxx (50 bits)
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	encoded := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+	return encoded[:5] + "-" + encoded[5:], nil
+}
+
+// normalizeRecoveryCode ignores case, dashes and spaces the user may type
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}
+
+// rehashPassword re-hashes the password with the current hasher settings
+// Failures are only logged: the old hash still works, the upgrade is retried on next SignIn
+func (s *service) rehashPassword(ctx context.Context, user *user.User, password string) {
+	hashedPassword, err := s.cfg.PasswordHasher.Hash(password)
+	if err != nil {
+		requestid.Printf(ctx, loglevel.Warn, "failed to rehash password of user %s: %v", user.ID, err)
+		return
+	}
+
+	if err := s.repo.UpdatePasswordHash(ctx, user.ID, user.Password, hashedPassword); err != nil {
+		requestid.Printf(ctx, loglevel.Warn, "failed to store rehashed password of user %s: %v", user.ID, err)
+		return
+	}
+
+	user.Password = hashedPassword
+}
+
+// sendVerificationEmail signs a verification token and mails the link to the user
+func (s *service) sendVerificationEmail(ctx context.Context, user *user.User) error {
+	claimed, err := s.repo.ClaimVerificationSend(ctx, user.ID, s.cfg.VerificationResendCooldown)
+	if err != nil {
+		return fmt.Errorf("failed to record verification email: %w", err)
+	}
+	if !claimed {
+		return nil
+	}
+
+	token, err := s.cfg.VerificationKeys.Sign(verificationPurpose, user.ID.String(), time.Now().Add(s.cfg.VerificationTTL))
+	if err != nil {
+		return fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease verify your email address by opening the link below:\n\n%s/%s\n\nThe link expires in %s.",
+			user.Name, strings.TrimRight(s.cfg.VerificationURL, "/"), token, s.cfg.VerificationTTL),
+	})
+}
+
+// newRefreshToken generates an opaque token and the record that stores its hash
+func (s *service) newRefreshToken(userID, familyID uuid.UUID) (string, *RefreshToken, error) {
+	token, err := hashing.GenerateToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	now := time.Now()
+	return token, &RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashing.HashToken(token),
+		ExpiresAt: now.Add(s.cfg.RefreshTokenTTL),
+		CreatedAt: now,
+	}, nil
 }
 
 // validateSignUpData validates sign up data
 func (s *service) validateSignUpData(data *SignUpData) error {
 	if data.Name == "" {
-		return fmt.Errorf("name is required")
+		return ErrNameRequired
 	}
 	if data.Email == "" {
-		return fmt.Errorf("email is required")
-	}
-	if data.Password == "" {
-		return fmt.Errorf("password is required")
+		return ErrEmailRequired
 	}
-	if len(data.Password) < 8 {
-		return fmt.Errorf("password must be at least 8 characters")
-	}
-	return nil
+	return s.cfg.PasswordPolicy.Validate(data.Password, data.Email, data.Name)
 }
 
 // getPhotoOrDefault returns the photo or default photo
@@ -123,3 +1083,14 @@ func (s *service) getPhotoOrDefault(photo string) string {
 	}
 	return photo
 }
+
+// truncate shortens s to at most max bytes without splitting a UTF-8 sequence
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
@@ -1,13 +1,30 @@
 package auth
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang-fiber-jwt/internal/user"
+	"github.com/golang-fiber-jwt/pkg/hashing"
+	"github.com/golang-fiber-jwt/pkg/jwt"
+	"github.com/golang-fiber-jwt/pkg/lockout"
+	"github.com/golang-fiber-jwt/pkg/mailer"
+	"github.com/golang-fiber-jwt/pkg/oauth"
+	"github.com/golang-fiber-jwt/pkg/oauth/oauthtest"
+	"github.com/golang-fiber-jwt/pkg/passwordpolicy"
+	"github.com/golang-fiber-jwt/pkg/revocation"
+	"github.com/golang-fiber-jwt/pkg/secretbox"
+	"github.com/golang-fiber-jwt/pkg/signedtoken"
+	"github.com/golang-fiber-jwt/pkg/totp"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 // MockRepository is a mock implementation of Repository interface
@@ -15,31 +32,180 @@ type MockRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) GetUserByEmail(email string) (*user.User, error) {
-	args := m.Called(email)
+func (m *MockRepository) GetUserByEmail(ctx context.Context, email string) (*user.User, error) {
+	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*user.User), args.Error(1)
 }
 
-func (m *MockRepository) CreateUser(user *user.User) error {
-	args := m.Called(user)
+func (m *MockRepository) CreateUser(ctx context.Context, user *user.User) error {
+	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
-func (m *MockRepository) GetUserByID(id string) (*user.User, error) {
-	args := m.Called(id)
+func (m *MockRepository) GetUserByID(ctx context.Context, id string) (*user.User, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*user.User), args.Error(1)
 }
 
+func (m *MockRepository) CreateSession(ctx context.Context, session *Session, token *RefreshToken) error {
+	args := m.Called(ctx, session, token)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetSession(ctx context.Context, id uuid.UUID) (*Session, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Session), args.Error(1)
+}
+
+func (m *MockRepository) ListSessions(ctx context.Context, userID uuid.UUID) ([]*Session, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*Session), args.Error(1)
+}
+
+func (m *MockRepository) TouchSession(ctx context.Context, id uuid.UUID, seenAt time.Time) error {
+	args := m.Called(ctx, id, seenAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*RefreshToken, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*RefreshToken), args.Error(1)
+}
+
+func (m *MockRepository) RotateRefreshToken(ctx context.Context, currentID uuid.UUID, next *RefreshToken) error {
+	args := m.Called(ctx, currentID, next)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SignOutOtherSessions(ctx context.Context, userID, keepSessionID uuid.UUID) error {
+	args := m.Called(ctx, userID, keepSessionID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) IncrementTokenVersion(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) MarkUserVerified(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ClaimVerificationSend(ctx context.Context, userID uuid.UUID, cooldown time.Duration) (bool, error) {
+	args := m.Called(ctx, userID, cooldown)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) CreatePasswordResetToken(ctx context.Context, token *PasswordResetToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPasswordResetTokenByHash(ctx context.Context, hash string) (*PasswordResetToken, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*PasswordResetToken), args.Error(1)
+}
+
+func (m *MockRepository) UpdatePasswordHash(ctx context.Context, userID uuid.UUID, currentHash, newHash string) error {
+	args := m.Called(ctx, userID, currentHash, newHash)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SetPendingMFASecret(ctx context.Context, userID uuid.UUID, encryptedSecret string) error {
+	args := m.Called(ctx, userID, encryptedSecret)
+	return args.Error(0)
+}
+
+func (m *MockRepository) EnableMFA(ctx context.Context, userID uuid.UUID, step int64, codeHashes []string) error {
+	args := m.Called(ctx, userID, step, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DisableMFA(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ClaimMFAStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) ResetPassword(ctx context.Context, tokenID, userID uuid.UUID, passwordHash string) error {
+	args := m.Called(ctx, tokenID, userID, passwordHash)
+	return args.Error(0)
+}
+
+// testVerificationSecret signs verification tokens in tests
+const testVerificationSecret = "test-verification-secret"
+
+// testTokens issues access tokens in tests (EdDSA keys are cheap to generate)
+var testTokens = func() jwt.TokenService {
+	keys, err := jwt.NewKeySet(jwt.KeySetConfig{Algorithm: jwt.EdDSA})
+	if err != nil {
+		panic(err)
+	}
+	return jwt.NewTokenService(keys, jwt.TokenConfig{Issuer: "test", Audience: "test"})
+}()
+
+// newTestTokens returns the token service shared by the tests
+func newTestTokens() jwt.TokenService {
+	return testTokens
+}
+
+// newTestService builds a service with in-memory collaborators
+func newTestService(repo Repository, cfg Config) Service {
+	svc, _ := newTestServiceWithMail(repo, cfg)
+	return svc
+}
+
+// newTestServiceWithMail is newTestService that also exposes the mail outbox
+func newTestServiceWithMail(repo Repository, cfg Config) (Service, *bytes.Buffer) {
+	if cfg.VerificationSecret == "" {
+		cfg.VerificationSecret = testVerificationSecret
+	}
+	outbox := new(bytes.Buffer)
+	return NewAuthService(repo, revocation.NewMemoryStore(0), newTestTokens(), mailer.NewLogMailer(outbox), cfg), outbox
+}
+
 // Test SignUp Service - Success
 func TestService_SignUp_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewAuthService(mockRepo)
+	service, outbox := newTestServiceWithMail(mockRepo, Config{VerificationURL: "http://localhost/api/auth/verify"})
 
 	signUpData := &SignUpData{
 		Name:            "John Doe",
@@ -49,11 +215,15 @@ func TestService_SignUp_Success(t *testing.T) {
 		Photo:           "photo.jpg",
 	}
 
-	mockRepo.On("CreateUser", mock.AnythingOfType("*user.User")).Return(nil)
+	mockRepo.On("CreateUser", mock.Anything, mock.AnythingOfType("*user.User")).Return(nil)
+	mockRepo.On("ClaimVerificationSend", mock.Anything, mock.AnythingOfType("uuid.UUID"), defaultVerificationResendCooldown).Return(true, nil)
 
-	user, err := service.SignUp(signUpData)
+	user, err := service.SignUp(context.Background(), signUpData)
 
 	assert.NoError(t, err)
+	assert.False(t, user.Verified)
+	assert.Contains(t, outbox.String(), "To: john@example.com")
+	assert.Contains(t, outbox.String(), "http://localhost/api/auth/verify/")
 	assert.NotNil(t, user)
 	assert.Equal(t, "John Doe", user.Name)
 	assert.Equal(t, "john@example.com", user.Email)
@@ -66,7 +236,7 @@ func TestService_SignUp_Success(t *testing.T) {
 // Test SignUp Service - Password Mismatch
 func TestService_SignUp_PasswordMismatch(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewAuthService(mockRepo)
+	service := newTestService(mockRepo, Config{})
 
 	signUpData := &SignUpData{
 		Name:            "John Doe",
@@ -76,7 +246,7 @@ func TestService_SignUp_PasswordMismatch(t *testing.T) {
 		Photo:           "photo.jpg",
 	}
 
-	user, err := service.SignUp(signUpData)
+	user, err := service.SignUp(context.Background(), signUpData)
 
 	assert.Error(t, err)
 	assert.Nil(t, user)
@@ -86,7 +256,7 @@ func TestService_SignUp_PasswordMismatch(t *testing.T) {
 // Test SignUp Service - Validation Errors
 func TestService_SignUp_ValidationErrors(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewAuthService(mockRepo)
+	service := newTestService(mockRepo, Config{})
 
 	tests := []struct {
 		name          string
@@ -133,11 +303,21 @@ func TestService_SignUp_ValidationErrors(t *testing.T) {
 			},
 			expectedError: "password must be at least 8 characters",
 		},
+		{
+			name: "Password Equals Email",
+			signUpData: &SignUpData{
+				Name:            "John Doe",
+				Email:           "john@example.com",
+				Password:        "john@example.com",
+				PasswordConfirm: "john@example.com",
+			},
+			expectedError: "password must not match your email or name",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			user, err := service.SignUp(tt.signUpData)
+			user, err := service.SignUp(context.Background(), tt.signUpData)
 			assert.Error(t, err)
 			assert.Nil(t, user)
 			assert.Equal(t, tt.expectedError, err.Error())
@@ -148,7 +328,7 @@ func TestService_SignUp_ValidationErrors(t *testing.T) {
 // Test SignUp Service - Duplicate Email
 func TestService_SignUp_DuplicateEmail(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewAuthService(mockRepo)
+	service := newTestService(mockRepo, Config{})
 
 	signUpData := &SignUpData{
 		Name:            "John Doe",
@@ -157,10 +337,10 @@ func TestService_SignUp_DuplicateEmail(t *testing.T) {
 		PasswordConfirm: "password123",
 	}
 
-	mockRepo.On("CreateUser", mock.AnythingOfType("*auth.User")).
+	mockRepo.On("CreateUser", mock.Anything, mock.AnythingOfType("*user.User")).
 		Return(errors.New("duplicate key value violates unique constraint"))
 
-	user, err := service.SignUp(signUpData)
+	user, err := service.SignUp(context.Background(), signUpData)
 
 	assert.Error(t, err)
 	assert.Nil(t, user)
@@ -171,40 +351,119 @@ func TestService_SignUp_DuplicateEmail(t *testing.T) {
 // Test SignIn Service - Success
 func TestService_SignIn_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewAuthService(mockRepo)
+	service := newTestService(mockRepo, Config{})
 
 	// Create a user with hashed password
-	hashedPassword := "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy" // "password123"
+	hashedPassword, err := hashing.HashPassword("password123")
+	assert.NoError(t, err)
 	existingUser := &user.User{
-		ID:       uuid.New(),
-		Name:     "John Doe",
-		Email:    "john@example.com",
-		Password: hashedPassword,
+		ID:           uuid.New(),
+		Name:         "John Doe",
+		Email:        "john@example.com",
+		Password:     hashedPassword,
+		Role:         "user",
+		TokenVersion: 3,
 	}
 
-	mockRepo.On("GetUserByEmail", "john@example.com").Return(existingUser, nil)
+	mockRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(existingUser, nil)
 
-	token, user, err := service.SignIn("john@example.com", "password123")
+	var session *Session
+	mockRepo.On("CreateSession", mock.Anything, mock.AnythingOfType("*auth.Session"), mock.AnythingOfType("*auth.RefreshToken")).
+		Run(func(args mock.Arguments) { session = args.Get(1).(*Session) }).
+		Return(nil)
+
+	tokens, user, err := service.SignIn(context.Background(), "john@example.com", "password123", Device{UserAgent: "curl/8.0", IP: "203.0.113.7"})
 
 	assert.NoError(t, err)
-	assert.NotEmpty(t, token)
+	assert.NotNil(t, tokens)
+	assert.NotEmpty(t, tokens.RefreshToken)
 	assert.NotNil(t, user)
 	assert.Equal(t, "John Doe", user.Name)
 	assert.Equal(t, "john@example.com", user.Email)
+
+	// The session records the device
+	assert.Equal(t, existingUser.ID, session.UserID)
+	assert.Equal(t, "curl/8.0", session.UserAgent)
+	assert.Equal(t, "203.0.113.7", session.IP)
+	assert.Equal(t, session.ID, tokens.SessionID)
+
+	// The token is a real access token issued by the token service
+	claims, err := newTestTokens().Validate(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, existingUser.ID.String(), claims.Subject)
+	assert.Equal(t, "user", claims.Role)
+	assert.Equal(t, 3, claims.TokenVersion)
+	assert.Equal(t, session.ID.String(), claims.SessionID)
+	assert.NotEmpty(t, claims.Id)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test SignIn Service - No access token before the second factor
+func TestService_SignIn_MFAEnabledReturnsNoToken(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	hashedPassword, _ := hashing.HashPassword("password123")
+	existingUser := &user.User{
+		ID:         uuid.New(),
+		Email:      "john@example.com",
+		Password:   hashedPassword,
+		MFAEnabled: true,
+	}
+	mockRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(existingUser, nil)
+
+	tokens, signedIn, err := service.SignIn(context.Background(), "john@example.com", "password123", Device{})
+
+	assert.NoError(t, err)
+	assert.Nil(t, tokens)
+	assert.Equal(t, existingUser, signedIn)
+	mockRepo.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test SignIn Service - Outdated hashes are upgraded transparently
+func TestService_SignIn_RehashesOutdatedHash(t *testing.T) {
+	mockRepo := new(MockRepository)
+	hasher, err := hashing.New(hashing.Config{
+		Algorithm:         hashing.AlgorithmArgon2id,
+		Argon2Memory:      1024,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+	})
+	assert.NoError(t, err)
+	service := newTestService(mockRepo, Config{PasswordHasher: hasher})
+
+	legacyHash, err := hashing.HashPassword("password123")
+	assert.NoError(t, err)
+	existingUser := &user.User{ID: uuid.New(), Email: "john@example.com", Password: legacyHash}
+
+	mockRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(existingUser, nil)
+
+	var newHash string
+	mockRepo.On("UpdatePasswordHash", mock.Anything, existingUser.ID, legacyHash, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { newHash = args.String(3) }).
+		Return(nil)
+	mockRepo.On("CreateSession", mock.Anything, mock.AnythingOfType("*auth.Session"), mock.AnythingOfType("*auth.RefreshToken")).Return(nil)
+
+	_, signedIn, err := service.SignIn(context.Background(), "john@example.com", "password123", Device{})
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(newHash, "$argon2id$"))
+	assert.NoError(t, hasher.Verify(newHash, "password123"))
+	assert.Equal(t, newHash, signedIn.Password)
 	mockRepo.AssertExpectations(t)
 }
 
 // Test SignIn Service - User Not Found
 func TestService_SignIn_UserNotFound(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewAuthService(mockRepo)
+	service := newTestService(mockRepo, Config{})
 
-	mockRepo.On("GetUserByEmail", "notfound@example.com").Return(nil, errors.New("record not found"))
+	mockRepo.On("GetUserByEmail", mock.Anything, "notfound@example.com").Return(nil, gorm.ErrRecordNotFound)
 
-	token, user, err := service.SignIn("notfound@example.com", "password123")
+	tokens, user, err := service.SignIn(context.Background(), "notfound@example.com", "password123", Device{})
 
 	assert.Error(t, err)
-	assert.Empty(t, token)
+	assert.Nil(t, tokens)
 	assert.Nil(t, user)
 	assert.Equal(t, "invalid email or password", err.Error())
 	mockRepo.AssertExpectations(t)
@@ -213,7 +472,7 @@ func TestService_SignIn_UserNotFound(t *testing.T) {
 // Test SignIn Service - Invalid Password
 func TestService_SignIn_InvalidPassword(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewAuthService(mockRepo)
+	service := newTestService(mockRepo, Config{})
 
 	hashedPassword := "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy" // "password123"
 	existingUser := &user.User{
@@ -223,21 +482,116 @@ func TestService_SignIn_InvalidPassword(t *testing.T) {
 		Password: hashedPassword,
 	}
 
-	mockRepo.On("GetUserByEmail", "john@example.com").Return(existingUser, nil)
+	mockRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(existingUser, nil)
 
-	token, user, err := service.SignIn("john@example.com", "wrongpassword")
+	tokens, user, err := service.SignIn(context.Background(), "john@example.com", "wrongpassword", Device{})
 
 	assert.Error(t, err)
-	assert.Empty(t, token)
+	assert.Nil(t, tokens)
+	assert.Nil(t, user)
+	assert.Equal(t, "invalid email or password", err.Error())
+	mockRepo.AssertExpectations(t)
+}
+
+// Test SignIn Service - Failed attempts lock the account out
+func TestService_SignIn_LockedOut(t *testing.T) {
+	mockRepo := new(MockRepository)
+	guard := lockout.NewGuard(lockout.NewMemoryStore(0), lockout.Config{MaxAttempts: 2, BaseDelay: time.Nanosecond, LockoutDuration: time.Hour})
+	service := newTestService(mockRepo, Config{Lockout: guard})
+
+	hashedPassword, _ := hashing.HashPassword("password123")
+	existingUser := &user.User{ID: uuid.New(), Email: "john@example.com", Password: hashedPassword}
+	mockRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(existingUser, nil)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := service.SignIn(context.Background(), "john@example.com", "wrongpassword", Device{IP: "203.0.113.7"})
+		assert.Equal(t, "invalid email or password", err.Error())
+		time.Sleep(time.Millisecond) // Past the backoff
+	}
+
+	// Even the right password is rejected while locked, without touching the repository
+	tokens, user, err := service.SignIn(context.Background(), "John@example.com", "password123", Device{IP: "198.51.100.1"})
+
+	var locked *lockout.LockedError
+	assert.True(t, errors.As(err, &locked))
+	assert.Equal(t, time.Hour, locked.RetryAfter)
+	assert.Nil(t, tokens)
 	assert.Nil(t, user)
+	mockRepo.AssertNumberOfCalls(t, "GetUserByEmail", 2)
+
+	statuses, err := guard.Status("john@example.com")
+	assert.NoError(t, err)
+	assert.True(t, statuses["john@example.com"].Locked())
+}
+
+// Test SignIn Service - Unknown emails are throttled like existing ones
+func TestService_SignIn_UnknownEmailCounted(t *testing.T) {
+	mockRepo := new(MockRepository)
+	guard := lockout.NewGuard(lockout.NewMemoryStore(0), lockout.Config{})
+	service := newTestService(mockRepo, Config{Lockout: guard})
+
+	mockRepo.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+
+	_, _, err := service.SignIn(context.Background(), "nobody@example.com", "password123", Device{})
 	assert.Equal(t, "invalid email or password", err.Error())
+
+	statuses, err := guard.Status("nobody@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, statuses["nobody@example.com"].Failures)
+}
+
+// Test SignIn Service - A canceled request aborts without counting a failed attempt
+func TestService_SignIn_ContextCanceled(t *testing.T) {
+	mockRepo := new(MockRepository)
+	guard := lockout.NewGuard(lockout.NewMemoryStore(0), lockout.Config{})
+	service := newTestService(mockRepo, Config{Lockout: guard})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The repository sees the caller's context and fails like GORM does once it is canceled
+	mockRepo.On("GetUserByEmail", mock.MatchedBy(func(c context.Context) bool { return c == ctx }), "john@example.com").
+		Return(nil, ctx.Err())
+
+	tokens, user, err := service.SignIn(ctx, "john@example.com", "password123", Device{IP: "203.0.113.7"})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, tokens)
+	assert.Nil(t, user)
+
+	statuses, err := guard.Status("john@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, statuses["john@example.com"].Failures)
 	mockRepo.AssertExpectations(t)
 }
 
+// Test SignIn Service - A successful sign-in clears the account's failures
+func TestService_SignIn_SuccessResetsFailures(t *testing.T) {
+	mockRepo := new(MockRepository)
+	guard := lockout.NewGuard(lockout.NewMemoryStore(0), lockout.Config{BaseDelay: time.Nanosecond})
+	service := newTestService(mockRepo, Config{Lockout: guard})
+
+	hashedPassword, _ := hashing.HashPassword("password123")
+	existingUser := &user.User{ID: uuid.New(), Email: "john@example.com", Password: hashedPassword}
+	mockRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(existingUser, nil)
+	mockRepo.On("CreateSession", mock.Anything, mock.AnythingOfType("*auth.Session"), mock.AnythingOfType("*auth.RefreshToken")).Return(nil)
+
+	_, _, err := service.SignIn(context.Background(), "john@example.com", "wrongpassword", Device{})
+	assert.Error(t, err)
+	time.Sleep(time.Millisecond) // Past the backoff
+
+	_, _, err = service.SignIn(context.Background(), "john@example.com", "password123", Device{})
+	assert.NoError(t, err)
+
+	statuses, err := guard.Status("john@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, statuses["john@example.com"].Failures)
+}
+
 // Test GetUserByID Service - Success
 func TestService_GetUserByID_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewAuthService(mockRepo)
+	service := newTestService(mockRepo, Config{})
 
 	userID := uuid.New().String()
 	expectedUser := &user.User{
@@ -247,9 +601,9 @@ func TestService_GetUserByID_Success(t *testing.T) {
 		Role:  "user",
 	}
 
-	mockRepo.On("GetUserByID", userID).Return(expectedUser, nil)
+	mockRepo.On("GetUserByID", mock.Anything, userID).Return(expectedUser, nil)
 
-	user, err := service.GetUserByID(userID)
+	user, err := service.GetUserByID(context.Background(), userID)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
@@ -261,15 +615,993 @@ func TestService_GetUserByID_Success(t *testing.T) {
 // Test GetUserByID Service - User Not Found
 func TestService_GetUserByID_NotFound(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewAuthService(mockRepo)
+	service := newTestService(mockRepo, Config{})
 
 	userID := uuid.New().String()
 
-	mockRepo.On("GetUserByID", userID).Return(nil, errors.New("user not found"))
+	mockRepo.On("GetUserByID", mock.Anything, userID).Return(nil, gorm.ErrRecordNotFound)
+
+	user, err := service.GetUserByID(context.Background(), userID)
+
+	assert.Error(t, err)
+	assert.Nil(t, user)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test StartSession Service - Success
+func TestService_StartSession_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{RefreshTokenTTL: time.Hour})
+
+	existingUser := &user.User{ID: uuid.New()}
+	var session *Session
+	var stored *RefreshToken
+	mockRepo.On("CreateSession", mock.Anything, mock.AnythingOfType("*auth.Session"), mock.AnythingOfType("*auth.RefreshToken")).
+		Run(func(args mock.Arguments) {
+			session = args.Get(1).(*Session)
+			stored = args.Get(2).(*RefreshToken)
+		}).
+		Return(nil)
+
+	tokens, err := service.StartSession(context.Background(), existingUser, Device{UserAgent: strings.Repeat("a", 300)})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	assert.Equal(t, existingUser.ID, stored.UserID)
+	assert.Equal(t, session.ID, stored.FamilyID) // The session is the refresh token family
+	assert.Len(t, session.UserAgent, maxUserAgentLength)
+	assert.Equal(t, hashing.HashToken(tokens.RefreshToken), stored.TokenHash) // Only the hash is persisted
+	assert.NotEqual(t, tokens.RefreshToken, stored.TokenHash)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test RefreshTokens Service - Rotation
+func TestService_RefreshTokens_Rotates(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	existingUser := &user.User{ID: uuid.New(), Name: "John Doe"}
+	current := &RefreshToken{
+		ID:        uuid.New(),
+		UserID:    existingUser.ID,
+		FamilyID:  uuid.New(),
+		TokenHash: hashing.HashToken("old-token"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	mockRepo.On("GetRefreshTokenByHash", mock.Anything, hashing.HashToken("old-token")).Return(current, nil)
+	mockRepo.On("GetUserByID", mock.Anything, existingUser.ID.String()).Return(existingUser, nil)
+	mockRepo.On("RotateRefreshToken", mock.Anything, current.ID, mock.MatchedBy(func(next *RefreshToken) bool {
+		return next.FamilyID == current.FamilyID && next.UserID == current.UserID
+	})).Return(nil)
+	mockRepo.On("TouchSession", mock.Anything, current.FamilyID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	tokens, user, err := service.RefreshTokens(context.Background(), "old-token")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	assert.NotEqual(t, "old-token", tokens.RefreshToken)
+	assert.Equal(t, current.FamilyID, tokens.SessionID)
+	assert.Equal(t, existingUser, user)
+
+	// The new access token stays bound to the same session
+	claims, err := newTestTokens().Validate(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, current.FamilyID.String(), claims.SessionID)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test RefreshTokens Service - Reuse of a rotated token revokes the family
+func TestService_RefreshTokens_ReuseDetected(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	revokedAt := time.Now().Add(-time.Minute)
+	current := &RefreshToken{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		FamilyID:  uuid.New(),
+		ExpiresAt: time.Now().Add(time.Hour),
+		RevokedAt: &revokedAt,
+	}
+
+	mockRepo.On("GetRefreshTokenByHash", mock.Anything, hashing.HashToken("stolen-token")).Return(current, nil)
+	mockRepo.On("RevokeRefreshTokenFamily", mock.Anything, current.FamilyID).Return(nil)
 
-	user, err := service.GetUserByID(userID)
+	tokens, user, err := service.RefreshTokens(context.Background(), "stolen-token")
 
 	assert.Error(t, err)
+	assert.Nil(t, tokens)
 	assert.Nil(t, user)
+	assert.Equal(t, "refresh token reuse detected", err.Error())
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "RotateRefreshToken", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test RefreshTokens Service - Concurrent rotation is treated as reuse
+func TestService_RefreshTokens_RotationRace(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	existingUser := &user.User{ID: uuid.New()}
+	current := &RefreshToken{
+		ID:        uuid.New(),
+		UserID:    existingUser.ID,
+		FamilyID:  uuid.New(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	mockRepo.On("GetRefreshTokenByHash", mock.Anything, hashing.HashToken("raced-token")).Return(current, nil)
+	mockRepo.On("GetUserByID", mock.Anything, existingUser.ID.String()).Return(existingUser, nil)
+	mockRepo.On("RotateRefreshToken", mock.Anything, current.ID, mock.AnythingOfType("*auth.RefreshToken")).Return(ErrRefreshTokenRevoked)
+	mockRepo.On("RevokeRefreshTokenFamily", mock.Anything, current.FamilyID).Return(nil)
+
+	_, _, err := service.RefreshTokens(context.Background(), "raced-token")
+
+	assert.Error(t, err)
+	assert.Equal(t, "refresh token reuse detected", err.Error())
+	mockRepo.AssertExpectations(t)
+}
+
+// Test RefreshTokens Service - Expired and unknown tokens
+func TestService_RefreshTokens_Invalid(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	expired := &RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  uuid.New(),
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}
+
+	mockRepo.On("GetRefreshTokenByHash", mock.Anything, hashing.HashToken("expired-token")).Return(expired, nil)
+	mockRepo.On("GetRefreshTokenByHash", mock.Anything, hashing.HashToken("unknown-token")).Return(nil, gorm.ErrRecordNotFound)
+
+	tests := []struct {
+		name          string
+		token         string
+		expectedError string
+	}{
+		{name: "Empty Token", token: "", expectedError: "invalid refresh token"},
+		{name: "Unknown Token", token: "unknown-token", expectedError: "invalid refresh token"},
+		{name: "Expired Token", token: "expired-token", expectedError: "refresh token expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, user, err := service.RefreshTokens(context.Background(), tt.token)
+			assert.Error(t, err)
+			assert.Nil(t, tokens)
+			assert.Nil(t, user)
+			assert.Equal(t, tt.expectedError, err.Error())
+		})
+	}
+}
+
+// Test ValidateAccessToken Service - Success
+func TestService_ValidateAccessToken_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	existingUser := &user.User{ID: uuid.New(), TokenVersion: 2}
+	mockRepo.On("GetUserByID", mock.Anything, existingUser.ID.String()).Return(existingUser, nil)
+
+	err := service.ValidateAccessToken(context.Background(), uuid.New().String(), existingUser.ID.String(), "", 2)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test ValidateAccessToken Service - Revoked jti
+func TestService_ValidateAccessToken_Revoked(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	jti := uuid.New().String()
+	assert.NoError(t, service.RevokeAccessToken(context.Background(), jti, time.Now().Add(time.Hour)))
+
+	err := service.ValidateAccessToken(context.Background(), jti, uuid.New().String(), "", 0)
+
+	assert.Error(t, err)
+	assert.Equal(t, "token has been revoked", err.Error())
+	mockRepo.AssertNotCalled(t, "GetUserByID", mock.Anything, mock.Anything)
+}
+
+// Test ValidateAccessToken Service - Outdated token version
+func TestService_ValidateAccessToken_OutdatedVersion(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	existingUser := &user.User{ID: uuid.New(), TokenVersion: 3}
+	mockRepo.On("GetUserByID", mock.Anything, existingUser.ID.String()).Return(existingUser, nil)
+
+	err := service.ValidateAccessToken(context.Background(), uuid.New().String(), existingUser.ID.String(), "", 2)
+
+	assert.Error(t, err)
+	assert.Equal(t, "token has been revoked", err.Error())
+	mockRepo.AssertExpectations(t)
+}
+
+// Test ValidateAccessToken Service - An expired deadline is not a revoked token
+func TestService_ValidateAccessToken_DeadlineExceeded(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	userID := uuid.New().String()
+	mockRepo.On("GetUserByID", mock.MatchedBy(func(c context.Context) bool { return c == ctx }), userID).
+		Return(nil, ctx.Err())
+
+	err := service.ValidateAccessToken(ctx, uuid.New().String(), userID, "", 0)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, ErrUserNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test ValidateAccessToken Service - Active session
+func TestService_ValidateAccessToken_ActiveSession(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	existingUser := &user.User{ID: uuid.New()}
+	session := &Session{ID: uuid.New(), UserID: existingUser.ID, LastSeenAt: time.Now().Add(-time.Hour)}
+	mockRepo.On("GetUserByID", mock.Anything, existingUser.ID.String()).Return(existingUser, nil)
+	mockRepo.On("GetSession", mock.Anything, session.ID).Return(session, nil)
+	mockRepo.On("TouchSession", mock.Anything, session.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	err := service.ValidateAccessToken(context.Background(), uuid.New().String(), existingUser.ID.String(), session.ID.String(), 0)
+
+	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
+
+// Test ValidateAccessToken Service - Revoked or foreign sessions reject the token
+func TestService_ValidateAccessToken_SessionRevoked(t *testing.T) {
+	existingUser := &user.User{ID: uuid.New()}
+	revokedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		session *Session
+		err     error
+	}{
+		{name: "Revoked", session: &Session{ID: uuid.New(), UserID: existingUser.ID, RevokedAt: &revokedAt}},
+		{name: "Other User", session: &Session{ID: uuid.New(), UserID: uuid.New()}},
+		{name: "Missing", session: &Session{ID: uuid.New()}, err: gorm.ErrRecordNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := newTestService(mockRepo, Config{})
+
+			mockRepo.On("GetUserByID", mock.Anything, existingUser.ID.String()).Return(existingUser, nil)
+			if tt.err != nil {
+				mockRepo.On("GetSession", mock.Anything, tt.session.ID).Return(nil, tt.err)
+			} else {
+				mockRepo.On("GetSession", mock.Anything, tt.session.ID).Return(tt.session, nil)
+			}
+
+			err := service.ValidateAccessToken(context.Background(), uuid.New().String(), existingUser.ID.String(), tt.session.ID.String(), 0)
+
+			assert.Error(t, err)
+			assert.Equal(t, "token has been revoked", err.Error())
+			mockRepo.AssertNotCalled(t, "TouchSession", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// Test ListSessions Service - Success
+func TestService_ListSessions_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	userID := uuid.New()
+	sessions := []*Session{{ID: uuid.New(), UserID: userID}, {ID: uuid.New(), UserID: userID}}
+	mockRepo.On("ListSessions", mock.Anything, userID).Return(sessions, nil)
+
+	listed, err := service.ListSessions(context.Background(), userID.String())
+
+	assert.NoError(t, err)
+	assert.Equal(t, sessions, listed)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test RevokeSession Service - Success
+func TestService_RevokeSession_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	session := &Session{ID: uuid.New(), UserID: uuid.New()}
+	mockRepo.On("GetSession", mock.Anything, session.ID).Return(session, nil)
+	mockRepo.On("RevokeRefreshTokenFamily", mock.Anything, session.ID).Return(nil)
+
+	err := service.RevokeSession(context.Background(), session.UserID.String(), session.ID.String())
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test RevokeSession Service - Sessions of other users are not found
+func TestService_RevokeSession_NotOwner(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	session := &Session{ID: uuid.New(), UserID: uuid.New()}
+	mockRepo.On("GetSession", mock.Anything, session.ID).Return(session, nil)
+
+	err := service.RevokeSession(context.Background(), uuid.New().String(), session.ID.String())
+
+	assert.Error(t, err)
+	assert.Equal(t, "session not found", err.Error())
+	mockRepo.AssertNotCalled(t, "RevokeRefreshTokenFamily", mock.Anything, mock.Anything)
+}
+
+// Test RevokeAllSessions Service - Success
+func TestService_RevokeAllSessions_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	userID := uuid.New()
+	mockRepo.On("RevokeUserRefreshTokens", mock.Anything, userID).Return(nil)
+
+	err := service.RevokeAllSessions(context.Background(), userID.String())
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "IncrementTokenVersion", mock.Anything, mock.Anything)
+}
+
+// Test SignOutOtherSessions Service - Keeps the given session, all of them for an invalid ID
+func TestService_SignOutOtherSessions(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	userID, sessionID := uuid.New(), uuid.New()
+	mockRepo.On("SignOutOtherSessions", mock.Anything, userID, sessionID).Return(nil)
+	mockRepo.On("SignOutOtherSessions", mock.Anything, userID, uuid.Nil).Return(nil)
+
+	assert.NoError(t, service.SignOutOtherSessions(context.Background(), userID.String(), sessionID.String()))
+	assert.NoError(t, service.SignOutOtherSessions(context.Background(), userID.String(), ""))
+	mockRepo.AssertExpectations(t)
+}
+
+// Test LogoutEverywhere Service - Success
+func TestService_LogoutEverywhere_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	userID := uuid.New()
+	mockRepo.On("IncrementTokenVersion", mock.Anything, userID).Return(nil)
+	mockRepo.On("RevokeUserRefreshTokens", mock.Anything, userID).Return(nil)
+
+	err := service.LogoutEverywhere(context.Background(), userID.String())
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test SignIn Service - Unverified email is blocked when required
+func TestService_SignIn_RequireVerifiedEmail(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{RequireVerifiedEmail: true})
+
+	hashedPassword, err := hashing.HashPassword("password123")
+	assert.NoError(t, err)
+	existingUser := &user.User{
+		ID:       uuid.New(),
+		Email:    "john@example.com",
+		Password: hashedPassword,
+		Verified: false,
+	}
+
+	mockRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(existingUser, nil)
+
+	tokens, user, err := service.SignIn(context.Background(), "john@example.com", "password123", Device{})
+
+	assert.Error(t, err)
+	assert.Nil(t, tokens)
+	assert.Nil(t, user)
+	assert.Equal(t, "email address is not verified", err.Error())
+}
+
+// Test VerifyEmail Service - Success
+func TestService_VerifyEmail_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	existingUser := &user.User{ID: uuid.New(), Verified: false}
+	token, err := signedtoken.Sign([]byte(testVerificationSecret), verificationPurpose, existingUser.ID.String(), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	mockRepo.On("GetUserByID", mock.Anything, existingUser.ID.String()).Return(existingUser, nil)
+	mockRepo.On("MarkUserVerified", mock.Anything, existingUser.ID).Return(nil)
+
+	err = service.VerifyEmail(context.Background(), token)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test VerifyEmail Service - Invalid tokens
+func TestService_VerifyEmail_InvalidToken(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	userID := uuid.New().String()
+	expired, _ := signedtoken.Sign([]byte(testVerificationSecret), verificationPurpose, userID, time.Now().Add(-time.Minute))
+	forged, _ := signedtoken.Sign([]byte("another-secret"), verificationPurpose, userID, time.Now().Add(time.Hour))
+	wrongPurpose, _ := signedtoken.Sign([]byte(testVerificationSecret), "password_reset", userID, time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name          string
+		token         string
+		expectedError string
+	}{
+		{name: "Expired Token", token: expired, expectedError: "verification token expired"},
+		{name: "Forged Token", token: forged, expectedError: "invalid verification token"},
+		{name: "Wrong Purpose", token: wrongPurpose, expectedError: "invalid verification token"},
+		{name: "Garbage", token: "not-a-token", expectedError: "invalid verification token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.VerifyEmail(context.Background(), tt.token)
+			assert.Error(t, err)
+			assert.Equal(t, tt.expectedError, err.Error())
+		})
+	}
+	mockRepo.AssertNotCalled(t, "MarkUserVerified", mock.Anything, mock.Anything)
+}
+
+// Test ResendVerification Service - Throttled by cooldown
+func TestService_ResendVerification_Throttled(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, outbox := newTestServiceWithMail(mockRepo, Config{VerificationResendCooldown: time.Minute})
+
+	existingUser := &user.User{ID: uuid.New(), Email: "john@example.com", Verified: false}
+	mockRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(existingUser, nil)
+	mockRepo.On("ClaimVerificationSend", mock.Anything, existingUser.ID, time.Minute).Return(false, nil)
+
+	err := service.ResendVerification(context.Background(), "john@example.com")
+
+	assert.NoError(t, err)
+	assert.Empty(t, outbox.String())
+	mockRepo.AssertExpectations(t)
+}
+
+// Test ResendVerification Service - Unknown email gives no hint
+func TestService_ResendVerification_UnknownEmail(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, outbox := newTestServiceWithMail(mockRepo, Config{})
+
+	mockRepo.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+
+	err := service.ResendVerification(context.Background(), "nobody@example.com")
+
+	assert.NoError(t, err)
+	assert.Empty(t, outbox.String())
+}
+
+// Test ForgotPassword Service - Stores only the token hash and mails the plain token
+func TestService_ForgotPassword_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, outbox := newTestServiceWithMail(mockRepo, Config{PasswordResetURL: "http://localhost:3000/reset-password"})
+
+	existingUser := &user.User{ID: uuid.New(), Name: "John", Email: "john@example.com", Provider: "local"}
+	mockRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(existingUser, nil)
+
+	var stored *PasswordResetToken
+	mockRepo.On("CreatePasswordResetToken", mock.Anything, mock.AnythingOfType("*auth.PasswordResetToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*PasswordResetToken) }).
+		Return(nil)
+
+	err := service.ForgotPassword(context.Background(), " John@Example.com ")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, stored)
+	assert.Equal(t, existingUser.ID, stored.UserID)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+
+	mail := outbox.String()
+	start := strings.Index(mail, "reset-password?token=")
+	assert.NotEqual(t, -1, start)
+	token, err := url.QueryUnescape(strings.Fields(mail[start+len("reset-password?token="):])[0])
+	assert.NoError(t, err)
+	assert.Equal(t, hashing.HashToken(token), stored.TokenHash)
+	assert.NotContains(t, mail, stored.TokenHash)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test ForgotPassword Service - Unknown email gives no hint
+func TestService_ForgotPassword_UnknownEmail(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, outbox := newTestServiceWithMail(mockRepo, Config{})
+
+	mockRepo.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+
+	err := service.ForgotPassword(context.Background(), "nobody@example.com")
+
+	assert.NoError(t, err)
+	assert.Empty(t, outbox.String())
+	mockRepo.AssertNotCalled(t, "CreatePasswordResetToken", mock.Anything, mock.Anything)
+}
+
+// Test ResetPassword Service - Success
+func TestService_ResetPassword_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	record := &PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		TokenHash: hashing.HashToken("reset-token"),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	mockRepo.On("GetPasswordResetTokenByHash", mock.Anything, record.TokenHash).Return(record, nil)
+	mockRepo.On("GetUserByID", mock.Anything, record.UserID.String()).Return(&user.User{ID: record.UserID, Name: "John", Email: "john@example.com"}, nil)
+
+	var newHash string
+	mockRepo.On("ResetPassword", mock.Anything, record.ID, record.UserID, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { newHash = args.String(3) }).
+		Return(nil)
+
+	err := service.ResetPassword(context.Background(), &ResetPasswordData{
+		Token:           "reset-token",
+		Password:        "newpassword123",
+		PasswordConfirm: "newpassword123",
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, hashing.VerifyPassword(newHash, "newpassword123"))
+	mockRepo.AssertExpectations(t)
+}
+
+// Test ResetPassword Service - Rejected tokens
+func TestService_ResetPassword_InvalidToken(t *testing.T) {
+	usedAt := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name    string
+		record  *PasswordResetToken
+		repoErr error
+	}{
+		{name: "Unknown Token", repoErr: errors.New("record not found")},
+		{name: "Expired Token", record: &PasswordResetToken{ID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)}},
+		{name: "Used Token", record: &PasswordResetToken{ID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := newTestService(mockRepo, Config{})
+
+			if tt.record != nil {
+				mockRepo.On("GetPasswordResetTokenByHash", mock.Anything, hashing.HashToken("reset-token")).Return(tt.record, nil)
+			} else {
+				mockRepo.On("GetPasswordResetTokenByHash", mock.Anything, hashing.HashToken("reset-token")).Return(nil, tt.repoErr)
+			}
+
+			err := service.ResetPassword(context.Background(), &ResetPasswordData{
+				Token:           "reset-token",
+				Password:        "newpassword123",
+				PasswordConfirm: "newpassword123",
+			})
+
+			assert.Error(t, err)
+			assert.Equal(t, "invalid or expired reset token", err.Error())
+			mockRepo.AssertNotCalled(t, "ResetPassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// Test ResetPassword Service - Token consumed concurrently
+func TestService_ResetPassword_ConsumedRace(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{})
+
+	record := &PasswordResetToken{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	mockRepo.On("GetPasswordResetTokenByHash", mock.Anything, hashing.HashToken("reset-token")).Return(record, nil)
+	mockRepo.On("GetUserByID", mock.Anything, record.UserID.String()).Return(&user.User{ID: record.UserID, Email: "john@example.com"}, nil)
+	mockRepo.On("ResetPassword", mock.Anything, record.ID, record.UserID, mock.AnythingOfType("string")).Return(ErrPasswordResetTokenUsed)
+
+	err := service.ResetPassword(context.Background(), &ResetPasswordData{
+		Token:           "reset-token",
+		Password:        "newpassword123",
+		PasswordConfirm: "newpassword123",
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "invalid or expired reset token", err.Error())
+}
+
+// Test ResetPassword Service - New password must satisfy the policy
+func TestService_ResetPassword_PolicyViolation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := newTestService(mockRepo, Config{PasswordPolicy: passwordpolicy.Policy{RequireDigit: true}})
+
+	record := &PasswordResetToken{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	mockRepo.On("GetPasswordResetTokenByHash", mock.Anything, hashing.HashToken("reset-token")).Return(record, nil)
+	mockRepo.On("GetUserByID", mock.Anything, record.UserID.String()).Return(&user.User{ID: record.UserID, Email: "john@example.com"}, nil)
+
+	err := service.ResetPassword(context.Background(), &ResetPasswordData{
+		Token:           "reset-token",
+		Password:        "nodigitshere",
+		PasswordConfirm: "nodigitshere",
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, "password must contain a digit", err.Error())
+	mockRepo.AssertNotCalled(t, "ResetPassword", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// newOAuthTestService builds a service with a "google" provider backed by a mock authorization server
+func newOAuthTestService(repo Repository, profile map[string]interface{}) (Service, *oauthtest.Server) {
+	server := oauthtest.NewServer(profile)
+	svc := newTestService(repo, Config{OAuthProviders: oauth.NewRegistry(server.Provider("google"))})
+	return svc, server
+}
+
+// Test OAuth login - First login creates a verified google user
+func TestService_OAuthLogin_CreatesUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, server := newOAuthTestService(mockRepo, map[string]interface{}{
+		"sub":            "1234",
+		"email":          "john@example.com",
+		"email_verified": true,
+		"name":           "John Doe",
+		"picture":        "https://example.com/john.png",
+	})
+	defer server.Close()
+
+	mockRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("CreateUser", mock.Anything, mock.AnythingOfType("*user.User")).Return(nil)
+
+	authURL, savedState, err := service.BeginOAuthLogin(context.Background(), "google")
+	assert.NoError(t, err)
+	code, state, err := server.Authorize(authURL)
+	assert.NoError(t, err)
+
+	created, err := service.CompleteOAuthLogin(context.Background(), "google", code, state, savedState)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "google", created.Provider)
+	assert.Equal(t, "John Doe", created.Name)
+	assert.Equal(t, "https://example.com/john.png", created.Photo)
+	assert.True(t, created.Verified)
+	assert.Empty(t, created.Password)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test OAuth login - Returning users are signed in without being recreated
+func TestService_OAuthLogin_ExistingUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, server := newOAuthTestService(mockRepo, map[string]interface{}{
+		"sub": "1234", "email": "john@example.com", "email_verified": true,
+	})
+	defer server.Close()
+
+	existingUser := &user.User{ID: uuid.New(), Email: "john@example.com", Provider: "google"}
+	mockRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(existingUser, nil)
+
+	authURL, savedState, _ := service.BeginOAuthLogin(context.Background(), "google")
+	code, state, _ := server.Authorize(authURL)
+
+	signedIn, err := service.CompleteOAuthLogin(context.Background(), "google", code, state, savedState)
+
+	assert.NoError(t, err)
+	assert.Equal(t, existingUser.ID, signedIn.ID)
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
+// Test OAuth login - Rejections
+func TestService_OAuthLogin_Rejected(t *testing.T) {
+	tests := []struct {
+		name          string
+		profile       map[string]interface{}
+		existing      *user.User
+		tamper        func(state, savedState string) (string, string)
+		expectedError string
+	}{
+		{
+			name:          "State Mismatch",
+			profile:       map[string]interface{}{"sub": "1", "email": "john@example.com", "email_verified": true},
+			tamper:        func(state, savedState string) (string, string) { return "forged", savedState },
+			expectedError: "invalid oauth state",
+		},
+		{
+			name:          "Missing State Cookie",
+			profile:       map[string]interface{}{"sub": "1", "email": "john@example.com", "email_verified": true},
+			tamper:        func(state, savedState string) (string, string) { return state, "" },
+			expectedError: "invalid oauth state",
+		},
+		{
+			name:          "Unverified Email",
+			profile:       map[string]interface{}{"sub": "1", "email": "john@example.com", "email_verified": false},
+			expectedError: "email address is not verified by provider",
+		},
+		{
+			name:          "Local Account Not Linked",
+			profile:       map[string]interface{}{"sub": "1", "email": "john@example.com", "email_verified": true},
+			existing:      &user.User{ID: uuid.New(), Email: "john@example.com", Provider: "local"},
+			expectedError: "account is registered with a different sign-in method",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service, server := newOAuthTestService(mockRepo, tt.profile)
+			defer server.Close()
+
+			if tt.existing != nil {
+				mockRepo.On("GetUserByEmail", mock.Anything, tt.existing.Email).Return(tt.existing, nil)
+			}
+
+			authURL, savedState, err := service.BeginOAuthLogin(context.Background(), "google")
+			assert.NoError(t, err)
+			code, state, err := server.Authorize(authURL)
+			assert.NoError(t, err)
+			if tt.tamper != nil {
+				state, savedState = tt.tamper(state, savedState)
+			}
+
+			_, err = service.CompleteOAuthLogin(context.Background(), "google", code, state, savedState)
+
+			assert.Error(t, err)
+			assert.Equal(t, tt.expectedError, err.Error())
+			mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// Test OAuth login - Unknown or unconfigured providers
+func TestService_OAuthLogin_UnsupportedProvider(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, server := newOAuthTestService(mockRepo, nil)
+	defer server.Close()
+
+	_, _, err := service.BeginOAuthLogin(context.Background(), "facebook")
+	assert.Error(t, err)
+	assert.Equal(t, "unsupported provider", err.Error())
+}
+
+// newMFATestService builds a service with an MFA secret box and a user holding an encrypted TOTP secret
+func newMFATestService(repo Repository, enabled bool) (Service, *user.User, string) {
+	box, _ := secretbox.New(bytes.Repeat([]byte{7}, secretbox.KeySize))
+	secret, _ := totp.GenerateSecret()
+	encrypted, _ := box.Seal([]byte(secret))
+
+	mfaUser := &user.User{ID: uuid.New(), Email: "admin@example.com", MFAEnabled: enabled, MFASecret: encrypted}
+	return newTestService(repo, Config{MFASecretBox: box}), mfaUser, secret
+}
+
+// Test EnrollMFA Service - Stores the secret encrypted and returns an otpauth URI
+func TestService_EnrollMFA_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, mfaUser, _ := newMFATestService(mockRepo, false)
+	mfaUser.MFASecret = ""
+
+	mockRepo.On("GetUserByID", mock.Anything, mfaUser.ID.String()).Return(mfaUser, nil)
+
+	var stored string
+	mockRepo.On("SetPendingMFASecret", mock.Anything, mfaUser.ID, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { stored = args.String(2) }).
+		Return(nil)
+
+	enrollment, err := service.EnrollMFA(context.Background(), mfaUser.ID.String())
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, enrollment.Secret)
+	assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/"))
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+	assert.NotContains(t, stored, enrollment.Secret)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test EnrollMFA Service - Cannot re-enroll while enabled
+func TestService_EnrollMFA_AlreadyEnabled(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, mfaUser, _ := newMFATestService(mockRepo, true)
+
+	mockRepo.On("GetUserByID", mock.Anything, mfaUser.ID.String()).Return(mfaUser, nil)
+
+	_, err := service.EnrollMFA(context.Background(), mfaUser.ID.String())
+
+	assert.Error(t, err)
+	assert.Equal(t, "mfa is already enabled", err.Error())
+	mockRepo.AssertNotCalled(t, "SetPendingMFASecret", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test ConfirmMFA Service - Valid code enables MFA with hashed recovery codes
+func TestService_ConfirmMFA_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, mfaUser, secret := newMFATestService(mockRepo, false)
+
+	step := totp.Step(time.Now())
+	code, _ := totp.Code(secret, step)
+	mockRepo.On("GetUserByID", mock.Anything, mfaUser.ID.String()).Return(mfaUser, nil)
+
+	var hashes []string
+	mockRepo.On("EnableMFA", mock.Anything, mfaUser.ID, step, mock.Anything).
+		Run(func(args mock.Arguments) { hashes = args.Get(3).([]string) }).
+		Return(nil)
+
+	codes, err := service.ConfirmMFA(context.Background(), mfaUser.ID.String(), code)
+
+	assert.NoError(t, err)
+	assert.Len(t, codes, 10)
+	assert.Len(t, hashes, 10)
+	assert.Equal(t, hashing.HashToken(strings.ReplaceAll(codes[0], "-", "")), hashes[0])
+	assert.NotContains(t, hashes, codes[0])
+	mockRepo.AssertExpectations(t)
+}
+
+// Test ConfirmMFA Service - Wrong code keeps MFA disabled
+func TestService_ConfirmMFA_InvalidCode(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, mfaUser, _ := newMFATestService(mockRepo, false)
+
+	mockRepo.On("GetUserByID", mock.Anything, mfaUser.ID.String()).Return(mfaUser, nil)
+
+	_, err := service.ConfirmMFA(context.Background(), mfaUser.ID.String(), "000000")
+
+	assert.Error(t, err)
+	assert.Equal(t, "invalid mfa code", err.Error())
+	mockRepo.AssertNotCalled(t, "EnableMFA", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test VerifyMFA Service - Challenge plus TOTP code signs the user in
+func TestService_VerifyMFA_TOTP(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, mfaUser, secret := newMFATestService(mockRepo, true)
+
+	step := totp.Step(time.Now())
+	code, _ := totp.Code(secret, step)
+	mockRepo.On("GetUserByID", mock.Anything, mfaUser.ID.String()).Return(mfaUser, nil)
+	mockRepo.On("ClaimMFAStep", mock.Anything, mfaUser.ID, step).Return(true, nil).Once()
+
+	challenge, err := service.CreateMFAChallenge(context.Background(), mfaUser)
+	assert.NoError(t, err)
+
+	signedIn, err := service.VerifyMFA(context.Background(), challenge, code, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, mfaUser.ID, signedIn.ID)
+
+	// The same code cannot be replayed
+	mockRepo.On("ClaimMFAStep", mock.Anything, mfaUser.ID, step).Return(false, nil).Once()
+	_, err = service.VerifyMFA(context.Background(), challenge, code, "")
+	assert.Error(t, err)
+	assert.Equal(t, "invalid mfa code", err.Error())
+}
+
+// Test VerifyMFA Service - Recovery codes are accepted once, in any case or format
+func TestService_VerifyMFA_RecoveryCode(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, mfaUser, _ := newMFATestService(mockRepo, true)
+
+	mockRepo.On("GetUserByID", mock.Anything, mfaUser.ID.String()).Return(mfaUser, nil)
+	mockRepo.On("UseRecoveryCode", mock.Anything, mfaUser.ID, hashing.HashToken("abcdefghij")).Return(true, nil)
+
+	challenge, _ := service.CreateMFAChallenge(context.Background(), mfaUser)
+	signedIn, err := service.VerifyMFA(context.Background(), challenge, " ABCDE-FGHIJ ", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, mfaUser.ID, signedIn.ID)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test VerifyMFA Service - Challenge tokens must be valid
+func TestService_VerifyMFA_InvalidChallenge(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, mfaUser, _ := newMFATestService(mockRepo, true)
+
+	expired, _ := signedtoken.Sign([]byte(testVerificationSecret), "mfa_challenge", mfaUser.ID.String(), time.Now().Add(-time.Minute))
+	verification, _ := signedtoken.Sign([]byte(testVerificationSecret), "email_verification", mfaUser.ID.String(), time.Now().Add(time.Minute))
+
+	_, err := service.VerifyMFA(context.Background(), expired, "123456", "")
+	assert.Equal(t, "mfa token expired", err.Error())
+
+	_, err = service.VerifyMFA(context.Background(), verification, "123456", "")
+	assert.Equal(t, "invalid mfa token", err.Error())
+}
+
+// newLockoutMFATestService is newMFATestService with its own lockout guard and MFA attempt limit
+func newLockoutMFATestService(repo Repository, guard *lockout.Guard, maxAttempts int) (Service, *user.User, string) {
+	box, _ := secretbox.New(bytes.Repeat([]byte{7}, secretbox.KeySize))
+	secret, _ := totp.GenerateSecret()
+	encrypted, _ := box.Seal([]byte(secret))
+
+	mfaUser := &user.User{ID: uuid.New(), Email: "admin@example.com", MFAEnabled: true, MFASecret: encrypted}
+	return newTestService(repo, Config{MFASecretBox: box, Lockout: guard, MFAMaxAttempts: maxAttempts}), mfaUser, secret
+}
+
+// Test VerifyMFA Service - Wrong codes lock the account out like wrong passwords
+func TestService_VerifyMFA_LockedOut(t *testing.T) {
+	mockRepo := new(MockRepository)
+	guard := lockout.NewGuard(lockout.NewMemoryStore(0), lockout.Config{MaxAttempts: 3, BaseDelay: time.Nanosecond, LockoutDuration: time.Hour})
+	service, mfaUser, secret := newLockoutMFATestService(mockRepo, guard, 10)
+
+	mockRepo.On("GetUserByID", mock.Anything, mfaUser.ID.String()).Return(mfaUser, nil)
+	mockRepo.On("UseRecoveryCode", mock.Anything, mfaUser.ID, mock.AnythingOfType("string")).Return(false, nil)
+
+	challenge, _ := service.CreateMFAChallenge(context.Background(), mfaUser)
+	for i := 0; i < 3; i++ {
+		_, err := service.VerifyMFA(context.Background(), challenge, "wrong-code", "203.0.113.7")
+		assert.Equal(t, "invalid mfa code", err.Error())
+		time.Sleep(time.Millisecond) // Past the backoff
+	}
+
+	// Even the right code is rejected while locked, without touching the repository
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	_, err := service.VerifyMFA(context.Background(), challenge, code, "198.51.100.1")
+
+	var locked *lockout.LockedError
+	assert.True(t, errors.As(err, &locked))
+	assert.Equal(t, time.Hour, locked.RetryAfter)
+	mockRepo.AssertNotCalled(t, "ClaimMFAStep", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNumberOfCalls(t, "UseRecoveryCode", 3)
+}
+
+// Test VerifyMFA Service - The challenge stops working after MFAMaxAttempts wrong codes
+func TestService_VerifyMFA_ChallengeInvalidated(t *testing.T) {
+	mockRepo := new(MockRepository)
+	guard := lockout.NewGuard(lockout.NewMemoryStore(0), lockout.Config{MaxAttempts: 10, BaseDelay: time.Nanosecond})
+	service, mfaUser, secret := newLockoutMFATestService(mockRepo, guard, 2)
+
+	mockRepo.On("GetUserByID", mock.Anything, mfaUser.ID.String()).Return(mfaUser, nil)
+	mockRepo.On("UseRecoveryCode", mock.Anything, mfaUser.ID, mock.AnythingOfType("string")).Return(false, nil)
+
+	challenge, _ := service.CreateMFAChallenge(context.Background(), mfaUser)
+	for i := 0; i < 2; i++ {
+		_, err := service.VerifyMFA(context.Background(), challenge, "wrong-code", "")
+		assert.Equal(t, "invalid mfa code", err.Error())
+		time.Sleep(time.Millisecond) // Past the backoff
+	}
+
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	_, err := service.VerifyMFA(context.Background(), challenge, code, "")
+
+	assert.Equal(t, "invalid mfa token", err.Error())
+	mockRepo.AssertNotCalled(t, "ClaimMFAStep", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test mfaChallengeRevocationID - Fits the revoked_tokens.jti column
+func TestMFAChallengeRevocationID(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service, mfaUser, _ := newMFATestService(mockRepo, true)
+	challenge, _ := service.CreateMFAChallenge(context.Background(), mfaUser)
+
+	id := mfaChallengeRevocationID(challenge)
+
+	assert.LessOrEqual(t, len(id), revocation.MaxIDLength)
+	assert.NotEqual(t, id, hashing.HashToken(challenge))
+}
+
+// Test SignIn Service - The right password does not clear failures while the second factor is pending
+func TestService_SignIn_MFAKeepsFailures(t *testing.T) {
+	mockRepo := new(MockRepository)
+	guard := lockout.NewGuard(lockout.NewMemoryStore(0), lockout.Config{BaseDelay: time.Nanosecond})
+	service, mfaUser, _ := newLockoutMFATestService(mockRepo, guard, 0)
+
+	mfaUser.Password, _ = hashing.HashPassword("password123")
+	mockRepo.On("GetUserByEmail", mock.Anything, mfaUser.Email).Return(mfaUser, nil)
+
+	_, _, err := service.SignIn(context.Background(), mfaUser.Email, "wrongpassword", Device{})
+	assert.Error(t, err)
+	time.Sleep(time.Millisecond) // Past the backoff
+
+	tokens, _, err := service.SignIn(context.Background(), mfaUser.Email, "password123", Device{})
+	assert.NoError(t, err)
+	assert.Nil(t, tokens)
+
+	statuses, err := guard.Status(mfaUser.Email)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, statuses[mfaUser.Email].Failures)
+}
@@ -1,40 +1,351 @@
 package container
 
 import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/golang-fiber-jwt/config"
+	"github.com/golang-fiber-jwt/internal/apikey"
 	"github.com/golang-fiber-jwt/internal/auth"
+	"github.com/golang-fiber-jwt/internal/middleware"
 	"github.com/golang-fiber-jwt/internal/user"
+	"github.com/golang-fiber-jwt/pkg/feature"
+	"github.com/golang-fiber-jwt/pkg/hashing"
+	"github.com/golang-fiber-jwt/pkg/jwt"
+	"github.com/golang-fiber-jwt/pkg/lockout"
+	"github.com/golang-fiber-jwt/pkg/loglevel"
+	"github.com/golang-fiber-jwt/pkg/mailer"
+	"github.com/golang-fiber-jwt/pkg/oauth"
+	"github.com/golang-fiber-jwt/pkg/passwordpolicy"
+	"github.com/golang-fiber-jwt/pkg/ratelimit"
+	"github.com/golang-fiber-jwt/pkg/rbac"
+	"github.com/golang-fiber-jwt/pkg/revocation"
+	"github.com/golang-fiber-jwt/pkg/secretbox"
+	"github.com/golang-fiber-jwt/pkg/signedtoken"
 	"gorm.io/gorm"
 )
 
 // Container holds all application dependencies
 type Container struct {
-	AuthHandler *auth.Handler
-	UserHandler *user.Handler
+	AuthHandler   *auth.Handler
+	UserHandler   *user.Handler
+	APIKeyHandler *apikey.Handler
 	// Add other handlers here as you create new modules
 	// ProductHandler *product.Handler
 	// OrderHandler   *order.Handler
+
+	AuthMiddleware *middleware.AuthMiddleware
+	RateLimits     middleware.RateLimits
+	Deadlines      middleware.Deadlines
+	Features       middleware.Features
+	CORS           fiber.Handler
 }
 
+// Default rate limits of the route groups: strict on credentials, relaxed for the API
+const (
+	defaultAuthRateLimit  = "sliding_window:10/1m"
+	defaultUsersRateLimit = "token_bucket:120/1m"
+)
+
+// Default request deadlines of the route groups, auth leaves room for password hashing and SMTP
+const (
+	defaultAuthRequestTimeout  = 15 * time.Second
+	defaultUsersRequestTimeout = 5 * time.Second
+)
+
 // NewContainer creates a new dependency injection container
-func NewContainer(db *gorm.DB) *Container {
+// Dependencies are built from the current configuration, the reloadable settings
+// (log level, rate limits, CORS, feature toggles, JWT secret) follow the reloads of settings
+func NewContainer(db *gorm.DB, settings *config.Manager) (*Container, error) {
+	cfg := settings.Current()
+
+	level, err := loglevel.Parse(cfg.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	loglevel.Set(level)
+	settings.Subscribe(func(prev, next *config.AppConfig) {
+		if level, err := loglevel.Parse(next.LogLevel); err == nil {
+			loglevel.Set(level)
+		}
+	})
+
+	// Shared infrastructure
+	revocations, err := revocation.NewStore(cfg.TokenRevocationStore, db, cfg.TokenRevocationCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Failed sign-ins are counted per account and IP address, shared with the user module for unlocks
+	lockouts, err := lockout.NewStore(cfg.LoginLockoutStore, db, cfg.LoginLockoutCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	signInGuard := lockout.NewGuard(lockouts, lockout.Config{
+		MaxAttempts:        cfg.LoginMaxAttempts,
+		IPMaxAttempts:      cfg.LoginIPMaxAttempts,
+		BaseDelay:          cfg.LoginBackoffBase,
+		LockoutDuration:    cfg.LoginLockoutDuration,
+		MaxLockoutDuration: cfg.LoginLockoutMaxDuration,
+		Window:             cfg.LoginFailureWindow,
+	})
+
+	mail, err := mailer.New(mailer.Config{
+		Backend:      cfg.MailerBackend,
+		LogFile:      cfg.MailerLogFile,
+		From:         cfg.MailFrom,
+		SMTPHost:     cfg.SMTPHost,
+		SMTPPort:     cfg.SMTPPort,
+		SMTPUsername: cfg.SMTPUsername,
+		SMTPPassword: cfg.SMTPPassword,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	policy := rbac.DefaultPolicy()
+	if cfg.RBACPolicyFile != "" {
+		if policy, err = rbac.LoadPolicy(cfg.RBACPolicyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	// One policy for every place a password is set
+	passwordPolicy := passwordpolicy.Policy{
+		MinLength:     cfg.PasswordMinLength,
+		MaxLength:     cfg.PasswordMaxLength,
+		RequireUpper:  cfg.PasswordRequireUpper,
+		RequireLower:  cfg.PasswordRequireLower,
+		RequireDigit:  cfg.PasswordRequireDigit,
+		RequireSymbol: cfg.PasswordRequireSymbol,
+	}
+
+	passwordHasher, err := hashing.New(hashing.Config{
+		Algorithm:         cfg.PasswordHashAlgorithm,
+		BcryptCost:        cfg.BcryptCost,
+		Argon2Memory:      cfg.Argon2Memory,
+		Argon2Iterations:  cfg.Argon2Iterations,
+		Argon2Parallelism: cfg.Argon2Parallelism,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Providers without a client ID stay disabled
+	oauthProviders := oauth.NewRegistry(
+		oauth.Google(cfg.GoogleClientID, cfg.GoogleClientSecret, cfg.AppBaseURL+"/api/auth/google/callback"),
+		oauth.Facebook(cfg.FacebookClientID, cfg.FacebookClientSecret, cfg.AppBaseURL+"/api/auth/facebook/callback"),
+	)
+
+	// TOTP secrets are encrypted at rest with their own key, never one of the rotatable signing secrets
+	mfaBox, err := secretbox.NewFromString(cfg.MFAEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Access tokens are signed with an asymmetric key that is rotated on a schedule
+	// Retired keys keep verifying for the grace period, by default one access token lifetime
+	gracePeriod := cfg.JwtKeyGracePeriod
+	if gracePeriod == 0 {
+		gracePeriod = cfg.JwtExpiresIn
+	}
+	signingKeys, err := jwt.NewKeySet(jwt.KeySetConfig{
+		Algorithm:        cfg.JwtAlgorithm,
+		Dir:              cfg.JwtKeysDir,
+		RotationInterval: cfg.JwtKeyRotationInterval,
+		GracePeriod:      gracePeriod,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Rotation runs for the lifetime of the process
+	signingKeys.StartRotation(nil)
+
+	// The one place access tokens are issued and validated
+	issuer := cfg.JwtIssuer
+	if issuer == "" {
+		issuer = cfg.AppBaseURL
+	}
+	audience := cfg.JwtAudience
+	if audience == "" {
+		audience = issuer
+	}
+	tokens := jwt.NewTokenService(signingKeys, jwt.TokenConfig{
+		Issuer:    issuer,
+		Audience:  audience,
+		TTL:       cfg.JwtExpiresIn,
+		ClockSkew: cfg.JwtClockSkew,
+	})
+
+	// Verification links, OAuth state and MFA challenges signed before a secret rotation
+	// stay valid for the grace period
+	verificationKeys := signedtoken.NewKeyring([]byte(verificationSecret(cfg)))
+	settings.Subscribe(func(prev, next *config.AppConfig) {
+		verificationKeys.Rotate([]byte(verificationSecret(next)), next.JwtSecretGracePeriod)
+	})
+
 	// Auth
 	authRepo := auth.NewAuthRepository(db)
-	authService := auth.NewAuthService(authRepo)
-	authHandler := auth.NewAuthHandler(authService)
+	authService := auth.NewAuthService(authRepo, revocations, tokens, mail, auth.Config{
+		RefreshTokenTTL:            cfg.RefreshTokenExpiresIn,
+		VerificationKeys:           verificationKeys,
+		VerificationTTL:            cfg.VerificationExpiresIn,
+		VerificationResendCooldown: cfg.VerificationResendCooldown,
+		VerificationURL:            cfg.AppBaseURL + "/api/auth/verify",
+		RequireVerifiedEmail:       cfg.RequireEmailVerification,
+		PasswordResetTTL:           cfg.PasswordResetExpiresIn,
+		PasswordResetURL:           cfg.ClientOrigin + "/reset-password",
+		PasswordPolicy:             passwordPolicy,
+		PasswordHasher:             passwordHasher,
+		Lockout:                    signInGuard,
+		OAuthProviders:             oauthProviders,
+		MFAIssuer:                  cfg.MFAIssuer,
+		MFASecretBox:               mfaBox,
+		MFAChallengeTTL:            cfg.MFAChallengeExpiresIn,
+	})
+	authHandler := auth.NewAuthHandler(authService, signingKeys, auth.CookieConfig{
+		AccessTokenMaxAge:  time.Duration(cfg.JwtMaxAge) * time.Minute,
+		RefreshTokenMaxAge: cfg.RefreshTokenExpiresIn,
+		Domain:             cfg.CookieDomain,
+		Secure:             cfg.CookieSecure,
+		SameSite:           cfg.CookieSameSite,
+	})
 
 	// User
 	userRepo := user.NewUserRepository(db)
-	userService := user.NewUserService(userRepo)
+	userService := user.NewUserService(userRepo, passwordPolicy, passwordHasher, signInGuard, authService)
 	userHandler := user.NewUserHandler(userService)
 
+	// API keys (scopes are checked against the owner's role)
+	apiKeyRepo := apikey.NewAPIKeyRepository(db)
+	apiKeyService := apikey.NewAPIKeyService(apiKeyRepo, authService, policy, apikey.Config{
+		DefaultTTL:     cfg.APIKeyDefaultExpiresIn,
+		MaxTTL:         cfg.APIKeyMaxExpiresIn,
+		MaxKeysPerUser: cfg.APIKeyMaxPerUser,
+	})
+	apiKeyHandler := apikey.NewAPIKeyHandler(apiKeyService)
+
 	// Wire other modules here
 	// productRepo := postgresql.NewProductRepository(db)
 	// productService := product.NewAuthService(productRepo)
 	// productHandler := product.NewAuthHandler(productService)
 
+	// Middlewares
+	authMiddleware := middleware.NewAuthMiddleware(authService, authService, policy, tokens, apiKeyService)
+
+	rateLimitStore, err := ratelimit.NewStore(cfg.RateLimitStore, db, cfg.RateLimitCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	authLimit, err := parseRateLimit(cfg.RateLimitAuth, defaultAuthRateLimit)
+	if err != nil {
+		return nil, err
+	}
+	usersLimit, err := parseRateLimit(cfg.RateLimitUsers, defaultUsersRateLimit)
+	if err != nil {
+		return nil, err
+	}
+	rateLimiter := middleware.NewRateLimiter(rateLimitStore)
+	authRateLimit := middleware.NewReloadable(rateLimiter.Limit(authRateLimitPolicy(authLimit)))
+	usersRateLimit := middleware.NewReloadable(rateLimiter.Limit(usersRateLimitPolicy(usersLimit)))
+	settings.Subscribe(func(prev, next *config.AppConfig) {
+		// Counters live in the store, so they carry over to the new limits
+		if next.RateLimitAuth != prev.RateLimitAuth {
+			if limit, err := parseRateLimit(next.RateLimitAuth, defaultAuthRateLimit); err == nil {
+				authRateLimit.Swap(rateLimiter.Limit(authRateLimitPolicy(limit)))
+			}
+		}
+		if next.RateLimitUsers != prev.RateLimitUsers {
+			if limit, err := parseRateLimit(next.RateLimitUsers, defaultUsersRateLimit); err == nil {
+				usersRateLimit.Swap(rateLimiter.Limit(usersRateLimitPolicy(limit)))
+			}
+		}
+	})
+
+	toggles := feature.NewToggles(featureToggles(cfg))
+	settings.Subscribe(func(prev, next *config.AppConfig) {
+		toggles.Set(featureToggles(next))
+	})
+
+	corsHandler := middleware.NewReloadable(newCORS(cfg))
+	settings.Subscribe(func(prev, next *config.AppConfig) {
+		corsHandler.Swap(newCORS(next))
+	})
+
 	return &Container{
-		AuthHandler: authHandler,
-		UserHandler: userHandler,
+		AuthHandler:   authHandler,
+		UserHandler:   userHandler,
+		APIKeyHandler: apiKeyHandler,
 		// ProductHandler: productHandler,
+		AuthMiddleware: authMiddleware,
+		RateLimits: middleware.RateLimits{
+			Auth:  authRateLimit.Handle,
+			Users: usersRateLimit.Handle,
+		},
+		Deadlines: middleware.Deadlines{
+			Auth:  middleware.Deadline(durationOr(cfg.RequestTimeoutAuth, defaultAuthRequestTimeout)),
+			Users: middleware.Deadline(durationOr(cfg.RequestTimeoutUsers, defaultUsersRequestTimeout)),
+		},
+		Features: middleware.Features{
+			Signup:     middleware.RequireFeature(toggles, feature.Signup),
+			OAuthLogin: middleware.RequireFeature(toggles, feature.OAuthLogin),
+			APIKeys:    middleware.RequireFeature(toggles, feature.APIKeys),
+		},
+		CORS: corsHandler.Handle,
+	}, nil
+}
+
+// authRateLimitPolicy counts the credential and email endpoints per IP address
+func authRateLimitPolicy(limit ratelimit.Limit) middleware.RateLimitPolicy {
+	return middleware.RateLimitPolicy{Name: "auth", Limit: limit, Key: middleware.KeyByIP}
+}
+
+// usersRateLimitPolicy counts the user routes per API key or user
+func usersRateLimitPolicy(limit ratelimit.Limit) middleware.RateLimitPolicy {
+	return middleware.RateLimitPolicy{Name: "users", Limit: limit, Key: middleware.KeyByAPIKey}
+}
+
+// verificationSecret is the secret of verification tokens, the JWT secret unless one is set
+func verificationSecret(cfg *config.AppConfig) string {
+	if cfg.VerificationSecret != "" {
+		return cfg.VerificationSecret
+	}
+	return cfg.JwtSecret
+}
+
+// featureToggles returns the state of the feature toggles in cfg
+func featureToggles(cfg *config.AppConfig) map[string]bool {
+	return map[string]bool{
+		feature.Signup:     cfg.FeatureSignup,
+		feature.OAuthLogin: cfg.FeatureOAuthLogin,
+		feature.APIKeys:    cfg.FeatureAPIKeys,
+	}
+}
+
+// newCORS builds the CORS handler of cfg, credentials (the auth cookies) are allowed
+func newCORS(cfg *config.AppConfig) fiber.Handler {
+	return cors.New(cors.Config{
+		AllowOrigins:     strings.Join(cfg.CORSAllowOrigins, ","),
+		AllowHeaders:     strings.Join(cfg.CORSAllowHeaders, ","),
+		AllowMethods:     strings.Join(cfg.CORSAllowMethods, ","),
+		AllowCredentials: true,
+	})
+}
+
+// parseRateLimit parses a rate limit spec, falling back to fallback when it is not set
+func parseRateLimit(spec, fallback string) (ratelimit.Limit, error) {
+	if spec == "" {
+		spec = fallback
+	}
+	return ratelimit.ParseLimit(spec)
+}
+
+// durationOr returns d, or fallback when d is not set
+func durationOr(d, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
 	}
+	return d
 }
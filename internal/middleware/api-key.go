@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-fiber-jwt/internal/apikey"
+	"github.com/golang-fiber-jwt/internal/user"
+	"github.com/golang-fiber-jwt/pkg/handler"
+	"github.com/golang-fiber-jwt/pkg/jwt"
+)
+
+// APIKeyAuthenticator resolves an API key to the key and its owner
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, rawKey string) (*apikey.APIKey, *user.User, error)
+}
+
+// apiKeyScheme is the Authorization scheme of API keys: "Authorization: ApiKey <key>"
+const apiKeyScheme = "ApiKey "
+
+// DeserializeUserOrAPIKey authenticates with an API key, or with an access token like DeserializeUser
+// API key callers get the same claims as token callers, restricted to the key's scopes by Require
+func (m *AuthMiddleware) DeserializeUserOrAPIKey(c *fiber.Ctx) error {
+	authorization := c.Get("Authorization")
+	if !strings.HasPrefix(authorization, apiKeyScheme) {
+		return m.DeserializeUser(c)
+	}
+
+	key, owner, err := m.apiKeys.Authenticate(c.UserContext(), strings.TrimSpace(strings.TrimPrefix(authorization, apiKeyScheme)))
+	if err != nil {
+		return authenticationError(err, errAPIKeyValidation)
+	}
+
+	handler.SetClaims(c, &jwt.Claims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   owner.ID.String(),
+			ExpiresAt: key.ExpiresAt.Unix(),
+		},
+		Role:         owner.Role,
+		TokenVersion: owner.TokenVersion,
+		Scopes:       key.Scopes,
+		APIKeyID:     key.ID.String(),
+	})
+
+	return c.Next()
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Deadlines are the request deadline handlers of the route groups
+type Deadlines struct {
+	// Auth bounds the credential and email endpoints (password hashing, mail delivery)
+	Auth fiber.Handler
+	// Users bounds the user and API key routes
+	Users fiber.Handler
+}
+
+// Deadline bounds the time spent on a request: the request's user context is canceled
+// after timeout, aborting the database queries run with it
+// Handlers pass c.UserContext() to the services, which fail with context.DeadlineExceeded
+func Deadline(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
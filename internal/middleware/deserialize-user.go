@@ -1,15 +1,52 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-fiber-jwt/config"
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-fiber-jwt/internal/user"
+	"github.com/golang-fiber-jwt/pkg/apperror"
+	"github.com/golang-fiber-jwt/pkg/handler"
+	"github.com/golang-fiber-jwt/pkg/jwt"
+	"github.com/golang-fiber-jwt/pkg/rbac"
 )
 
-func DeserializeUser(c *fiber.Ctx) error {
+// TokenValidator checks whether a parsed access token is still honoured
+// (not revoked, its session not revoked and issued for the user's current token version)
+type TokenValidator interface {
+	ValidateAccessToken(ctx context.Context, jti, userID, sessionID string, tokenVersion int) error
+}
+
+// UserLoader loads the authenticated caller
+type UserLoader interface {
+	GetUserByID(ctx context.Context, id string) (*user.User, error)
+}
+
+// AuthMiddleware authenticates requests using the access token
+// and authorizes them against the RBAC policy
+type AuthMiddleware struct {
+	validator TokenValidator
+	users     UserLoader
+	policy    *rbac.Policy
+	tokens    jwt.TokenService
+	apiKeys   APIKeyAuthenticator
+}
+
+// NewAuthMiddleware creates a new auth middleware
+func NewAuthMiddleware(validator TokenValidator, users UserLoader, policy *rbac.Policy, tokens jwt.TokenService, apiKeys APIKeyAuthenticator) *AuthMiddleware {
+	return &AuthMiddleware{
+		validator: validator,
+		users:     users,
+		policy:    policy,
+		tokens:    tokens,
+		apiKeys:   apiKeys,
+	}
+}
+
+// DeserializeUser verifies the access token and stores its claims in the request context
+func (m *AuthMiddleware) DeserializeUser(c *fiber.Ctx) error {
 	var tokenString string
 	authorization := c.Get("Authorization")
 
@@ -20,29 +57,22 @@ func DeserializeUser(c *fiber.Ctx) error {
 	}
 
 	if tokenString == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "fail", "message": "You are not logged in"})
+		return errNotLoggedIn
 	}
 
-	cfg, _ := config.LoadConfig(".")
-
-	tokenByte, err := jwt.Parse(tokenString, func(jwtToken *jwt.Token) (interface{}, error) {
-		if _, ok := jwtToken.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %s", jwtToken.Header["alg"])
-		}
-
-		return []byte(cfg.JwtSecret), nil
-	})
+	// Signature, expiry (with clock skew), issuer and audience
+	claims, err := m.tokens.Validate(tokenString)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "fail", "message": fmt.Sprintf("invalidate token: %v", err)})
+		return apperror.Unauthorized("invalid_token", fmt.Sprintf("invalidate token: %v", err)).Wrap(err)
 	}
 
-	claims, ok := tokenByte.Claims.(jwt.MapClaims)
-	if !ok || !tokenByte.Valid {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "fail", "message": "invalid token claim"})
+	// Reject tokens revoked on logout or by a "log out everywhere"
+	if err := m.validator.ValidateAccessToken(c.UserContext(), claims.Id, claims.Subject, claims.SessionID, claims.TokenVersion); err != nil {
+		return authenticationError(err, errTokenValidation)
 	}
 
-	// Store user ID in context for handlers to use
-	c.Locals("userId", fmt.Sprint(claims["sub"]))
+	// Store the typed claims in context for handlers to use (see handler.Claims)
+	handler.SetClaims(c, claims)
 
 	return c.Next()
 }
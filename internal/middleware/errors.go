@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang-fiber-jwt/pkg/apperror"
+)
+
+// Errors returned by the auth middleware, rendered by the app's error handler
+var (
+	errNotLoggedIn      = apperror.Unauthorized("not_logged_in", "You are not logged in")
+	errCallerNotFound   = apperror.Unauthorized("user_not_found", "user not found")
+	errPermissionDenied = apperror.Forbidden("permission_denied", "You do not have permission to perform this action")
+	errScopeDenied      = apperror.Forbidden("scope_denied", "API key scope does not allow this action")
+	errTokenValidation  = apperror.New(apperror.KindInternal, "token_validation_failed", "Failed to validate token")
+	errAPIKeyValidation = apperror.New(apperror.KindInternal, "api_key_validation_failed", "Failed to validate api key")
+)
+
+// authenticationError turns a credential check failure into a 401, whatever kind the
+// service gave it (a deleted owner is "not found" to the service but not to the caller)
+// Untyped errors are infrastructure failures and become internalErr, an expired request
+// deadline is returned as is
+func authenticationError(err error, internalErr *apperror.Error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	appErr, ok := apperror.As(err)
+	if !ok || appErr.Kind == apperror.KindInternal {
+		return internalErr.Wrap(err)
+	}
+	return apperror.Unauthorized(appErr.Code, appErr.Message).Wrap(err)
+}
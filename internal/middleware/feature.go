@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-fiber-jwt/pkg/apperror"
+	"github.com/golang-fiber-jwt/pkg/feature"
+)
+
+var errFeatureDisabled = apperror.NotFound("feature_disabled", "This feature is disabled")
+
+// Features are the feature toggle handlers of the routes that can be switched off
+type Features struct {
+	// Signup guards registration
+	Signup fiber.Handler
+	// OAuthLogin guards the social login redirects
+	OAuthLogin fiber.Handler
+	// APIKeys guards API key management, keys already issued keep authenticating
+	APIKeys fiber.Handler
+}
+
+// RequireFeature answers 404 while name is toggled off
+// The toggle is checked on every request, so reloads take effect immediately
+func RequireFeature(toggles *feature.Toggles, name string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !toggles.Enabled(name) {
+			return errFeatureDisabled
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-fiber-jwt/pkg/apperror"
+	"github.com/golang-fiber-jwt/pkg/handler"
+	"github.com/golang-fiber-jwt/pkg/loglevel"
+	"github.com/golang-fiber-jwt/pkg/ratelimit"
+)
+
+// RateLimitKey identifies the caller a request is counted against
+type RateLimitKey func(c *fiber.Ctx) string
+
+// KeyByIP counts requests per client IP address
+func KeyByIP(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// KeyByUser counts requests per authenticated user, falling back to the IP address
+// Must run after DeserializeUser to see the user
+func KeyByUser(c *fiber.Ctx) string {
+	if claims, ok := handler.Claims(c); ok {
+		return "user:" + claims.Subject
+	}
+	return KeyByIP(c)
+}
+
+// KeyByAPIKey counts requests per API key, falling back to the user and then the IP address
+// Must run after DeserializeUserOrAPIKey to see the key
+func KeyByAPIKey(c *fiber.Ctx) string {
+	if claims, ok := handler.Claims(c); ok && claims.APIKeyID != "" {
+		return "apikey:" + claims.APIKeyID
+	}
+	return KeyByUser(c)
+}
+
+// RateLimitPolicy is the limit of a route group
+type RateLimitPolicy struct {
+	// Name separates the counters of policies sharing a store
+	Name  string
+	Limit ratelimit.Limit
+	Key   RateLimitKey
+}
+
+// RateLimits are the rate limit handlers of the route groups
+type RateLimits struct {
+	// Auth guards the credential and email endpoints, per IP address
+	Auth fiber.Handler
+	// Users guards the user routes, per API key or user
+	Users fiber.Handler
+}
+
+// RateLimiter enforces rate limit policies on top of a store
+type RateLimiter struct {
+	store ratelimit.Store
+}
+
+// NewRateLimiter creates a new rate limiter
+func NewRateLimiter(store ratelimit.Store) *RateLimiter {
+	return &RateLimiter{store: store}
+}
+
+// Limit returns a handler enforcing policy
+// Responses carry the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy
+// headers; rejected requests fail with a too many requests error carrying Retry-After
+func (l *RateLimiter) Limit(policy RateLimitPolicy) fiber.Handler {
+	if policy.Limit.Disabled() {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+	if policy.Key == nil {
+		policy.Key = KeyByIP
+	}
+
+	policyHeader := fmt.Sprintf("%d;w=%d", policy.Limit.Requests, int(policy.Limit.Window/time.Second))
+
+	return func(c *fiber.Ctx) error {
+		result, err := l.store.Allow(policy.Name+":"+policy.Key(c), policy.Limit)
+		if err != nil {
+			// Fail open, an unavailable store must not take the API down with it
+			loglevel.Printf(loglevel.Error, "ratelimit: %s: %v", policy.Name, err)
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		c.Set("RateLimit-Policy", policyHeader)
+
+		if !result.Allowed {
+			return apperror.TooManyRequests("rate_limited", "Too many requests, please try again later", result.RetryAfter)
+		}
+
+		return c.Next()
+	}
+}
+
+// ceilSeconds rounds a duration up to whole seconds, as used by the headers
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
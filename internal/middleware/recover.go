@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-fiber-jwt/pkg/apperror"
+	"github.com/golang-fiber-jwt/pkg/loglevel"
+	"github.com/golang-fiber-jwt/pkg/response"
+)
+
+// errPanic is returned for a request whose handler panicked
+var errPanic = apperror.New(apperror.KindInternal, "internal_error", "Internal server error")
+
+// Recover turns a panic into an internal error rendered by the app's error handler,
+// logging the stack trace with the request ID
+// Must be registered right after RequestID so it covers every other handler
+// and the logged panic carries the request ID
+func Recover(c *fiber.Ctx) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			loglevel.Printf(loglevel.Error, "panic: request_id=%s %s %s: %v\n%s", response.RequestID(c), c.Method(), c.OriginalURL(), r, debug.Stack())
+			err = errPanic.Wrap(fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	return c.Next()
+}
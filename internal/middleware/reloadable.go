@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Reloadable is a handler whose implementation is swapped when the configuration is reloaded
+// Requests in flight finish with the handler they started with
+type Reloadable struct {
+	handler atomic.Pointer[fiber.Handler]
+}
+
+// NewReloadable creates a reloadable handler running h
+func NewReloadable(h fiber.Handler) *Reloadable {
+	r := &Reloadable{}
+	r.Swap(h)
+	return r
+}
+
+// Handle runs the current handler
+func (r *Reloadable) Handle(c *fiber.Ctx) error {
+	return (*r.handler.Load())(c)
+}
+
+// Swap replaces the handler for the next requests
+func (r *Reloadable) Swap(h fiber.Handler) {
+	r.handler.Store(&h)
+}
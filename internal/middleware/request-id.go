@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-fiber-jwt/pkg/requestid"
+)
+
+// RequestID assigns every request an ID, the client's X-Request-ID when it is usable
+// The ID is returned in the X-Request-ID header and stored in c.Locals and the
+// request's user context (see requestid.FromContext) for logs and queries
+// Must be registered first, before Recover, so every log line of the request carries it
+func RequestID(c *fiber.Ctx) error {
+	id := c.Get(requestid.Header)
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+
+	c.Locals(requestid.LocalsKey, id)
+	c.SetUserContext(requestid.NewContext(c.UserContext(), id))
+	c.Set(requestid.Header, id)
+
+	return c.Next()
+}
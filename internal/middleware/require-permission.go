@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-fiber-jwt/pkg/handler"
+	"github.com/golang-fiber-jwt/pkg/rbac"
+	"gorm.io/gorm"
+)
+
+// Require only lets the request through when the caller's role is granted every permission
+// Must run after DeserializeUser, which stores the token claims
+func (m *AuthMiddleware) Require(perms ...rbac.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := handler.Claims(c)
+		if !ok {
+			return errNotLoggedIn
+		}
+
+		// Role is read from the database so role changes apply immediately
+		caller, err := m.users.GetUserByID(c.UserContext(), claims.Subject)
+		if err != nil {
+			// Only a deleted caller is a 401, a failed lookup is rendered as a server error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errCallerNotFound.Wrap(err)
+			}
+			return err
+		}
+
+		if !m.policy.Allows(caller.Role, perms...) {
+			return errPermissionDenied
+		}
+
+		// API keys are further limited to the scopes they were created with
+		if claims.APIKeyID != "" && !rbac.ScopesAllow(claims.Scopes, perms...) {
+			return errScopeDenied
+		}
+
+		c.Locals("role", caller.Role)
+		return c.Next()
+	}
+}
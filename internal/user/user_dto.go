@@ -26,18 +26,38 @@ type UpdateUserRequest struct {
 	Verified bool   `json:"verified"`
 }
 
+// UpdateProfileRequest represents the caller's own profile update HTTP request
+// Role and verified are intentionally absent so they cannot be self-assigned
+type UpdateProfileRequest struct {
+	Name  string `json:"name" validate:"omitempty,min=2,max=100"`
+	Photo string `json:"photo"`
+}
+
+// ChangePasswordRequest represents the caller's own password change HTTP request
+type ChangePasswordRequest struct {
+	CurrentPassword    string `json:"currentPassword" validate:"required"`
+	NewPassword        string `json:"newPassword" validate:"required"`
+	NewPasswordConfirm string `json:"newPasswordConfirm" validate:"required"`
+}
+
 // UserResponse represents user data for HTTP responses
 type UserResponse struct {
-	ID        uuid.UUID  `json:"id"`
-	Name      string     `json:"name"`
-	Email     string     `json:"email"`
-	Role      string     `json:"role"`
-	Provider  string     `json:"provider"`
-	Photo     string     `json:"photo"`
-	Verified  bool       `json:"verified"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	Provider   string     `json:"provider"`
+	Photo      string     `json:"photo"`
+	Verified   bool       `json:"verified"`
+	MFAEnabled bool       `json:"mfa_enabled"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+
+	// Sign-in lockout state (see lockout.Guard)
+	FailedLoginAttempts int        `json:"failed_login_attempts"`
+	Locked              bool       `json:"locked"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
 }
 
 // UserListResponse represents paginated user list response
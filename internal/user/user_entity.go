@@ -4,21 +4,27 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // User represents the user domain entity (pure business model)
 type User struct {
-	ID        uuid.UUID
-	Name      string
-	Email     string
-	Password  string
-	Role      string
-	Provider  string
-	Photo     string
-	Verified  bool
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt *time.Time
+	ID                 uuid.UUID
+	Name               string
+	Email              string
+	Password           string
+	Role               string
+	Provider           string
+	Photo              string
+	Verified           bool
+	TokenVersion       int
+	VerificationSentAt *time.Time
+	MFAEnabled         bool
+	MFASecret          string
+	MFALastStep        int64
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	DeletedAt          *time.Time
 }
 
 // CreateUserData represents user creation data for domain layer
@@ -41,19 +47,41 @@ type UpdateUserData struct {
 	Verified bool
 }
 
+// UpdateProfileData represents self-service profile update data for domain layer
+type UpdateProfileData struct {
+	Name  string
+	Photo string
+}
+
+// ChangePasswordData represents a password change of the user's own account
+type ChangePasswordData struct {
+	CurrentPassword    string
+	NewPassword        string
+	NewPasswordConfirm string
+	// SessionID is the caller's session, it stays signed in while the others are revoked
+	SessionID string
+	// IP is the caller's address, wrong current passwords count against it like failed sign-ins
+	IP string
+}
+
 // UserModel represents the database model with GORM tags (infrastructure concern)
 type UserModel struct {
-	ID        *uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primary_key"`
-	Name      string     `gorm:"type:varchar(100);not null"`
-	Email     string     `gorm:"type:varchar(100);uniqueIndex;not null"`
-	Password  string     `gorm:"type:varchar(100);not null"`
-	Role      string     `gorm:"type:varchar(50);default:'user';not null"`
-	Provider  string     `gorm:"type:varchar(50);default:'local';not null"`
-	Photo     string     `gorm:"type:text;default:'default.png';not null"`
-	Verified  bool       `gorm:"not null;default:false"`
-	CreatedAt time.Time  `gorm:"not null;default:now()"`
-	UpdatedAt time.Time  `gorm:"not null;default:now()"`
-	DeletedAt *time.Time `gorm:"index"`
+	ID                 *uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primary_key"`
+	Name               string     `gorm:"type:varchar(100);not null"`
+	Email              string     `gorm:"type:varchar(100);uniqueIndex;not null"`
+	Password           string     `gorm:"type:varchar(255);not null"`
+	Role               string     `gorm:"type:varchar(50);default:'user';not null"`
+	Provider           string     `gorm:"type:varchar(50);default:'local';not null"`
+	Photo              string     `gorm:"type:text;default:'default.png';not null"`
+	Verified           bool       `gorm:"not null;default:false"`
+	TokenVersion       int        `gorm:"not null;default:0"`
+	VerificationSentAt *time.Time
+	MFAEnabled         bool           `gorm:"not null;default:false"`
+	MFASecret          string         `gorm:"type:text"`
+	MFALastStep        int64          `gorm:"not null;default:0"`
+	CreatedAt          time.Time      `gorm:"not null;default:now()"`
+	UpdatedAt          time.Time      `gorm:"not null;default:now()"`
+	DeletedAt          gorm.DeletedAt `gorm:"index"`
 }
 
 // TableName specifies the table name for GORM
@@ -0,0 +1,19 @@
+package user
+
+import "github.com/golang-fiber-jwt/pkg/apperror"
+
+// Errors returned by the user service, their messages are shown to the client
+var (
+	ErrUserIDRequired           = apperror.Validation("user_id_required", "user ID is required")
+	ErrDeleteSelf               = apperror.Validation("delete_self", "you cannot delete your own account")
+	ErrInvalidUserID            = apperror.Validation("invalid_user_id", "invalid user ID format")
+	ErrUserNotFound             = apperror.NotFound("user_not_found", "user not found")
+	ErrNameRequired             = apperror.Validation("name_required", "name is required")
+	ErrEmailRequired            = apperror.Validation("email_required", "email is required")
+	ErrUserExists               = apperror.Conflict("user_exists", "user with that email already exists")
+	ErrEmailTaken               = apperror.Conflict("email_taken", "email is already taken by another user")
+	ErrCurrentPasswordIncorrect = apperror.Validation("current_password_incorrect", "current password is incorrect")
+	ErrPasswordMismatch         = apperror.Validation("password_mismatch", "passwords do not match")
+	ErrPasswordUnchanged        = apperror.Validation("password_unchanged", "new password must be different from the current password")
+	ErrUserNotDeleted           = apperror.Validation("user_not_deleted", "user is not deleted")
+)
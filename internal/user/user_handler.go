@@ -20,39 +20,24 @@ func NewUserHandler(service Service) *Handler {
 	}
 }
 
-// handleServiceError maps service errors to appropriate HTTP responses
-func (h *Handler) handleServiceError(c *fiber.Ctx, err error) error {
-	errorMessage := err.Error()
-
-	switch errorMessage {
-	case "user with that email already exists", "email is already taken by another user":
-		return response.Conflict(c, errorMessage)
-	case "name is required", "email is required", "password is required", "password must be at least 8 characters":
-		return response.BadRequest(c, errorMessage)
-	case "invalid user ID format":
-		return response.BadRequest(c, errorMessage)
-	case "user not found":
-		return response.NotFound(c, errorMessage)
-	case "user is not deleted":
-		return response.BadRequest(c, errorMessage)
-	default:
-		return response.InternalError(c, "Internal server error")
-	}
-}
-
 // userToResponse maps domain UserResponse to UserResponse DTO
 func (h *Handler) userToResponse(user *UserResponse) UserResponse {
 	return UserResponse{
-		ID:        user.ID,
-		Name:      user.Name,
-		Email:     user.Email,
-		Role:      user.Role,
-		Provider:  user.Provider,
-		Photo:     user.Photo,
-		Verified:  user.Verified,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		DeletedAt: user.DeletedAt,
+		ID:         user.ID,
+		Name:       user.Name,
+		Email:      user.Email,
+		Role:       user.Role,
+		Provider:   user.Provider,
+		Photo:      user.Photo,
+		Verified:   user.Verified,
+		MFAEnabled: user.MFAEnabled,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
+		DeletedAt:  user.DeletedAt,
+
+		FailedLoginAttempts: user.FailedLoginAttempts,
+		Locked:              user.Locked,
+		LockedUntil:         user.LockedUntil,
 	}
 }
 
@@ -112,7 +97,7 @@ func (h *Handler) ListUsers(c *fiber.Ctx) error {
 		defer close(resultChan)
 
 		// Fetch users and total count
-		users, total, err := h.service.GetUsers(query)
+		users, total, err := h.service.GetUsers(c.UserContext(), query)
 		if err != nil {
 			resultChan <- result{err: err}
 			return
@@ -132,7 +117,7 @@ func (h *Handler) ListUsers(c *fiber.Ctx) error {
 	// Wait for result
 	res := <-resultChan
 	if res.err != nil {
-		return h.handleServiceError(c, res.err)
+		return res.err
 	}
 
 	// Return response
@@ -150,13 +135,13 @@ func (h *Handler) GetUserByID(c *fiber.Ctx) error {
 	// Get ID from URL parameters
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "user ID is required")
+		return ErrUserIDRequired
 	}
 
 	// Call service
-	user, err := h.service.GetUserByID(id)
+	user, err := h.service.GetUserByID(c.UserContext(), id)
 	if err != nil {
-		return h.handleServiceError(c, err)
+		return err
 	}
 
 	// Map to response DTO
@@ -186,9 +171,9 @@ func (h *Handler) CreateUser(c *fiber.Ctx) error {
 	}
 
 	// Call service
-	err := h.service.CreateUser(createData)
+	err := h.service.CreateUser(c.UserContext(), createData)
 	if err != nil {
-		return h.handleServiceError(c, err)
+		return err
 	}
 
 	// Map to response DTO and return success
@@ -201,7 +186,7 @@ func (h *Handler) UpdateUser(c *fiber.Ctx) error {
 	// Get ID from URL parameters
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "user ID is required")
+		return ErrUserIDRequired
 	}
 
 	// Parse and validate request
@@ -220,25 +205,98 @@ func (h *Handler) UpdateUser(c *fiber.Ctx) error {
 	}
 
 	// Call service
-	err := h.service.UpdateUser(id, updateData)
+	err := h.service.UpdateUser(c.UserContext(), id, updateData)
 	if err != nil {
-		return h.handleServiceError(c, err)
+		return err
 	}
 
 	return response.OK(c, nil)
 }
 
-// DeleteUser handles DELETE /users/:id - soft delete user
+// UpdateMe handles PATCH /users/me - update the caller's own profile
+func (h *Handler) UpdateMe(c *fiber.Ctx) error {
+	// Get the caller from the token claims (set by middleware)
+	claims, ok := handler.Claims(c)
+	if !ok {
+		return handler.ErrUnauthenticated
+	}
+
+	// Parse and validate request
+	var req UpdateProfileRequest
+	if err := handler.ParseAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	// Call service
+	user, err := h.service.UpdateProfile(c.UserContext(), claims.Subject, &UpdateProfileData{
+		Name:  req.Name,
+		Photo: req.Photo,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Map to response DTO and return success
+	userResponse := h.userToResponse(user)
+	return response.OK(c, UserDataResponse{
+		User: userResponse,
+	})
+}
+
+// ChangePassword handles POST /users/me/password - change the caller's own password
+func (h *Handler) ChangePassword(c *fiber.Ctx) error {
+	// Get the caller from the token claims (set by middleware)
+	claims, ok := handler.Claims(c)
+	if !ok {
+		return handler.ErrUnauthenticated
+	}
+
+	// Parse and validate request
+	var req ChangePasswordRequest
+	if err := handler.ParseAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	// Call service
+	err := h.service.ChangePassword(c.UserContext(), claims.Subject, &ChangePasswordData{
+		CurrentPassword:    req.CurrentPassword,
+		NewPassword:        req.NewPassword,
+		NewPasswordConfirm: req.NewPasswordConfirm,
+		SessionID:          claims.SessionID,
+		IP:                 c.IP(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return response.SuccessWithMessage(c, fiber.StatusOK, "Password changed successfully")
+}
+
+// DeleteUser handles DELETE /users/:id - soft delete user (?hard=true deletes permanently)
 func (h *Handler) DeleteUser(c *fiber.Ctx) error {
 	// Get ID from URL parameters
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "user ID is required")
+		return ErrUserIDRequired
+	}
+
+	// Ownership rule: admins cannot lock themselves out
+	if claims, ok := handler.Claims(c); ok && claims.Subject == id {
+		return ErrDeleteSelf
+	}
+
+	// Permanent deletion is opt-in
+	hard, _ := strconv.ParseBool(c.Query("hard"))
+	if hard {
+		if err := h.service.HardDeleteUser(c.UserContext(), id); err != nil {
+			return err
+		}
+		return response.SuccessWithMessage(c, fiber.StatusOK, "User permanently deleted")
 	}
 
 	// Call service
-	if err := h.service.DeleteUser(id); err != nil {
-		return h.handleServiceError(c, err)
+	if err := h.service.DeleteUser(c.UserContext(), id); err != nil {
+		return err
 	}
 
 	return response.SuccessWithMessage(c, fiber.StatusOK, "User deleted successfully")
@@ -249,13 +307,34 @@ func (h *Handler) RestoreUser(c *fiber.Ctx) error {
 	// Get ID from URL parameters
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "user ID is required")
+		return ErrUserIDRequired
+	}
+
+	// Call service
+	user, err := h.service.RestoreUser(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	// Map to response DTO and return success
+	userResponse := h.userToResponse(user)
+	return response.OK(c, UserDataResponse{
+		User: userResponse,
+	})
+}
+
+// UnlockUser handles POST /users/:id/unlock - clear a sign-in lockout
+func (h *Handler) UnlockUser(c *fiber.Ctx) error {
+	// Get ID from URL parameters
+	id := c.Params("id")
+	if id == "" {
+		return ErrUserIDRequired
 	}
 
 	// Call service
-	user, err := h.service.RestoreUser(id)
+	user, err := h.service.UnlockUser(c.UserContext(), id)
 	if err != nil {
-		return h.handleServiceError(c, err)
+		return err
 	}
 
 	// Map to response DTO and return success
@@ -269,24 +348,24 @@ func (h *Handler) RestoreUser(c *fiber.Ctx) error {
 func (h *Handler) GetUserStats(c *fiber.Ctx) error {
 	// Get total users
 	totalQuery := ListUsersQuery{Page: 1, PerPage: 1}
-	_, total, err := h.service.GetUsers(totalQuery)
+	_, total, err := h.service.GetUsers(c.UserContext(), totalQuery)
 	if err != nil {
-		return h.handleServiceError(c, err)
+		return err
 	}
 
 	// Get verified users
 	verified := true
 	verifiedQuery := ListUsersQuery{Page: 1, PerPage: 1, Verified: &verified}
-	_, totalVerified, err := h.service.GetUsers(verifiedQuery)
+	_, totalVerified, err := h.service.GetUsers(c.UserContext(), verifiedQuery)
 	if err != nil {
-		return h.handleServiceError(c, err)
+		return err
 	}
 
 	// Get admin users
 	adminQuery := ListUsersQuery{Page: 1, PerPage: 1, Role: "admin"}
-	_, totalAdmins, err := h.service.GetUsers(adminQuery)
+	_, totalAdmins, err := h.service.GetUsers(c.UserContext(), adminQuery)
 	if err != nil {
-		return h.handleServiceError(c, err)
+		return err
 	}
 
 	stats := map[string]interface{}{
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -17,28 +18,34 @@ var AllowedSearchFields = map[string]bool{
 // Repository defines the interface for user data persistence
 type Repository interface {
 	// GetUsers retrieves users with filtering and pagination
-	GetUsers(query ListUsersQuery) ([]UserResponse, int64, error)
+	GetUsers(ctx context.Context, query ListUsersQuery) ([]UserResponse, int64, error)
 
 	// GetUserByID retrieves a user by their ID
-	GetUserByID(id string, includeDeleted bool) (*UserResponse, error)
+	GetUserByID(ctx context.Context, id string, includeDeleted bool) (*UserResponse, error)
 
 	// GetUserByEmail retrieves a user by their email address
-	GetUserByEmail(email string) (*UserResponse, error)
+	GetUserByEmail(ctx context.Context, email string) (*UserResponse, error)
 
 	// CreateUser creates a new user in the system
-	CreateUser(user *User) error
+	CreateUser(ctx context.Context, user *User) error
 
 	// UpdateUser updates an existing user
-	UpdateUser(id string, user *User) error
+	UpdateUser(ctx context.Context, id string, user *User) error
 
 	// DeleteUser soft deletes a user
-	DeleteUser(id string) error
+	DeleteUser(ctx context.Context, id string) error
 
 	// RestoreUser restores a soft deleted user
-	RestoreUser(id string) error
+	RestoreUser(ctx context.Context, id string) error
 
 	// HardDeleteUser permanently deletes a user
-	HardDeleteUser(id string) error
+	HardDeleteUser(ctx context.Context, id string) error
+
+	// GetPasswordHash retrieves the stored password hash of a user
+	GetPasswordHash(ctx context.Context, id string) (string, error)
+
+	// UpdatePassword replaces the stored password hash of a user
+	UpdatePassword(ctx context.Context, id string, passwordHash string) error
 }
 
 // userRepository implements Repository interface with GORM
@@ -52,7 +59,7 @@ func NewUserRepository(db *gorm.DB) Repository {
 }
 
 // GetUsers retrieves users with filtering and pagination
-func (r *userRepository) GetUsers(query ListUsersQuery) ([]UserResponse, int64, error) {
+func (r *userRepository) GetUsers(ctx context.Context, query ListUsersQuery) ([]UserResponse, int64, error) {
 	var models []UserResponse
 	var total int64
 
@@ -65,7 +72,7 @@ func (r *userRepository) GetUsers(query ListUsersQuery) ([]UserResponse, int64,
 	}
 
 	// Build base query
-	db := r.db.Model(&UserModel{})
+	db := r.db.WithContext(ctx).Model(&UserModel{})
 
 	// Include soft deleted records if requested
 	if query.ShowDeleted {
@@ -120,15 +127,15 @@ func (r *userRepository) GetUsers(query ListUsersQuery) ([]UserResponse, int64,
 }
 
 // GetUserByID retrieves a user by ID
-func (r *userRepository) GetUserByID(id string, includeDeleted bool) (*UserResponse, error) {
+func (r *userRepository) GetUserByID(ctx context.Context, id string, includeDeleted bool) (*UserResponse, error) {
 	var model UserResponse
 
-	db := r.db
+	db := r.db.WithContext(ctx)
 	if includeDeleted {
 		db = db.Unscoped()
 	}
 
-	result := db.Where("id = ?", id).First(&model)
+	result := db.Model(&UserModel{}).Where("id = ?", id).First(&model)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -137,9 +144,9 @@ func (r *userRepository) GetUserByID(id string, includeDeleted bool) (*UserRespo
 }
 
 // GetUserByEmail retrieves a user by email
-func (r *userRepository) GetUserByEmail(email string) (*UserResponse, error) {
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*UserResponse, error) {
 	var model UserResponse
-	result := r.db.Where("email = ?", email).First(&model)
+	result := r.db.WithContext(ctx).Model(&UserModel{}).Where("email = ?", email).First(&model)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -147,9 +154,9 @@ func (r *userRepository) GetUserByEmail(email string) (*UserResponse, error) {
 }
 
 // CreateUser creates a new user
-func (r *userRepository) CreateUser(user *User) error {
+func (r *userRepository) CreateUser(ctx context.Context, user *User) error {
 	model := toModel(user)
-	result := r.db.Create(&model)
+	result := r.db.WithContext(ctx).Create(&model)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -165,11 +172,15 @@ func (r *userRepository) CreateUser(user *User) error {
 }
 
 // UpdateUser updates an existing user
-func (r *userRepository) UpdateUser(id string, user *User) error {
+func (r *userRepository) UpdateUser(ctx context.Context, id string, user *User) error {
 	model := toModel(user)
 	model.UpdatedAt = time.Now()
 
-	result := r.db.Where("id = ?", id).Updates(&model)
+	// Select writes zero values too (e.g. verified=false)
+	result := r.db.WithContext(ctx).Model(&UserModel{}).
+		Where("id = ?", id).
+		Select("name", "email", "role", "photo", "verified", "updated_at").
+		Updates(model)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -183,8 +194,8 @@ func (r *userRepository) UpdateUser(id string, user *User) error {
 }
 
 // DeleteUser soft deletes a user
-func (r *userRepository) DeleteUser(id string) error {
-	result := r.db.Where("id = ?", id).Delete(&UserModel{})
+func (r *userRepository) DeleteUser(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&UserModel{})
 	if result.Error != nil {
 		return result.Error
 	}
@@ -197,8 +208,8 @@ func (r *userRepository) DeleteUser(id string) error {
 }
 
 // RestoreUser restores a soft deleted user
-func (r *userRepository) RestoreUser(id string) error {
-	result := r.db.Unscoped().Where("id = ?", id).Update("deleted_at", nil)
+func (r *userRepository) RestoreUser(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&UserModel{}).Where("id = ?", id).Update("deleted_at", nil)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -211,8 +222,37 @@ func (r *userRepository) RestoreUser(id string) error {
 }
 
 // HardDeleteUser permanently deletes a user
-func (r *userRepository) HardDeleteUser(id string) error {
-	result := r.db.Unscoped().Where("id = ?", id).Delete(&UserModel{})
+func (r *userRepository) HardDeleteUser(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Unscoped().Where("id = ?", id).Delete(&UserModel{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetPasswordHash retrieves the password hash of a user
+func (r *userRepository) GetPasswordHash(ctx context.Context, id string) (string, error) {
+	var model UserModel
+	result := r.db.WithContext(ctx).Select("password").Where("id = ?", id).First(&model)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	return model.Password, nil
+}
+
+// UpdatePassword replaces the password hash of a user
+func (r *userRepository) UpdatePassword(ctx context.Context, id string, passwordHash string) error {
+	result := r.db.WithContext(ctx).Model(&UserModel{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"password":   passwordHash,
+			"updated_at": time.Now(),
+		})
 	if result.Error != nil {
 		return result.Error
 	}
@@ -227,15 +267,16 @@ func (r *userRepository) HardDeleteUser(id string) error {
 // toDomain converts database model to domain model
 func toDomain(model *UserResponse) *UserResponse {
 	user := &UserResponse{
-		ID:        model.ID,
-		Name:      model.Name,
-		Email:     model.Email,
-		Role:      model.Role,
-		Provider:  model.Provider,
-		Photo:     model.Photo,
-		Verified:  model.Verified,
-		CreatedAt: model.CreatedAt,
-		UpdatedAt: model.UpdatedAt,
+		ID:         model.ID,
+		Name:       model.Name,
+		Email:      model.Email,
+		Role:       model.Role,
+		Provider:   model.Provider,
+		Photo:      model.Photo,
+		Verified:   model.Verified,
+		MFAEnabled: model.MFAEnabled,
+		CreatedAt:  model.CreatedAt,
+		UpdatedAt:  model.UpdatedAt,
 	}
 
 	if model.DeletedAt != nil {
@@ -257,7 +298,10 @@ func toModel(user *User) *UserModel {
 		Verified:  user.Verified,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
-		DeletedAt: user.DeletedAt,
+	}
+
+	if user.DeletedAt != nil {
+		model.DeletedAt = gorm.DeletedAt{Time: *user.DeletedAt, Valid: true}
 	}
 
 	// Set ID if it exists
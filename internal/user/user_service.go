@@ -1,10 +1,16 @@
 package user
 
 import (
+	"context"
 	"errors"
 	"math"
 	"time"
 
+	"github.com/golang-fiber-jwt/pkg/hashing"
+	"github.com/golang-fiber-jwt/pkg/lockout"
+	"github.com/golang-fiber-jwt/pkg/loglevel"
+	"github.com/golang-fiber-jwt/pkg/passwordpolicy"
+	"github.com/golang-fiber-jwt/pkg/requestid"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -12,39 +18,72 @@ import (
 // Service defines the interface for user business logic
 type Service interface {
 	// GetUsers retrieves users with filtering and pagination
-	GetUsers(query ListUsersQuery) ([]UserResponse, int64, error)
+	GetUsers(ctx context.Context, query ListUsersQuery) ([]UserResponse, int64, error)
 
 	// GetUserByID retrieves a user by their ID
-	GetUserByID(id string) (*UserResponse, error)
+	GetUserByID(ctx context.Context, id string) (*UserResponse, error)
 
 	// CreateUser creates a new user in the system
-	CreateUser(data *CreateUserData) error
+	CreateUser(ctx context.Context, data *CreateUserData) error
 
 	// UpdateUser updates an existing user
-	UpdateUser(id string, data *UpdateUserData) error
+	UpdateUser(ctx context.Context, id string, data *UpdateUserData) error
+
+	// UpdateProfile updates the self-service fields of a user's own profile
+	UpdateProfile(ctx context.Context, id string, data *UpdateProfileData) (*UserResponse, error)
+
+	// ChangePassword replaces a user's password after checking the current one
+	// and signs out every other session of the user
+	ChangePassword(ctx context.Context, id string, data *ChangePasswordData) error
 
 	// DeleteUser soft deletes a user
-	DeleteUser(id string) error
+	DeleteUser(ctx context.Context, id string) error
+
+	// HardDeleteUser permanently deletes a user
+	HardDeleteUser(ctx context.Context, id string) error
 
 	// RestoreUser restores a soft deleted user
-	RestoreUser(id string) (*UserResponse, error)
+	RestoreUser(ctx context.Context, id string) (*UserResponse, error)
+
+	// UnlockUser clears the failed sign-in attempts and lockout of a user
+	UnlockUser(ctx context.Context, id string) (*UserResponse, error)
 
 	// CalculatePagination calculates total pages for pagination
 	CalculatePagination(total int64, page, perPage int) int
 }
 
+// SessionRevoker signs users out of their sessions, implemented by the auth service
+type SessionRevoker interface {
+	// SignOutOtherSessions invalidates the user's access tokens and revokes every session but keepSessionID
+	SignOutOtherSessions(ctx context.Context, userID, keepSessionID string) error
+}
+
 // service implements Service interface with pure business logic
 type service struct {
-	repo Repository
+	repo     Repository
+	policy   passwordpolicy.Policy
+	hasher   hashing.Hasher
+	lockouts *lockout.Guard
+	sessions SessionRevoker
 }
 
 // NewUserService creates a new user service
-func NewUserService(repo Repository) Service {
-	return &service{repo: repo}
+// policy is enforced on passwords set through CreateUser and ChangePassword,
+// hasher hashes them (nil uses hashing.Default)
+// lockouts must be the guard used by sign-in for the lockout state to be reported,
+// sessions signs the user's other sessions out after a password change
+func NewUserService(repo Repository, policy passwordpolicy.Policy, hasher hashing.Hasher, lockouts *lockout.Guard, sessions SessionRevoker) Service {
+	if hasher == nil {
+		hasher = hashing.Default()
+	}
+	if lockouts == nil {
+		lockouts = lockout.NewGuard(lockout.NewMemoryStore(0), lockout.Config{})
+	}
+	return &service{repo: repo, policy: policy, hasher: hasher, lockouts: lockouts, sessions: sessions}
 }
 
 // GetUsers retrieves users with filtering and pagination
-func (s *service) GetUsers(query ListUsersQuery) ([]UserResponse, int64, error) {
+func (s *service) GetUsers(ctx context.Context, query ListUsersQuery) ([]UserResponse, int64, error) {
 	// Business rule: Default pagination values
 	if query.Page <= 0 {
 		query.Page = 1
@@ -53,53 +92,61 @@ func (s *service) GetUsers(query ListUsersQuery) ([]UserResponse, int64, error)
 		query.PerPage = 10
 	}
 
-	return s.repo.GetUsers(query)
+	users, total, err := s.repo.GetUsers(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page := make([]*UserResponse, len(users))
+	for i := range users {
+		page[i] = &users[i]
+	}
+	s.addLockoutState(ctx, page...)
+
+	return users, total, nil
 }
 
 // GetUserByID retrieves a user by their ID
-func (s *service) GetUserByID(id string) (*UserResponse, error) {
+func (s *service) GetUserByID(ctx context.Context, id string) (*UserResponse, error) {
 	// Validate UUID format
 	if _, err := uuid.Parse(id); err != nil {
-		return nil, errors.New("invalid user ID format")
+		return nil, ErrInvalidUserID
 	}
 
-	user, err := s.repo.GetUserByID(id, false)
+	user, err := s.repo.GetUserByID(ctx, id, false)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
 
+	s.addLockoutState(ctx, user)
 	return user, nil
 }
 
 // CreateUser creates a new user in the system
-func (s *service) CreateUser(data *CreateUserData) error {
+func (s *service) CreateUser(ctx context.Context, data *CreateUserData) error {
 	// Business rule validations
 	if data.Name == "" {
-		return errors.New("name is required")
+		return ErrNameRequired
 	}
 
 	if data.Email == "" {
-		return errors.New("email is required")
-	}
-
-	if data.Password == "" {
-		return errors.New("password is required")
+		return ErrEmailRequired
 	}
 
-	if len(data.Password) < 8 {
-		return errors.New("password must be at least 8 characters")
+	if err := s.policy.Validate(data.Password, data.Email, data.Name); err != nil {
+		return err
 	}
 
 	// Check if user already exists
-	existingUser, err := s.repo.GetUserByEmail(data.Email)
+	existingUser, err := s.repo.GetUserByEmail(ctx, data.Email)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return err
 	}
 	if existingUser != nil {
-		return errors.New("user with that email already exists")
+		return ErrUserExists
 	}
 
 	// Set default values
@@ -115,12 +162,17 @@ func (s *service) CreateUser(data *CreateUserData) error {
 		data.Photo = "default.png"
 	}
 
+	hashedPassword, err := s.hasher.Hash(data.Password)
+	if err != nil {
+		return err
+	}
+
 	// Create user entity
 	user := &User{
 		ID:        uuid.New(),
 		Name:      data.Name,
 		Email:     data.Email,
-		Password:  data.Password, // In real app, this should be hashed
+		Password:  hashedPassword,
 		Role:      data.Role,
 		Provider:  data.Provider,
 		Photo:     data.Photo,
@@ -130,7 +182,7 @@ func (s *service) CreateUser(data *CreateUserData) error {
 	}
 
 	// Save to repository
-	if err := s.repo.CreateUser(user); err != nil {
+	if err := s.repo.CreateUser(ctx, user); err != nil {
 		return err
 	}
 
@@ -138,38 +190,38 @@ func (s *service) CreateUser(data *CreateUserData) error {
 }
 
 // UpdateUser updates an existing user
-func (s *service) UpdateUser(id string, data *UpdateUserData) error {
+func (s *service) UpdateUser(ctx context.Context, id string, data *UpdateUserData) error {
 	// Validate UUID format
 	if _, err := uuid.Parse(id); err != nil {
-		return errors.New("invalid user ID format")
+		return ErrInvalidUserID
 	}
 
 	// Business rule validations
 	if data.Name == "" {
-		return errors.New("name is required")
+		return ErrNameRequired
 	}
 
 	if data.Email == "" {
-		return errors.New("email is required")
+		return ErrEmailRequired
 	}
 
 	// Check if user exists
-	existingUser, err := s.repo.GetUserByID(id, false)
+	existingUser, err := s.repo.GetUserByID(ctx, id, false)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("user not found")
+			return ErrUserNotFound
 		}
 		return err
 	}
 
 	// Check if email is already taken by another user
 	if data.Email != existingUser.Email {
-		emailUser, err := s.repo.GetUserByEmail(data.Email)
+		emailUser, err := s.repo.GetUserByEmail(ctx, data.Email)
 		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 			return err
 		}
 		if emailUser != nil && emailUser.ID != existingUser.ID {
-			return errors.New("email is already taken by another user")
+			return ErrEmailTaken
 		}
 	}
 
@@ -185,42 +237,188 @@ func (s *service) UpdateUser(id string, data *UpdateUserData) error {
 	// Update user entity
 	updatedUser := &User{
 		Name:      data.Name,
+		Email:     data.Email,
 		Role:      data.Role,
 		Photo:     data.Photo,
+		Verified:  data.Verified,
+		UpdatedAt: time.Now(),
+	}
+
+	// Save to repository
+	if err := s.repo.UpdateUser(ctx, id, updatedUser); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// UpdateProfile updates name and photo of a user's own profile
+// Role, verification status and email are deliberately not self-service
+func (s *service) UpdateProfile(ctx context.Context, id string, data *UpdateProfileData) (*UserResponse, error) {
+	// Validate UUID format
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	// Check if user exists
+	existingUser, err := s.repo.GetUserByID(ctx, id, false)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	// Only overwrite the fields that were provided
+	updatedUser := &User{
+		Name:      existingUser.Name,
+		Email:     existingUser.Email,
+		Role:      existingUser.Role,
+		Photo:     existingUser.Photo,
+		Verified:  existingUser.Verified,
 		UpdatedAt: time.Now(),
 	}
+	if data.Name != "" {
+		updatedUser.Name = data.Name
+	}
+	if data.Photo != "" {
+		updatedUser.Photo = data.Photo
+	}
 
 	// Save to repository
-	if err := s.repo.UpdateUser(id, updatedUser); err != nil {
+	if err := s.repo.UpdateUser(ctx, id, updatedUser); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return s.repo.GetUserByID(ctx, id, false)
+}
+
+// ChangePassword verifies the current password and stores the new one
+// Every access token is invalidated and every session but data.SessionID is revoked,
+// the caller keeps their session by refreshing its access token
+func (s *service) ChangePassword(ctx context.Context, id string, data *ChangePasswordData) error {
+	// Validate UUID format
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidUserID
+	}
+
+	existingUser, err := s.repo.GetUserByID(ctx, id, false)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	currentHash, err := s.repo.GetPasswordHash(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	// Throttled like SignIn so a stolen access token cannot be used to guess the password
+	if err := s.lockouts.Check(existingUser.Email, data.IP); err != nil {
+		return err
+	}
+
+	if err := s.hasher.Verify(currentHash, data.CurrentPassword); err != nil {
+		if err := s.lockouts.Fail(existingUser.Email, data.IP); err != nil {
+			requestid.Printf(ctx, loglevel.Warn, "user: %v", err)
+		}
+		return ErrCurrentPasswordIncorrect
+	}
+
+	// Best effort, the password was right
+	if err := s.lockouts.Succeed(existingUser.Email); err != nil {
+		requestid.Printf(ctx, loglevel.Warn, "user: %v", err)
+	}
+
+	if data.NewPassword != data.NewPasswordConfirm {
+		return ErrPasswordMismatch
+	}
+
+	if data.NewPassword == data.CurrentPassword {
+		return ErrPasswordUnchanged
+	}
+
+	if err := s.policy.Validate(data.NewPassword, existingUser.Email, existingUser.Name); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(data.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdatePassword(ctx, id, hashedPassword); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("user not found")
+			return ErrUserNotFound
 		}
 		return err
 	}
 
+	if err := s.sessions.SignOutOtherSessions(ctx, id, data.SessionID); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // DeleteUser soft deletes a user
-func (s *service) DeleteUser(id string) error {
+func (s *service) DeleteUser(ctx context.Context, id string) error {
 	// Validate UUID format
 	if _, err := uuid.Parse(id); err != nil {
-		return errors.New("invalid user ID format")
+		return ErrInvalidUserID
 	}
 
 	// Check if user exists
-	_, err := s.repo.GetUserByID(id, false)
+	_, err := s.repo.GetUserByID(ctx, id, false)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("user not found")
+			return ErrUserNotFound
 		}
 		return err
 	}
 
 	// Soft delete user
-	if err := s.repo.DeleteUser(id); err != nil {
+	if err := s.repo.DeleteUser(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// HardDeleteUser permanently deletes a user, including soft deleted ones
+func (s *service) HardDeleteUser(ctx context.Context, id string) error {
+	// Validate UUID format
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrInvalidUserID
+	}
+
+	// Check if user exists (including soft deleted)
+	_, err := s.repo.GetUserByID(ctx, id, true)
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("user not found")
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	// Permanently delete user
+	if err := s.repo.HardDeleteUser(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
 		}
 		return err
 	}
@@ -229,36 +427,36 @@ func (s *service) DeleteUser(id string) error {
 }
 
 // RestoreUser restores a soft deleted user
-func (s *service) RestoreUser(id string) (*UserResponse, error) {
+func (s *service) RestoreUser(ctx context.Context, id string) (*UserResponse, error) {
 	// Validate UUID format
 	if _, err := uuid.Parse(id); err != nil {
-		return nil, errors.New("invalid user ID format")
+		return nil, ErrInvalidUserID
 	}
 
 	// Check if user exists (including soft deleted)
-	user, err := s.repo.GetUserByID(id, true)
+	user, err := s.repo.GetUserByID(ctx, id, true)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
 
 	// Check if user is actually deleted
 	if user.DeletedAt == nil {
-		return nil, errors.New("user is not deleted")
+		return nil, ErrUserNotDeleted
 	}
 
 	// Restore user
-	if err := s.repo.RestoreUser(id); err != nil {
+	if err := s.repo.RestoreUser(ctx, id); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
 
 	// Return restored user
-	restoredUser, err := s.repo.GetUserByID(id, false)
+	restoredUser, err := s.repo.GetUserByID(ctx, id, false)
 	if err != nil {
 		return nil, err
 	}
@@ -266,6 +464,58 @@ func (s *service) RestoreUser(id string) (*UserResponse, error) {
 	return restoredUser, nil
 }
 
+// UnlockUser clears the failed sign-in attempts and lockout of a user
+func (s *service) UnlockUser(ctx context.Context, id string) (*UserResponse, error) {
+	// Validate UUID format
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	user, err := s.repo.GetUserByID(ctx, id, false)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.lockouts.Unlock(user.Email); err != nil {
+		return nil, err
+	}
+
+	// Nothing left to report
+	user.FailedLoginAttempts = 0
+	user.Locked = false
+	user.LockedUntil = nil
+	return user, nil
+}
+
+// addLockoutState fills in the sign-in lockout state of users
+// Best effort, the users are still returned when the lockout store is unavailable
+func (s *service) addLockoutState(ctx context.Context, users ...*UserResponse) {
+	if len(users) == 0 {
+		return
+	}
+
+	emails := make([]string, len(users))
+	for i, user := range users {
+		emails[i] = user.Email
+	}
+
+	statuses, err := s.lockouts.Status(emails...)
+	if err != nil {
+		requestid.Printf(ctx, loglevel.Warn, "user: %v", err)
+		return
+	}
+
+	for _, user := range users {
+		status := statuses[user.Email]
+		user.FailedLoginAttempts = status.Failures
+		user.Locked = status.Locked()
+		user.LockedUntil = status.LockedUntil
+	}
+}
+
 // CalculatePagination calculates pagination metadata
 func (s *service) CalculatePagination(total int64, page, perPage int) int {
 	if perPage <= 0 {
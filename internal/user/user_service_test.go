@@ -0,0 +1,345 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-fiber-jwt/pkg/hashing"
+	"github.com/golang-fiber-jwt/pkg/lockout"
+	"github.com/golang-fiber-jwt/pkg/passwordpolicy"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// MockRepository is a mock implementation of Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) GetUsers(ctx context.Context, query ListUsersQuery) ([]UserResponse, int64, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]UserResponse), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRepository) GetUserByID(ctx context.Context, id string, includeDeleted bool) (*UserResponse, error) {
+	args := m.Called(ctx, id, includeDeleted)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*UserResponse), args.Error(1)
+}
+
+func (m *MockRepository) GetUserByEmail(ctx context.Context, email string) (*UserResponse, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*UserResponse), args.Error(1)
+}
+
+func (m *MockRepository) CreateUser(ctx context.Context, user *User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateUser(ctx context.Context, id string, user *User) error {
+	args := m.Called(ctx, id, user)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteUser(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RestoreUser(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) HardDeleteUser(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPasswordHash(ctx context.Context, id string) (string, error) {
+	args := m.Called(ctx, id)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockRepository) UpdatePassword(ctx context.Context, id string, passwordHash string) error {
+	args := m.Called(ctx, id, passwordHash)
+	return args.Error(0)
+}
+
+// MockSessionRevoker is a mock implementation of SessionRevoker
+type MockSessionRevoker struct {
+	mock.Mock
+}
+
+func (m *MockSessionRevoker) SignOutOtherSessions(ctx context.Context, userID, keepSessionID string) error {
+	args := m.Called(ctx, userID, keepSessionID)
+	return args.Error(0)
+}
+
+// Test UpdateProfile Service - Role and verified are preserved
+func TestService_UpdateProfile_KeepsPrivilegedFields(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewUserService(mockRepo, passwordpolicy.Default(), hashing.Default(), nil, nil)
+
+	id := uuid.New()
+	existing := &UserResponse{
+		ID:       id,
+		Name:     "John Doe",
+		Email:    "john@example.com",
+		Role:     "user",
+		Photo:    "default.png",
+		Verified: false,
+	}
+
+	mockRepo.On("GetUserByID", mock.Anything, id.String(), false).Return(existing, nil)
+	mockRepo.On("UpdateUser", mock.Anything, id.String(), mock.MatchedBy(func(u *User) bool {
+		return u.Name == "Johnny" && u.Role == "user" && !u.Verified && u.Email == "john@example.com" && u.Photo == "default.png"
+	})).Return(nil)
+
+	user, err := service.UpdateProfile(context.Background(), id.String(), &UpdateProfileData{Name: "Johnny"})
+
+	assert.NoError(t, err)
+	assert.NotNil(t, user)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test UpdateProfile Service - User not found
+func TestService_UpdateProfile_NotFound(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewUserService(mockRepo, passwordpolicy.Default(), hashing.Default(), nil, nil)
+
+	id := uuid.New().String()
+	mockRepo.On("GetUserByID", mock.Anything, id, false).Return(nil, gorm.ErrRecordNotFound)
+
+	user, err := service.UpdateProfile(context.Background(), id, &UpdateProfileData{Name: "Johnny"})
+
+	assert.Error(t, err)
+	assert.Nil(t, user)
+	assert.Equal(t, "user not found", err.Error())
+	mockRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test GetUserByID Service - Reports the sign-in lockout state
+func TestService_GetUserByID_LockoutState(t *testing.T) {
+	mockRepo := new(MockRepository)
+	guard := lockout.NewGuard(lockout.NewMemoryStore(0), lockout.Config{MaxAttempts: 1})
+	service := NewUserService(mockRepo, passwordpolicy.Default(), hashing.Default(), guard, nil)
+
+	id := uuid.New().String()
+	mockRepo.On("GetUserByID", mock.Anything, id, false).Return(&UserResponse{Email: "john@example.com"}, nil)
+	assert.NoError(t, guard.Fail("john@example.com", ""))
+
+	user, err := service.GetUserByID(context.Background(), id)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, user.FailedLoginAttempts)
+	assert.True(t, user.Locked)
+	assert.NotNil(t, user.LockedUntil)
+}
+
+// Test UnlockUser Service - Clears the lockout
+func TestService_UnlockUser_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	guard := lockout.NewGuard(lockout.NewMemoryStore(0), lockout.Config{MaxAttempts: 1})
+	service := NewUserService(mockRepo, passwordpolicy.Default(), hashing.Default(), guard, nil)
+
+	id := uuid.New().String()
+	mockRepo.On("GetUserByID", mock.Anything, id, false).Return(&UserResponse{Email: "john@example.com"}, nil)
+	assert.NoError(t, guard.Fail("john@example.com", ""))
+
+	user, err := service.UnlockUser(context.Background(), id)
+
+	assert.NoError(t, err)
+	assert.False(t, user.Locked)
+	assert.NoError(t, guard.Check("john@example.com", ""))
+	mockRepo.AssertExpectations(t)
+}
+
+// Test HardDeleteUser Service - Also removes soft deleted users
+func TestService_HardDeleteUser_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewUserService(mockRepo, passwordpolicy.Default(), hashing.Default(), nil, nil)
+
+	id := uuid.New().String()
+	mockRepo.On("GetUserByID", mock.Anything, id, true).Return(&UserResponse{}, nil)
+	mockRepo.On("HardDeleteUser", mock.Anything, id).Return(nil)
+
+	err := service.HardDeleteUser(context.Background(), id)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// Test HardDeleteUser Service - Invalid ID
+func TestService_HardDeleteUser_InvalidID(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewUserService(mockRepo, passwordpolicy.Default(), hashing.Default(), nil, nil)
+
+	err := service.HardDeleteUser(context.Background(), "not-a-uuid")
+
+	assert.Error(t, err)
+	assert.Equal(t, "invalid user ID format", err.Error())
+}
+
+// Test ChangePassword Service - Success
+func TestService_ChangePassword_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockSessions := new(MockSessionRevoker)
+	service := NewUserService(mockRepo, passwordpolicy.Default(), hashing.Default(), nil, mockSessions)
+
+	id := uuid.New()
+	currentHash, _ := hashing.HashPassword("oldpassword123")
+	mockRepo.On("GetUserByID", mock.Anything, id.String(), false).Return(&UserResponse{ID: id, Name: "John", Email: "john@example.com"}, nil)
+	mockRepo.On("GetPasswordHash", mock.Anything, id.String()).Return(currentHash, nil)
+
+	var newHash string
+	mockRepo.On("UpdatePassword", mock.Anything, id.String(), mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { newHash = args.String(2) }).
+		Return(nil)
+
+	// The other sessions are signed out, the caller's one is kept
+	sessionID := uuid.NewString()
+	mockSessions.On("SignOutOtherSessions", mock.Anything, id.String(), sessionID).Return(nil)
+
+	err := service.ChangePassword(context.Background(), id.String(), &ChangePasswordData{
+		CurrentPassword:    "oldpassword123",
+		NewPassword:        "newpassword123",
+		NewPasswordConfirm: "newpassword123",
+		SessionID:          sessionID,
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, hashing.VerifyPassword(newHash, "newpassword123"))
+	mockRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+}
+
+// Test ChangePassword Service - Wrong current passwords lock the account out like failed sign-ins
+func TestService_ChangePassword_LockedOut(t *testing.T) {
+	mockRepo := new(MockRepository)
+	guard := lockout.NewGuard(lockout.NewMemoryStore(0), lockout.Config{MaxAttempts: 2, BaseDelay: time.Nanosecond, LockoutDuration: time.Hour})
+	service := NewUserService(mockRepo, passwordpolicy.Default(), hashing.Default(), guard, nil)
+
+	id := uuid.New()
+	currentHash, _ := hashing.HashPassword("oldpassword123")
+	mockRepo.On("GetUserByID", mock.Anything, id.String(), false).Return(&UserResponse{ID: id, Name: "John", Email: "john@example.com"}, nil)
+	mockRepo.On("GetPasswordHash", mock.Anything, id.String()).Return(currentHash, nil)
+
+	for i := 0; i < 2; i++ {
+		err := service.ChangePassword(context.Background(), id.String(), &ChangePasswordData{
+			CurrentPassword: "wrong", NewPassword: "newpassword123", NewPasswordConfirm: "newpassword123", IP: "203.0.113.7",
+		})
+		assert.Equal(t, "current password is incorrect", err.Error())
+		time.Sleep(time.Millisecond) // Past the backoff
+	}
+
+	// Even the right password is rejected while locked
+	err := service.ChangePassword(context.Background(), id.String(), &ChangePasswordData{
+		CurrentPassword: "oldpassword123", NewPassword: "newpassword123", NewPasswordConfirm: "newpassword123", IP: "203.0.113.7",
+	})
+
+	var locked *lockout.LockedError
+	assert.True(t, errors.As(err, &locked))
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Test ChangePassword Service - Rejections
+func TestService_ChangePassword_Rejected(t *testing.T) {
+	id := uuid.New()
+	currentHash, _ := hashing.HashPassword("oldpassword123")
+
+	tests := []struct {
+		name          string
+		policy        passwordpolicy.Policy
+		data          *ChangePasswordData
+		expectedError string
+	}{
+		{
+			name:          "Wrong Current Password",
+			data:          &ChangePasswordData{CurrentPassword: "wrong", NewPassword: "newpassword123", NewPasswordConfirm: "newpassword123"},
+			expectedError: "current password is incorrect",
+		},
+		{
+			name:          "Mismatch",
+			data:          &ChangePasswordData{CurrentPassword: "oldpassword123", NewPassword: "newpassword123", NewPasswordConfirm: "other"},
+			expectedError: "passwords do not match",
+		},
+		{
+			name:          "Unchanged",
+			data:          &ChangePasswordData{CurrentPassword: "oldpassword123", NewPassword: "oldpassword123", NewPasswordConfirm: "oldpassword123"},
+			expectedError: "new password must be different from the current password",
+		},
+		{
+			name:          "Equals Email",
+			data:          &ChangePasswordData{CurrentPassword: "oldpassword123", NewPassword: "john@example.com", NewPasswordConfirm: "john@example.com"},
+			expectedError: "password must not match your email or name",
+		},
+		{
+			name:          "Policy Character Classes",
+			policy:        passwordpolicy.Policy{RequireUpper: true},
+			data:          &ChangePasswordData{CurrentPassword: "oldpassword123", NewPassword: "newpassword123", NewPasswordConfirm: "newpassword123"},
+			expectedError: "password must contain an uppercase letter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			service := NewUserService(mockRepo, tt.policy, hashing.Default(), nil, nil)
+
+			mockRepo.On("GetUserByID", mock.Anything, id.String(), false).Return(&UserResponse{ID: id, Name: "John", Email: "john@example.com"}, nil)
+			mockRepo.On("GetPasswordHash", mock.Anything, id.String()).Return(currentHash, nil)
+
+			err := service.ChangePassword(context.Background(), id.String(), tt.data)
+
+			assert.Error(t, err)
+			assert.Equal(t, tt.expectedError, err.Error())
+			mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// Test CreateUser Service - Password policy is enforced
+func TestService_CreateUser_PolicyViolation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewUserService(mockRepo, passwordpolicy.Policy{RequireSymbol: true}, hashing.Default(), nil, nil)
+
+	err := service.CreateUser(context.Background(), &CreateUserData{Name: "John", Email: "john@example.com", Password: "password123"})
+
+	assert.Error(t, err)
+	assert.Equal(t, "password must contain a special character", err.Error())
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
+// Test CreateUser Service - Password is stored hashed
+func TestService_CreateUser_HashesPassword(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewUserService(mockRepo, passwordpolicy.Default(), hashing.Default(), nil, nil)
+
+	mockRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(nil, gorm.ErrRecordNotFound)
+
+	var created *User
+	mockRepo.On("CreateUser", mock.Anything, mock.AnythingOfType("*user.User")).
+		Run(func(args mock.Arguments) { created = args.Get(1).(*User) }).
+		Return(nil)
+
+	err := service.CreateUser(context.Background(), &CreateUserData{Name: "John", Email: "john@example.com", Password: "password123"})
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, "password123", created.Password)
+	assert.NoError(t, hashing.VerifyPassword(created.Password, "password123"))
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,123 @@
+package apperror
+
+import (
+	"errors"
+	"time"
+)
+
+// Kind classifies an error, the HTTP layer maps each kind to a status code
+type Kind int
+
+// Error kinds
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindTooManyRequests
+)
+
+// Error is a domain error with a kind and a stable machine-readable code
+// Its message is safe to show to the client
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	// RetryAfter tells the client when to try again (KindTooManyRequests)
+	RetryAfter time.Duration
+	// Details is extra data for the client, e.g. the failed fields of a validation error
+	Details interface{}
+	// Err is the underlying cause, never shown to the client
+	Err error
+}
+
+// New creates an error of the given kind
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// Validation creates an error for invalid input
+func Validation(code, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+// Unauthorized creates an error for missing or invalid credentials
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+// Forbidden creates an error for an authenticated caller that may not perform the action
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+// NotFound creates an error for a missing resource
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+// Conflict creates an error for a request clashing with the current state
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+// TooManyRequests creates an error for a throttled caller
+func TooManyRequests(code, message string, retryAfter time.Duration) *Error {
+	e := New(KindTooManyRequests, code, message)
+	e.RetryAfter = retryAfter
+	return e
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an *Error with the same code, so errors.Is matches
+// sentinel errors even when they were wrapped with a cause
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of e with err as its cause
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// Coder is implemented by domain errors of their own type that map to an *Error
+// (e.g. passwordpolicy.Violation, which carries the broken rule)
+type Coder interface {
+	AppError() *Error
+}
+
+// As finds the first *Error or Coder in err's chain
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+
+	var coder Coder
+	if errors.As(err, &coder) {
+		return coder.AppError(), true
+	}
+	return nil, false
+}
+
+// KindOf returns the kind of err, KindInternal for untyped errors
+func KindOf(err error) Kind {
+	if appErr, ok := As(err); ok {
+		return appErr.Kind
+	}
+	return KindInternal
+}
@@ -0,0 +1,46 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type lockedError struct{}
+
+func (lockedError) Error() string { return "locked" }
+
+func (lockedError) AppError() *Error {
+	return TooManyRequests("locked", "locked", time.Minute)
+}
+
+// Test Is - Sentinels match by code, also once wrapped with a cause
+func TestError_Is(t *testing.T) {
+	notFound := NotFound("user_not_found", "user not found")
+	cause := errors.New("record not found")
+
+	wrapped := fmt.Errorf("loading user: %w", notFound.Wrap(cause))
+	assert.True(t, errors.Is(wrapped, notFound))
+	assert.True(t, errors.Is(wrapped, cause))
+	assert.False(t, errors.Is(wrapped, Conflict("user_exists", "user with that email already exists")))
+	assert.Nil(t, notFound.Err, "Wrap must not modify the sentinel")
+}
+
+// Test As - Finds *Error and Coder values in the chain
+func TestAs(t *testing.T) {
+	appErr, ok := As(fmt.Errorf("context: %w", Forbidden("denied", "denied")))
+	assert.True(t, ok)
+	assert.Equal(t, KindForbidden, appErr.Kind)
+
+	appErr, ok = As(fmt.Errorf("context: %w", lockedError{}))
+	assert.True(t, ok)
+	assert.Equal(t, KindTooManyRequests, appErr.Kind)
+	assert.Equal(t, time.Minute, appErr.RetryAfter)
+
+	_, ok = As(errors.New("boom"))
+	assert.False(t, ok)
+	assert.Equal(t, KindInternal, KindOf(errors.New("boom")))
+}
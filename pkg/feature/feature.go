@@ -0,0 +1,36 @@
+package feature
+
+import "sync/atomic"
+
+// Features that can be switched off while serving
+const (
+	Signup     = "signup"
+	OAuthLogin = "oauth_login"
+	APIKeys    = "api_keys"
+)
+
+// Toggles are the on/off state of the features, replaced as a whole on configuration reloads
+type Toggles struct {
+	enabled atomic.Pointer[map[string]bool]
+}
+
+// NewToggles creates toggles with the given state, features missing from enabled are off
+func NewToggles(enabled map[string]bool) *Toggles {
+	t := &Toggles{}
+	t.Set(enabled)
+	return t
+}
+
+// Enabled reports whether a feature is on
+func (t *Toggles) Enabled(feature string) bool {
+	return (*t.enabled.Load())[feature]
+}
+
+// Set replaces the state of every feature
+func (t *Toggles) Set(enabled map[string]bool) {
+	copied := make(map[string]bool, len(enabled))
+	for feature, on := range enabled {
+		copied[feature] = on
+	}
+	t.enabled.Store(&copied)
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-fiber-jwt/pkg/jwt"
+)
+
+// claimsKey is the c.Locals key of the authenticated caller's token claims
+const claimsKey = "claims"
+
+// SetClaims stores the validated token claims for the rest of the request
+func SetClaims(c *fiber.Ctx, claims *jwt.Claims) {
+	c.Locals(claimsKey, claims)
+}
+
+// Claims returns the claims stored by the auth middleware
+// ok is false when the request is not authenticated
+func Claims(c *fiber.Ctx) (claims *jwt.Claims, ok bool) {
+	claims, ok = c.Locals(claimsKey).(*jwt.Claims)
+	return claims, ok && claims != nil && claims.Subject != ""
+}
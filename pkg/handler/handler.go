@@ -1,47 +1,59 @@
 package handler
 
 import (
+	"fmt"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-fiber-jwt/pkg/apperror"
 	"github.com/golang-fiber-jwt/pkg/mapper"
-	"github.com/golang-fiber-jwt/pkg/response"
 	"github.com/golang-fiber-jwt/pkg/validator"
 )
 
-// ParseAndValidate parses request body and validates it
-func ParseAndValidate(c *fiber.Ctx, req interface{}) error {
+// ErrUnauthenticated is returned by handlers that need claims the auth middleware did not set
+var ErrUnauthenticated = apperror.Unauthorized("unauthorized", "Unauthorized")
+
+// ParseBody parses the request body into req
+// Malformed bodies are returned as a validation error
+func ParseBody(c *fiber.Ctx, req interface{}) error {
 	if err := c.BodyParser(req); err != nil {
-		return response.BadRequest(c, err.Error())
+		return apperror.Validation("invalid_body", err.Error()).Wrap(err)
 	}
+	return nil
+}
 
+// Validate validates req, failures are returned as a validation error listing the fields
+func Validate(req interface{}) error {
 	if errors := validator.ValidateStruct(req); errors != nil {
-		return response.ValidationError(c, errors)
+		err := apperror.Validation("validation_failed", "Validation failed")
+		err.Details = errors
+		return err
 	}
 	return nil
 }
 
+// ParseAndValidate parses request body and validates it
+// The returned error is rendered by the app's error handler
+func ParseAndValidate(c *fiber.Ctx, req interface{}) error {
+	if err := ParseBody(c, req); err != nil {
+		return err
+	}
+	return Validate(req)
+}
+
 // ParseValidateAndMap parses, validates, and auto-maps request to domain struct
 // Returns the mapped domain struct and error
 // Usage: data, err := handler.ParseValidateAndMap[RequestDTO, DomainStruct](c)
 func ParseValidateAndMap[TReq any, TDomain any](c *fiber.Ctx) (*TDomain, error) {
 	var req TReq
 
-	// Parse request body
-	if err := c.BodyParser(&req); err != nil {
-		response.BadRequest(c, err.Error())
+	if err := ParseAndValidate(c, &req); err != nil {
 		return nil, err
 	}
 
-	// Validate request
-	if errors := validator.ValidateStruct(req); errors != nil {
-		response.ValidationError(c, errors)
-		return nil, fiber.ErrBadRequest
-	}
-
 	// Auto-map to domain struct
 	domain, err := mapper.AutoMap[TDomain](&req)
 	if err != nil {
-		response.InternalError(c, "Failed to process request")
-		return nil, err
+		return nil, fmt.Errorf("failed to process request: %w", err)
 	}
 
 	return domain, nil
@@ -0,0 +1,275 @@
+package hashing
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Supported password hashing algorithms
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// Argon2id defaults (64 MiB, 3 passes, 2 lanes)
+const (
+	DefaultArgon2Memory      uint32 = 64 * 1024
+	DefaultArgon2Iterations  uint32 = 3
+	DefaultArgon2Parallelism uint8  = 2
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var (
+	// ErrMismatch is returned when a password does not match its hash
+	ErrMismatch = errors.New("hashing: password does not match")
+
+	// ErrUnknownFormat is returned for hashes no configured algorithm produced
+	ErrUnknownFormat = errors.New("hashing: unrecognized hash format")
+)
+
+// Hasher hashes and verifies passwords
+// Hashes are self-describing: algorithm and parameters are encoded in the string
+type Hasher interface {
+	// Hash returns the encoded hash of password
+	Hash(password string) (string, error)
+
+	// Verify returns nil when password matches encoded
+	Verify(encoded, password string) error
+
+	// NeedsRehash reports whether encoded was made with outdated parameters
+	NeedsRehash(encoded string) bool
+
+	// Recognizes reports whether encoded was produced by this hasher's algorithm
+	Recognizes(encoded string) bool
+}
+
+// Config selects the algorithm and its parameters for new hashes
+type Config struct {
+	Algorithm         string
+	BcryptCost        int
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+}
+
+// New builds a hasher that hashes with the configured algorithm and still
+// verifies hashes made by the other supported algorithms, so the algorithm
+// or its cost can change without invalidating stored passwords
+func New(cfg Config) (Hasher, error) {
+	bcryptHasher := &BcryptHasher{Cost: cfg.BcryptCost}
+	if bcryptHasher.Cost == 0 {
+		bcryptHasher.Cost = bcrypt.DefaultCost
+	}
+	if bcryptHasher.Cost < bcrypt.MinCost || bcryptHasher.Cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("hashing: bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	argon2Hasher := &Argon2idHasher{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+	}
+	if argon2Hasher.Memory == 0 {
+		argon2Hasher.Memory = DefaultArgon2Memory
+	}
+	if argon2Hasher.Iterations == 0 {
+		argon2Hasher.Iterations = DefaultArgon2Iterations
+	}
+	if argon2Hasher.Parallelism == 0 {
+		argon2Hasher.Parallelism = DefaultArgon2Parallelism
+	}
+
+	switch strings.ToLower(cfg.Algorithm) {
+	case "", AlgorithmBcrypt:
+		return NewChain(bcryptHasher, argon2Hasher), nil
+	case AlgorithmArgon2id:
+		return NewChain(argon2Hasher, bcryptHasher), nil
+	default:
+		return nil, fmt.Errorf("hashing: unsupported algorithm %q", cfg.Algorithm)
+	}
+}
+
+// Default returns the hasher used when nothing is configured (bcrypt, default cost)
+func Default() Hasher {
+	hasher, _ := New(Config{})
+	return hasher
+}
+
+// Chain hashes with a preferred hasher and verifies with any hasher that recognizes the hash
+type Chain struct {
+	preferred Hasher
+	fallbacks []Hasher
+}
+
+// NewChain creates a chain hashing with preferred and also accepting fallbacks
+func NewChain(preferred Hasher, fallbacks ...Hasher) *Chain {
+	return &Chain{preferred: preferred, fallbacks: fallbacks}
+}
+
+// Hash hashes password with the preferred hasher
+func (c *Chain) Hash(password string) (string, error) {
+	return c.preferred.Hash(password)
+}
+
+// Verify dispatches to the hasher that produced encoded
+func (c *Chain) Verify(encoded, password string) error {
+	for _, hasher := range append([]Hasher{c.preferred}, c.fallbacks...) {
+		if hasher.Recognizes(encoded) {
+			return hasher.Verify(encoded, password)
+		}
+	}
+	return ErrUnknownFormat
+}
+
+// NeedsRehash is true for hashes made by another algorithm or with outdated parameters
+func (c *Chain) NeedsRehash(encoded string) bool {
+	if !c.preferred.Recognizes(encoded) {
+		return true
+	}
+	return c.preferred.NeedsRehash(encoded)
+}
+
+// Recognizes reports whether any hasher in the chain recognizes encoded
+func (c *Chain) Recognizes(encoded string) bool {
+	for _, hasher := range append([]Hasher{c.preferred}, c.fallbacks...) {
+		if hasher.Recognizes(encoded) {
+			return true
+		}
+	}
+	return false
+}
+
+// BcryptHasher hashes passwords with bcrypt ($2a$<cost>$...)
+type BcryptHasher struct {
+	Cost int
+}
+
+// Hash returns the bcrypt hash of password
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedBytes), nil
+}
+
+// Verify compares a bcrypt hash with a plain password
+func (h *BcryptHasher) Verify(encoded, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatch
+	}
+	return err
+}
+
+// NeedsRehash is true when encoded was made with a different cost
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.Cost
+}
+
+// Recognizes matches the bcrypt $2a$, $2b$ and $2y$ prefixes
+func (h *BcryptHasher) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// Argon2idHasher hashes passwords with argon2id in PHC string format
+// ($argon2id$v=19$m=<KiB>,t=<iterations>,p=<parallelism>$<salt>$<key>)
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// argon2Hash is a decoded argon2id hash
+type argon2Hash struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+// Hash returns the argon2id hash of password with a random salt
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify recomputes the key with the parameters stored in encoded
+func (h *Argon2idHasher) Verify(encoded, password string) error {
+	decoded, err := decodeArgon2(encoded)
+	if err != nil {
+		return err
+	}
+
+	key := argon2.IDKey([]byte(password), decoded.salt, decoded.iterations, decoded.memory, decoded.parallelism, uint32(len(decoded.key)))
+	if subtle.ConstantTimeCompare(key, decoded.key) != 1 {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// NeedsRehash is true when encoded was made with different parameters
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	decoded, err := decodeArgon2(encoded)
+	if err != nil {
+		return true
+	}
+	return decoded.memory != h.Memory ||
+		decoded.iterations != h.Iterations ||
+		decoded.parallelism != h.Parallelism ||
+		len(decoded.key) != argon2KeyLength
+}
+
+// Recognizes matches the $argon2id$ prefix
+func (h *Argon2idHasher) Recognizes(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+// decodeArgon2 parses a PHC formatted argon2id hash
+func decodeArgon2(encoded string) (*argon2Hash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return nil, ErrUnknownFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, ErrUnknownFormat
+	}
+
+	decoded := &argon2Hash{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &decoded.memory, &decoded.iterations, &decoded.parallelism); err != nil {
+		return nil, ErrUnknownFormat
+	}
+
+	var err error
+	if decoded.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, ErrUnknownFormat
+	}
+	if decoded.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(decoded.key) == 0 {
+		return nil, ErrUnknownFormat
+	}
+
+	return decoded, nil
+}
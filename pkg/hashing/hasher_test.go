@@ -0,0 +1,94 @@
+package hashing
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2 keeps argon2id cheap enough for unit tests
+var testArgon2 = Config{Algorithm: AlgorithmArgon2id, Argon2Memory: 1024, Argon2Iterations: 1, Argon2Parallelism: 1}
+
+// Test Hasher - Hash and verify round trip for every algorithm
+func TestHasher_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    Config
+		prefix string
+	}{
+		{name: "Bcrypt", cfg: Config{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost}, prefix: "$2a$04$"},
+		{name: "Argon2id", cfg: testArgon2, prefix: "$argon2id$v=19$m=1024,t=1,p=1$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher, err := New(tt.cfg)
+			assert.NoError(t, err)
+
+			encoded, err := hasher.Hash("password123")
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(encoded, tt.prefix))
+
+			assert.NoError(t, hasher.Verify(encoded, "password123"))
+			assert.ErrorIs(t, hasher.Verify(encoded, "wrong-password"), ErrMismatch)
+			assert.False(t, hasher.NeedsRehash(encoded))
+		})
+	}
+}
+
+// Test Hasher - Hashes from the other algorithm verify and are flagged for rehash
+func TestHasher_AlgorithmMigration(t *testing.T) {
+	bcryptHasher, err := New(Config{BcryptCost: bcrypt.MinCost})
+	assert.NoError(t, err)
+	argon2Hasher, err := New(testArgon2)
+	assert.NoError(t, err)
+
+	legacy, err := bcryptHasher.Hash("password123")
+	assert.NoError(t, err)
+
+	assert.NoError(t, argon2Hasher.Verify(legacy, "password123"))
+	assert.True(t, argon2Hasher.NeedsRehash(legacy))
+
+	current, err := argon2Hasher.Hash("password123")
+	assert.NoError(t, err)
+	assert.NoError(t, bcryptHasher.Verify(current, "password123"))
+	assert.True(t, bcryptHasher.NeedsRehash(current))
+}
+
+// Test Hasher - Raising the cost flags older hashes for rehash
+func TestHasher_CostUpgrade(t *testing.T) {
+	oldHasher, err := New(Config{BcryptCost: bcrypt.MinCost})
+	assert.NoError(t, err)
+	newHasher, err := New(Config{BcryptCost: bcrypt.MinCost + 1})
+	assert.NoError(t, err)
+
+	encoded, err := oldHasher.Hash("password123")
+	assert.NoError(t, err)
+	assert.True(t, newHasher.NeedsRehash(encoded))
+
+	stronger := testArgon2
+	stronger.Argon2Iterations = 2
+	oldArgon2, err := New(testArgon2)
+	assert.NoError(t, err)
+	newArgon2, err := New(stronger)
+	assert.NoError(t, err)
+
+	encoded, err = oldArgon2.Hash("password123")
+	assert.NoError(t, err)
+	assert.True(t, newArgon2.NeedsRehash(encoded))
+	assert.NoError(t, newArgon2.Verify(encoded, "password123"))
+}
+
+// Test Hasher - Unknown formats and invalid configuration are rejected
+func TestHasher_Invalid(t *testing.T) {
+	hasher := Default()
+	assert.ErrorIs(t, hasher.Verify("password123", "password123"), ErrUnknownFormat)
+	assert.ErrorIs(t, hasher.Verify("$argon2id$v=19$garbage", "password123"), ErrUnknownFormat)
+
+	_, err := New(Config{Algorithm: "md5"})
+	assert.Error(t, err)
+	_, err = New(Config{BcryptCost: 99})
+	assert.Error(t, err)
+}
@@ -2,23 +2,23 @@ package hashing
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
-
-	"golang.org/x/crypto/bcrypt"
+	"encoding/hex"
 )
 
-// HashPassword hashes a password using bcrypt
+// defaultHasher backs the package level password helpers
+var defaultHasher = Default()
+
+// HashPassword hashes a password with the default hasher (bcrypt, default cost)
 func HashPassword(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(hashedBytes), nil
+	return defaultHasher.Hash(password)
 }
 
 // VerifyPassword compares a hashed password with a plain password
+// Any supported algorithm is accepted since hashes are self-describing
 func VerifyPassword(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	return defaultHasher.Verify(hashedPassword, password)
 }
 
 // GenerateToken generates a random token
@@ -29,3 +29,10 @@ func GenerateToken() (string, error) {
 	}
 	return base64.URLEncoding.EncodeToString(b), nil
 }
+
+// HashToken returns the hex-encoded SHA-256 digest of an opaque token
+// Use it for high-entropy tokens that are looked up by hash (not for passwords)
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
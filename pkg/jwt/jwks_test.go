@@ -0,0 +1,53 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test JWKS - Public parameters for each key type, retired keys listed during grace
+func TestKeySet_JWKS(t *testing.T) {
+	rsaKey, _ := GenerateKey(RS256)
+	ecKey, _ := GenerateKey(ES256)
+	edKey, _ := GenerateKey(EdDSA)
+	retiredAt := time.Now()
+	rsaKey.RetiredAt = &retiredAt
+	ecKey.RetiredAt = &retiredAt
+
+	set := NewStaticKeySet(time.Hour, rsaKey, ecKey, edKey).JWKS()
+	assert.Len(t, set.Keys, 3)
+
+	rsaJWK := set.Keys[0]
+	assert.Equal(t, "RSA", rsaJWK.KeyType)
+	assert.Equal(t, rsaKey.ID, rsaJWK.KeyID)
+	assert.Equal(t, "RS256", rsaJWK.Algorithm)
+	assert.Equal(t, "sig", rsaJWK.Use)
+	n, _ := base64.RawURLEncoding.DecodeString(rsaJWK.N)
+	assert.Equal(t, 0, new(big.Int).SetBytes(n).Cmp(rsaKey.Public().(*rsa.PublicKey).N))
+	assert.Equal(t, "AQAB", rsaJWK.E)
+
+	ecJWK := set.Keys[1]
+	assert.Equal(t, "EC", ecJWK.KeyType)
+	assert.Equal(t, "P-256", ecJWK.Curve)
+	x, _ := base64.RawURLEncoding.DecodeString(ecJWK.X)
+	assert.Len(t, x, 32)
+	assert.Equal(t, 0, new(big.Int).SetBytes(x).Cmp(ecKey.Public().(*ecdsa.PublicKey).X))
+
+	edJWK := set.Keys[2]
+	assert.Equal(t, "OKP", edJWK.KeyType)
+	assert.Equal(t, "Ed25519", edJWK.Curve)
+	assert.Equal(t, "EdDSA", edJWK.Algorithm)
+	assert.Empty(t, edJWK.N)
+
+	// Keys past their grace period are no longer published
+	expired := time.Now().Add(-2 * time.Hour)
+	rsaKey.RetiredAt = &expired
+	set = NewStaticKeySet(time.Hour, rsaKey, ecKey, edKey).JWKS()
+	assert.Len(t, set.Keys, 2)
+}
@@ -0,0 +1,230 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt"
+)
+
+// Supported signing algorithms
+const (
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+// rsaKeyBits is the modulus size of generated RSA keys
+const rsaKeyBits = 2048
+
+// createdAtHeader is the PEM header holding a key's creation time
+// File times are not used: copies and secret mounts do not preserve them
+const createdAtHeader = "Created-At"
+
+// Key is a signing key identified by its kid
+type Key struct {
+	ID        string
+	Algorithm string
+	Private   crypto.Signer
+	CreatedAt time.Time
+
+	// RetiredAt is when a newer key took over signing, nil for the active key
+	RetiredAt *time.Time
+}
+
+// Public returns the verification half of the key
+func (k *Key) Public() crypto.PublicKey {
+	return k.Private.Public()
+}
+
+// method returns the golang-jwt signing method of the key
+func (k *Key) method() gojwt.SigningMethod {
+	switch k.Algorithm {
+	case RS256:
+		return gojwt.SigningMethodRS256
+	case ES256:
+		return gojwt.SigningMethodES256
+	default:
+		return gojwt.SigningMethodEdDSA
+	}
+}
+
+// GenerateKey creates a new key for algorithm with a random kid
+func GenerateKey(algorithm string) (*Key, error) {
+	var (
+		private crypto.Signer
+		err     error
+	)
+
+	switch algorithm {
+	case RS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case ES256:
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case EdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("jwt: unsupported algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		ID:        hex.EncodeToString(id),
+		Algorithm: algorithm,
+		Private:   private,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// algorithmOf infers the signing algorithm from a private key
+func algorithmOf(private crypto.Signer) (string, error) {
+	switch key := private.(type) {
+	case *rsa.PrivateKey:
+		return RS256, nil
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return "", fmt.Errorf("jwt: only P-256 ECDSA keys are supported")
+		}
+		return ES256, nil
+	case ed25519.PrivateKey:
+		return EdDSA, nil
+	default:
+		return "", fmt.Errorf("jwt: unsupported key type %T", private)
+	}
+}
+
+// WriteKey stores key in dir as <kid>.pem (PKCS#8, owner read/write only)
+// The creation time is kept in a PEM header, the file is renamed into place
+// so instances reloading the directory never read it half written
+func WriteKey(dir string, key *Key) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		return err
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: map[string]string{createdAtHeader: key.CreatedAt.UTC().Format(time.RFC3339Nano)},
+		Bytes:   der,
+	})
+
+	tmp := filepath.Join(dir, "."+key.ID+".pem.tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, key.ID+".pem")); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// LoadKeyDir reads every <kid>.pem private key in dir
+// Keys are ordered by creation time (the Created-At header, the file modification time
+// for keys provisioned without one): the newest signs and each older key counts as
+// retired from the moment its successor was created
+func LoadKeyDir(dir string) ([]*Key, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*Key
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("jwt: %s: no PEM block found", entry.Name())
+		}
+
+		private, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: %s: %w", entry.Name(), err)
+		}
+
+		createdAt := info.ModTime()
+		if header, ok := block.Headers[createdAtHeader]; ok {
+			if createdAt, err = time.Parse(time.RFC3339Nano, header); err != nil {
+				return nil, fmt.Errorf("jwt: %s: invalid %s header: %w", entry.Name(), createdAtHeader, err)
+			}
+		}
+
+		algorithm, err := algorithmOf(private)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: %s: %w", entry.Name(), err)
+		}
+
+		keys = append(keys, &Key{
+			ID:        strings.TrimSuffix(entry.Name(), ".pem"),
+			Algorithm: algorithm,
+			Private:   private,
+			CreatedAt: createdAt,
+		})
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	for i := 0; i < len(keys)-1; i++ {
+		retiredAt := keys[i+1].CreatedAt
+		keys[i].RetiredAt = &retiredAt
+	}
+
+	return keys, nil
+}
+
+// parsePrivateKey decodes a PKCS#8, PKCS#1 or SEC 1 private key PEM block
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	var (
+		parsed interface{}
+		err    error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return signer, nil
+}
@@ -0,0 +1,361 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-fiber-jwt/pkg/loglevel"
+	gojwt "github.com/golang-jwt/jwt"
+)
+
+// ErrUnknownKey is returned for tokens whose kid is unknown or past its grace window
+var ErrUnknownKey = errors.New("jwt: unknown signing key")
+
+// rotationLockFile is created exclusively in Dir by the instance generating a new key
+const rotationLockFile = ".rotate.lock"
+
+// rotationLockTimeout is the age after which a lock left by a crashed instance is broken
+const rotationLockTimeout = 30 * time.Second
+
+// errRotationLocked is returned while another instance holds the rotation lock
+var errRotationLocked = errors.New("jwt: key rotation in progress on another instance")
+
+// missReloadInterval is the minimum delay between two reloads triggered by an unknown kid
+const missReloadInterval = 5 * time.Second
+
+// KeySetConfig configures a KeySet
+type KeySetConfig struct {
+	// Algorithm of generated keys: RS256, ES256 or EdDSA (default RS256)
+	Algorithm string
+
+	// Dir persists keys as <kid>.pem so restarts and other instances share them
+	// When empty keys only live in memory and tokens do not survive a restart
+	Dir string
+
+	// RotationInterval is the age at which StartRotation replaces the signing key (0 disables rotation)
+	RotationInterval time.Duration
+
+	// GracePeriod is how long a retired key is still accepted for verification
+	// It should be at least the access token lifetime
+	GracePeriod time.Duration
+}
+
+// KeySet signs tokens with its newest key and verifies them with any key still in its grace window
+type KeySet struct {
+	cfg KeySetConfig
+
+	mu   sync.RWMutex
+	keys []*Key
+
+	// lastMissReload is when an unknown kid last triggered a reload (unix nanoseconds)
+	lastMissReload atomic.Int64
+}
+
+// NewKeySet loads the keys in cfg.Dir (if any) and generates a signing key when none is usable
+func NewKeySet(cfg KeySetConfig) (*KeySet, error) {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = RS256
+	}
+	if cfg.Algorithm != RS256 && cfg.Algorithm != ES256 && cfg.Algorithm != EdDSA {
+		return nil, fmt.Errorf("jwt: unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	ks := &KeySet{cfg: cfg}
+
+	if cfg.Dir != "" {
+		if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+			return nil, err
+		}
+		if err := ks.Reload(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Instances starting together wait for the one generating the key
+	for {
+		_, err := ks.rotateExclusive(func() bool { return ks.Active() == nil || ks.rotationDue(time.Now()) })
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errRotationLocked) {
+			return nil, err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return ks, nil
+}
+
+// NewStaticKeySet creates a key set from existing keys, the last one signs
+func NewStaticKeySet(gracePeriod time.Duration, keys ...*Key) *KeySet {
+	return &KeySet{cfg: KeySetConfig{GracePeriod: gracePeriod}, keys: keys}
+}
+
+// Sign signs claims with the active key and sets the kid header
+func (ks *KeySet) Sign(claims gojwt.Claims) (string, error) {
+	key := ks.Active()
+	if key == nil {
+		return "", errors.New("jwt: no active signing key")
+	}
+
+	token := gojwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// Parse verifies the signature of tokenString and decodes it into claims
+// The key is selected by kid and must match the token's algorithm
+func (ks *KeySet) Parse(tokenString string, claims gojwt.Claims, parser *gojwt.Parser) (*gojwt.Token, error) {
+	if parser == nil {
+		parser = &gojwt.Parser{}
+	}
+
+	return parser.ParseWithClaims(tokenString, claims, func(token *gojwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key := ks.Lookup(kid)
+		if key == nil {
+			return nil, ErrUnknownKey
+		}
+
+		// Never let the token pick the algorithm (alg confusion)
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("jwt: unexpected signing method %s", token.Method.Alg())
+		}
+
+		return key.Public(), nil
+	})
+}
+
+// Active returns the key currently used for signing
+func (ks *KeySet) Active() *Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	for i := len(ks.keys) - 1; i >= 0; i-- {
+		if ks.keys[i].RetiredAt == nil {
+			return ks.keys[i]
+		}
+	}
+	return nil
+}
+
+// Lookup returns the key with kid if it is still accepted for verification
+// An unknown kid may have just been rotated in by another instance: the directory
+// is reloaded once, at most every missReloadInterval, before giving up
+func (ks *KeySet) Lookup(kid string) *Key {
+	if key := ks.lookup(kid); key != nil || kid == "" || ks.cfg.Dir == "" {
+		return key
+	}
+
+	last := ks.lastMissReload.Load()
+	now := time.Now()
+	if now.Sub(time.Unix(0, last)) < missReloadInterval || !ks.lastMissReload.CompareAndSwap(last, now.UnixNano()) {
+		return nil
+	}
+	if err := ks.Reload(); err != nil {
+		loglevel.Printf(loglevel.Error, "jwt: failed to reload signing keys: %v", err)
+		return nil
+	}
+	return ks.lookup(kid)
+}
+
+// lookup is Lookup without the reload
+func (ks *KeySet) lookup(kid string) *Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	now := time.Now()
+	for _, key := range ks.keys {
+		if key.ID == kid && ks.accepted(key, now) {
+			return key
+		}
+	}
+	return nil
+}
+
+// Keys returns every key still accepted for verification, oldest first
+func (ks *KeySet) Keys() []*Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	now := time.Now()
+	keys := make([]*Key, 0, len(ks.keys))
+	for _, key := range ks.keys {
+		if ks.accepted(key, now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Rotate generates a new signing key and retires the current one
+// The retired key keeps verifying tokens for the grace period
+func (ks *KeySet) Rotate() (*Key, error) {
+	key, err := GenerateKey(ks.cfg.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	if ks.cfg.Dir != "" {
+		if err := WriteKey(ks.cfg.Dir, key); err != nil {
+			return nil, err
+		}
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	for _, existing := range ks.keys {
+		if existing.RetiredAt == nil {
+			retiredAt := key.CreatedAt
+			existing.RetiredAt = &retiredAt
+		}
+	}
+	ks.keys = append(ks.keys, key)
+	ks.prune(key.CreatedAt)
+
+	return key, nil
+}
+
+// Reload re-reads the key directory, picking up keys rotated by other instances
+func (ks *KeySet) Reload() error {
+	if ks.cfg.Dir == "" {
+		return nil
+	}
+
+	keys, err := LoadKeyDir(ks.cfg.Dir)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.keys = keys
+	ks.prune(time.Now())
+	return nil
+}
+
+// StartRotation replaces the signing key every RotationInterval until stop is closed
+// With a shared Dir, the new key is generated under a lock file by a single instance,
+// the others pick it up on their next reload
+func (ks *KeySet) StartRotation(stop <-chan struct{}) {
+	if ks.cfg.RotationInterval <= 0 {
+		return
+	}
+
+	check := ks.cfg.RotationInterval
+	if check > time.Minute {
+		check = time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(check)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				if err := ks.Reload(); err != nil {
+					loglevel.Printf(loglevel.Error, "jwt: failed to reload signing keys: %v", err)
+					continue
+				}
+				if !ks.rotationDue(now) {
+					continue
+				}
+				key, err := ks.rotateExclusive(func() bool { return ks.rotationDue(now) })
+				switch {
+				case errors.Is(err, errRotationLocked):
+					// Another instance is rotating, its key is picked up on the next tick
+				case err != nil:
+					loglevel.Printf(loglevel.Error, "jwt: failed to rotate signing key: %v", err)
+				case key != nil:
+					loglevel.Printf(loglevel.Info, "jwt: rotated signing key, new kid %s", key.ID)
+				}
+			}
+		}
+	}()
+}
+
+// rotateExclusive rotates when due reports true, holding the lock file in Dir so that
+// instances sharing it generate a single key
+// due is checked again after reloading under the lock: another instance may have just rotated
+// The returned key is nil when no rotation was needed
+func (ks *KeySet) rotateExclusive(due func() bool) (*Key, error) {
+	if ks.cfg.Dir != "" {
+		unlock, err := lockDir(filepath.Join(ks.cfg.Dir, rotationLockFile))
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+
+		if err := ks.Reload(); err != nil {
+			return nil, err
+		}
+	}
+
+	if !due() {
+		return nil, nil
+	}
+	return ks.Rotate()
+}
+
+// lockDir creates the lock file at path, failing with errRotationLocked while another
+// instance holds it; a lock older than rotationLockTimeout is considered abandoned
+func lockDir(path string) (unlock func(), err error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if os.IsExist(err) {
+		info, statErr := os.Stat(path)
+		if statErr != nil || time.Since(info.ModTime()) < rotationLockTimeout {
+			return nil, errRotationLocked
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		file, err = os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if os.IsExist(err) {
+			return nil, errRotationLocked
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+
+	return func() { os.Remove(path) }, nil
+}
+
+// rotationDue reports whether the active key is older than the rotation interval
+func (ks *KeySet) rotationDue(now time.Time) bool {
+	active := ks.Active()
+	return active != nil && ks.cfg.RotationInterval > 0 && now.Sub(active.CreatedAt) >= ks.cfg.RotationInterval
+}
+
+// accepted reports whether key may verify tokens at now (callers hold the lock)
+func (ks *KeySet) accepted(key *Key, now time.Time) bool {
+	return key.RetiredAt == nil || now.Before(key.RetiredAt.Add(ks.cfg.GracePeriod))
+}
+
+// prune drops keys past their grace window, deleting their files (callers hold the lock)
+func (ks *KeySet) prune(now time.Time) {
+	kept := ks.keys[:0]
+	for _, key := range ks.keys {
+		if ks.accepted(key, now) {
+			kept = append(kept, key)
+			continue
+		}
+		if ks.cfg.Dir != "" {
+			if err := os.Remove(filepath.Join(ks.cfg.Dir, key.ID+".pem")); err != nil && !os.IsNotExist(err) {
+				loglevel.Printf(loglevel.Warn, "jwt: failed to remove expired key %s: %v", key.ID, err)
+			}
+		}
+	}
+	ks.keys = kept
+}
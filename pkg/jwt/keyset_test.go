@@ -0,0 +1,194 @@
+package jwt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func testClaims() gojwt.MapClaims {
+	return gojwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+// Test Sign/Parse - Round trip for every supported algorithm, kid header set
+func TestKeySet_SignParse(t *testing.T) {
+	for _, algorithm := range []string{RS256, ES256, EdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			ks, err := NewKeySet(KeySetConfig{Algorithm: algorithm})
+			assert.NoError(t, err)
+
+			signed, err := ks.Sign(testClaims())
+			assert.NoError(t, err)
+
+			token, err := ks.Parse(signed, gojwt.MapClaims{}, nil)
+			assert.NoError(t, err)
+			assert.True(t, token.Valid)
+			assert.Equal(t, algorithm, token.Method.Alg())
+			assert.Equal(t, ks.Active().ID, token.Header["kid"])
+			assert.Equal(t, "user-1", token.Claims.(gojwt.MapClaims)["sub"])
+		})
+	}
+
+	_, err := NewKeySet(KeySetConfig{Algorithm: "HS256"})
+	assert.Error(t, err)
+}
+
+// Test Parse - Unknown kid, foreign keys and HMAC tokens are rejected
+func TestKeySet_ParseRejects(t *testing.T) {
+	ks, _ := NewKeySet(KeySetConfig{Algorithm: RS256})
+	other, _ := NewKeySet(KeySetConfig{Algorithm: RS256})
+
+	foreign, _ := other.Sign(testClaims())
+	_, err := ks.Parse(foreign, gojwt.MapClaims{}, nil)
+	assert.Error(t, err)
+
+	// HMAC token claiming the active kid (alg confusion)
+	hmac := gojwt.NewWithClaims(gojwt.SigningMethodHS256, testClaims())
+	hmac.Header["kid"] = ks.Active().ID
+	hmacSigned, _ := hmac.SignedString([]byte("secret"))
+	_, err = ks.Parse(hmacSigned, gojwt.MapClaims{}, nil)
+	assert.Error(t, err)
+
+	// Token with the right kid but a different algorithm
+	ec, _ := GenerateKey(ES256)
+	ec.ID = ks.Active().ID
+	mismatched, _ := NewStaticKeySet(0, ec).Sign(testClaims())
+	_, err = ks.Parse(mismatched, gojwt.MapClaims{}, nil)
+	assert.Error(t, err)
+}
+
+// Test Rotate - Old key verifies within the grace period and is dropped after
+func TestKeySet_RotateGracePeriod(t *testing.T) {
+	ks, _ := NewKeySet(KeySetConfig{Algorithm: EdDSA, GracePeriod: time.Hour})
+	old := ks.Active()
+	signedWithOld, _ := ks.Sign(testClaims())
+
+	rotated, err := ks.Rotate()
+	assert.NoError(t, err)
+	assert.NotEqual(t, old.ID, rotated.ID)
+	assert.Equal(t, rotated.ID, ks.Active().ID)
+	assert.NotNil(t, old.RetiredAt)
+
+	_, err = ks.Parse(signedWithOld, gojwt.MapClaims{}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, ks.Keys(), 2)
+
+	// Past the grace period the retired key is no longer accepted
+	expired := time.Now().Add(-2 * time.Hour)
+	old.RetiredAt = &expired
+	_, err = ks.Parse(signedWithOld, gojwt.MapClaims{}, nil)
+	assert.Error(t, err)
+	assert.Len(t, ks.Keys(), 1)
+}
+
+// Test NewKeySet - Keys persist in the directory and survive a restart
+func TestKeySet_Dir(t *testing.T) {
+	dir := t.TempDir()
+	cfg := KeySetConfig{Algorithm: ES256, Dir: dir, GracePeriod: time.Hour}
+
+	first, err := NewKeySet(cfg)
+	assert.NoError(t, err)
+	signed, _ := first.Sign(testClaims())
+
+	info, err := os.Stat(filepath.Join(dir, first.Active().ID+".pem"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	restarted, err := NewKeySet(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, first.Active().ID, restarted.Active().ID)
+	_, err = restarted.Parse(signed, gojwt.MapClaims{}, nil)
+	assert.NoError(t, err)
+
+	// A rotation by one instance is picked up by another on reload
+	rotated, _ := first.Rotate()
+	assert.NoError(t, restarted.Reload())
+	assert.Equal(t, rotated.ID, restarted.Active().ID)
+	_, err = restarted.Parse(signed, gojwt.MapClaims{}, nil)
+	assert.NoError(t, err)
+
+	// An active key older than the rotation interval is replaced on start
+	cfg.RotationInterval = time.Minute
+	rotated.CreatedAt = time.Now().Add(-time.Hour)
+	assert.NoError(t, WriteKey(dir, rotated))
+	fresh, err := NewKeySet(cfg)
+	assert.NoError(t, err)
+	assert.NotEqual(t, rotated.ID, fresh.Active().ID)
+}
+
+// Test Parse - A key rotated in by another instance is picked up on the first miss
+func TestKeySet_ReloadOnUnknownKid(t *testing.T) {
+	cfg := KeySetConfig{Algorithm: ES256, Dir: t.TempDir(), GracePeriod: time.Hour}
+	first, err := NewKeySet(cfg)
+	assert.NoError(t, err)
+	second, err := NewKeySet(cfg)
+	assert.NoError(t, err)
+
+	_, err = first.Rotate()
+	assert.NoError(t, err)
+	signed, _ := first.Sign(testClaims())
+
+	_, err = second.Parse(signed, gojwt.MapClaims{}, nil)
+	assert.NoError(t, err)
+
+	// Misses right after do not hit the directory again
+	_, _ = first.Rotate()
+	signed, _ = first.Sign(testClaims())
+	_, err = second.Parse(signed, gojwt.MapClaims{}, nil)
+	assert.ErrorContains(t, err, ErrUnknownKey.Error())
+}
+
+// Test StartRotation - Only the instance holding the lock generates a key
+func TestKeySet_RotationLock(t *testing.T) {
+	dir := t.TempDir()
+	cfg := KeySetConfig{Algorithm: ES256, Dir: dir, RotationInterval: time.Minute, GracePeriod: time.Hour}
+	first, err := NewKeySet(cfg)
+	assert.NoError(t, err)
+	second, err := NewKeySet(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, first.Active().ID, second.Active().ID)
+
+	due := func() bool { return true }
+	unlock, err := lockDir(filepath.Join(dir, rotationLockFile))
+	assert.NoError(t, err)
+	_, err = first.rotateExclusive(due)
+	assert.ErrorIs(t, err, errRotationLocked)
+	unlock()
+
+	// The second instance sees the first one's key after taking the lock and does not rotate again
+	rotated, err := first.rotateExclusive(due)
+	assert.NoError(t, err)
+	key, err := second.rotateExclusive(func() bool { return second.rotationDue(time.Now().Add(30 * time.Second)) })
+	assert.NoError(t, err)
+	assert.Nil(t, key)
+	assert.Equal(t, rotated.ID, second.Active().ID)
+
+	// A lock abandoned by a crashed instance is broken
+	lock := filepath.Join(dir, rotationLockFile)
+	assert.NoError(t, os.WriteFile(lock, nil, 0o600))
+	stale := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(lock, stale, stale))
+	_, err = first.rotateExclusive(due)
+	assert.NoError(t, err)
+}
+
+// Test LoadKeyDir - Key age comes from the file content, not its modification time
+func TestLoadKeyDir_CreatedAt(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := GenerateKey(EdDSA)
+	key.CreatedAt = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(t, WriteKey(dir, key))
+
+	// As if copied or mounted from a secret store
+	now := time.Now()
+	assert.NoError(t, os.Chtimes(filepath.Join(dir, key.ID+".pem"), now, now))
+
+	keys, err := LoadKeyDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.True(t, key.CreatedAt.Equal(keys[0].CreatedAt))
+}
@@ -0,0 +1,149 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+// Token validation errors
+var (
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrTokenExpired     = errors.New("token has expired")
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+)
+
+// Defaults used when the corresponding TokenConfig field is not set
+const (
+	defaultAccessTokenTTL = 15 * time.Minute
+	defaultClockSkew      = 30 * time.Second
+)
+
+// StandardClaims are the registered claims: sub, jti, iss, aud, exp, iat and nbf
+type StandardClaims = gojwt.StandardClaims
+
+// Claims are the claims of an access token
+type Claims struct {
+	StandardClaims
+
+	Role         string `json:"role,omitempty"`
+	TokenVersion int    `json:"ver"`
+
+	// Custom holds application specific claims
+	Custom map[string]interface{} `json:"custom,omitempty"`
+
+	// SessionID is the session the token was issued for (sid)
+	SessionID string `json:"sid,omitempty"`
+
+	// Scopes restrict the caller to a subset of its role's permissions
+	Scopes []string `json:"scope,omitempty"`
+
+	// APIKeyID is set when the caller authenticated with an API key instead of a token
+	// It is never part of a signed token
+	APIKeyID string `json:"-"`
+}
+
+// UserID returns the subject, the ID of the authenticated user
+func (c *Claims) UserID() string {
+	return c.Subject
+}
+
+// ExpiresAtTime returns the exp claim as a time
+func (c *Claims) ExpiresAtTime() time.Time {
+	return time.Unix(c.ExpiresAt, 0)
+}
+
+// TokenConfig configures a TokenService
+type TokenConfig struct {
+	// Issuer is set as iss and required when validating (skipped when empty)
+	Issuer string
+	// Audience is set as aud and required when validating (skipped when empty)
+	Audience string
+	// TTL is the access token lifetime
+	TTL time.Duration
+	// ClockSkew is the tolerance applied to exp, nbf and iat between servers (negative disables it)
+	ClockSkew time.Duration
+}
+
+// TokenService issues and validates access tokens
+type TokenService interface {
+	// Issue signs a token for claims, filling jti, iss, aud, iat, nbf and exp
+	Issue(claims Claims) (string, *Claims, error)
+	// Validate checks the signature and registered claims of a token
+	Validate(tokenString string) (*Claims, error)
+}
+
+// tokenService implements TokenService on top of a KeySet
+type tokenService struct {
+	keys *KeySet
+	cfg  TokenConfig
+	now  func() time.Time
+}
+
+// NewTokenService creates a token service signing with keys
+func NewTokenService(keys *KeySet, cfg TokenConfig) TokenService {
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultAccessTokenTTL
+	}
+	if cfg.ClockSkew < 0 {
+		cfg.ClockSkew = 0
+	} else if cfg.ClockSkew == 0 {
+		cfg.ClockSkew = defaultClockSkew
+	}
+	return &tokenService{keys: keys, cfg: cfg, now: time.Now}
+}
+
+// Issue signs a token for claims, filling jti, iss, aud, iat, nbf and exp
+func (s *tokenService) Issue(claims Claims) (string, *Claims, error) {
+	if claims.Subject == "" {
+		return "", nil, fmt.Errorf("jwt: subject is required")
+	}
+
+	now := s.now().UTC()
+	claims.Id = uuid.New().String()
+	claims.Issuer = s.cfg.Issuer
+	claims.Audience = s.cfg.Audience
+	claims.IssuedAt = now.Unix()
+	claims.NotBefore = now.Unix()
+	claims.ExpiresAt = now.Add(s.cfg.TTL).Unix()
+
+	signed, err := s.keys.Sign(&claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, &claims, nil
+}
+
+// Validate checks the signature and registered claims of a token
+// Time based claims are checked with the configured clock skew
+func (s *tokenService) Validate(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+
+	// Registered claims are validated below, with leeway
+	if _, err := s.keys.Parse(tokenString, claims, &gojwt.Parser{SkipClaimsValidation: true}); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	now := s.now().Unix()
+	skew := int64(s.cfg.ClockSkew / time.Second)
+
+	switch {
+	case claims.Subject == "" || claims.Id == "":
+		return nil, ErrInvalidToken
+	case claims.ExpiresAt == 0 || now > claims.ExpiresAt+skew:
+		return nil, ErrTokenExpired
+	case claims.NotBefore > now+skew || claims.IssuedAt > now+skew:
+		return nil, ErrTokenNotYetValid
+	case s.cfg.Issuer != "" && claims.Issuer != s.cfg.Issuer:
+		return nil, ErrInvalidIssuer
+	case s.cfg.Audience != "" && claims.Audience != s.cfg.Audience:
+		return nil, ErrInvalidAudience
+	}
+
+	return claims, nil
+}
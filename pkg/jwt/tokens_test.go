@@ -0,0 +1,95 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTokenService(t *testing.T, cfg TokenConfig) *tokenService {
+	keys, err := NewKeySet(KeySetConfig{Algorithm: EdDSA})
+	assert.NoError(t, err)
+	return NewTokenService(keys, cfg).(*tokenService)
+}
+
+// Test Issue/Validate - Registered and custom claims round trip
+func TestTokenService_IssueValidate(t *testing.T) {
+	svc := newTestTokenService(t, TokenConfig{Issuer: "https://api.example.com", Audience: "example", TTL: time.Minute})
+
+	signed, issued, err := svc.Issue(Claims{
+		StandardClaims: StandardClaims{Subject: "user-1"},
+		Role:           "admin",
+		TokenVersion:   2,
+		Custom:         map[string]interface{}{"tenant": "acme"},
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, issued.Id)
+	assert.Equal(t, issued.IssuedAt+60, issued.ExpiresAt)
+
+	claims, err := svc.Validate(signed)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", claims.UserID())
+	assert.Equal(t, "admin", claims.Role)
+	assert.Equal(t, 2, claims.TokenVersion)
+	assert.Equal(t, issued.Id, claims.Id)
+	assert.Equal(t, "https://api.example.com", claims.Issuer)
+	assert.Equal(t, "example", claims.Audience)
+	assert.Equal(t, "acme", claims.Custom["tenant"])
+
+	_, _, err = svc.Issue(Claims{})
+	assert.Error(t, err)
+
+	_, err = svc.Validate("not a token")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+// Test Validate - exp and nbf are checked with clock skew tolerance
+func TestTokenService_ClockSkew(t *testing.T) {
+	svc := newTestTokenService(t, TokenConfig{TTL: time.Minute, ClockSkew: 10 * time.Second})
+	issuedAt := time.Now()
+	svc.now = func() time.Time { return issuedAt }
+	signed, _, _ := svc.Issue(Claims{StandardClaims: StandardClaims{Subject: "user-1"}})
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected error
+	}{
+		{name: "within lifetime", at: issuedAt.Add(30 * time.Second)},
+		{name: "expired within skew", at: issuedAt.Add(65 * time.Second)},
+		{name: "expired beyond skew", at: issuedAt.Add(75 * time.Second), expected: ErrTokenExpired},
+		{name: "early within skew", at: issuedAt.Add(-5 * time.Second)},
+		{name: "early beyond skew", at: issuedAt.Add(-20 * time.Second), expected: ErrTokenNotYetValid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := tt.at
+			svc.now = func() time.Time { return at }
+
+			_, err := svc.Validate(signed)
+			if tt.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expected)
+			}
+		})
+	}
+}
+
+// Test Validate - Tokens for another issuer or audience are rejected
+func TestTokenService_IssuerAudience(t *testing.T) {
+	keys, _ := NewKeySet(KeySetConfig{Algorithm: EdDSA})
+	api := NewTokenService(keys, TokenConfig{Issuer: "auth", Audience: "api"})
+	admin := NewTokenService(keys, TokenConfig{Issuer: "auth", Audience: "admin"})
+	other := NewTokenService(keys, TokenConfig{Issuer: "other", Audience: "api"})
+
+	signed, _, _ := admin.Issue(Claims{StandardClaims: StandardClaims{Subject: "user-1"}})
+	_, err := api.Validate(signed)
+	assert.ErrorIs(t, err, ErrInvalidAudience)
+
+	signed, _, _ = other.Issue(Claims{StandardClaims: StandardClaims{Subject: "user-1"}})
+	_, err = api.Validate(signed)
+	assert.ErrorIs(t, err, ErrInvalidIssuer)
+}
@@ -0,0 +1,217 @@
+package lockout
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-fiber-jwt/pkg/apperror"
+)
+
+// Defaults used when the corresponding Config field is not set
+const (
+	defaultMaxAttempts        = 5
+	defaultIPMaxAttempts      = 20
+	defaultBaseDelay          = time.Second
+	defaultLockoutDuration    = 15 * time.Minute
+	defaultMaxLockoutDuration = 24 * time.Hour
+	defaultWindow             = time.Hour
+)
+
+// Config configures a Guard
+type Config struct {
+	// MaxAttempts is the number of failures after which an account is locked out
+	MaxAttempts int
+	// IPMaxAttempts is the number of failures after which an IP address is locked out
+	IPMaxAttempts int
+	// BaseDelay is the wait after the first failure, doubling with every further one below the limit
+	BaseDelay time.Duration
+	// LockoutDuration is the lockout once the limit is reached, doubling with every failure past it
+	LockoutDuration time.Duration
+	// MaxLockoutDuration caps the lockout
+	MaxLockoutDuration time.Duration
+	// Window is how long failures are remembered after the last failure or lockout
+	Window time.Duration
+}
+
+// LockedError is returned while an account or IP address must wait before trying again
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *LockedError) Error() string {
+	return "too many failed sign-in attempts, try again later"
+}
+
+// AppError maps the lockout to a too many requests error carrying RetryAfter
+func (e *LockedError) AppError() *apperror.Error {
+	return apperror.TooManyRequests("too_many_attempts", e.Error(), e.RetryAfter)
+}
+
+// Status is the lockout state of an account
+type Status struct {
+	Failures    int
+	LockedUntil *time.Time
+}
+
+// Locked reports whether the account currently rejects sign-ins
+func (s Status) Locked() bool {
+	return s.LockedUntil != nil
+}
+
+// Guard throttles sign-in attempts per account and per IP address
+// Every failure delays the next attempt exponentially, reaching the limit locks the key out
+type Guard struct {
+	store Store
+	cfg   Config
+}
+
+// NewGuard creates a guard keeping its counters in store
+func NewGuard(store Store, cfg Config) *Guard {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if cfg.IPMaxAttempts <= 0 {
+		cfg.IPMaxAttempts = defaultIPMaxAttempts
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = defaultBaseDelay
+	}
+	if cfg.LockoutDuration <= 0 {
+		cfg.LockoutDuration = defaultLockoutDuration
+	}
+	if cfg.MaxLockoutDuration <= 0 {
+		cfg.MaxLockoutDuration = defaultMaxLockoutDuration
+	}
+	if cfg.LockoutDuration > cfg.MaxLockoutDuration {
+		cfg.LockoutDuration = cfg.MaxLockoutDuration
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultWindow
+	}
+	return &Guard{store: store, cfg: cfg}
+}
+
+// Check returns a *LockedError when the account or the IP address must wait
+func (g *Guard) Check(account, ip string) error {
+	keys := []string{accountKey(account)}
+	if ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+
+	entries, err := g.store.Get(keys...)
+	if err != nil {
+		return fmt.Errorf("failed to check sign-in attempts: %w", err)
+	}
+
+	var wait time.Duration
+	now := time.Now()
+	for _, entry := range entries {
+		if remaining := entry.LockedUntil.Sub(now); remaining > wait {
+			wait = remaining
+		}
+	}
+	if wait > 0 {
+		// Whole seconds, rounded up, as sent in Retry-After
+		return &LockedError{RetryAfter: (wait + time.Second - 1).Truncate(time.Second)}
+	}
+	return nil
+}
+
+// Fail records a failed sign-in of account from ip and delays the next attempt of both
+func (g *Guard) Fail(account, ip string) error {
+	if err := g.fail(accountKey(account), g.cfg.MaxAttempts); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return g.fail(ipKey(ip), g.cfg.IPMaxAttempts)
+}
+
+// Succeed clears the failures of account after a successful sign-in
+// IP counters are kept, one valid account must not reset an attack from the same address
+func (g *Guard) Succeed(account string) error {
+	if err := g.store.Reset(accountKey(account)); err != nil {
+		return fmt.Errorf("failed to reset sign-in attempts: %w", err)
+	}
+	return nil
+}
+
+// Unlock clears the failures and lockout of account (admin action)
+func (g *Guard) Unlock(account string) error {
+	return g.Succeed(account)
+}
+
+// Status returns the lockout state of accounts, keyed by the accounts as given
+func (g *Guard) Status(accounts ...string) (map[string]Status, error) {
+	keys := make([]string, len(accounts))
+	for i, account := range accounts {
+		keys[i] = accountKey(account)
+	}
+
+	entries, err := g.store.Get(keys...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sign-in attempts: %w", err)
+	}
+
+	now := time.Now()
+	statuses := make(map[string]Status, len(accounts))
+	for i, account := range accounts {
+		entry := entries[keys[i]]
+		status := Status{Failures: entry.Failures}
+		if entry.LockedUntil.After(now) {
+			lockedUntil := entry.LockedUntil
+			status.LockedUntil = &lockedUntil
+		}
+		statuses[account] = status
+	}
+	return statuses, nil
+}
+
+// fail counts a failure on key and locks it for the backoff of its failure count
+func (g *Guard) fail(key string, limit int) error {
+	now := time.Now()
+	entry, err := g.store.Fail(key, now.Add(g.cfg.Window))
+	if err != nil {
+		return fmt.Errorf("failed to record sign-in attempt: %w", err)
+	}
+
+	until := now.Add(g.delay(entry.Failures, limit))
+	if err := g.store.Lock(key, until, until.Add(g.cfg.Window)); err != nil {
+		return fmt.Errorf("failed to record sign-in attempt: %w", err)
+	}
+	return nil
+}
+
+// delay is the wait after failures: BaseDelay doubling up to the limit,
+// then LockoutDuration doubling with every failure past it
+func (g *Guard) delay(failures, limit int) time.Duration {
+	if failures < limit {
+		return backoff(g.cfg.BaseDelay, failures-1, g.cfg.LockoutDuration)
+	}
+	return backoff(g.cfg.LockoutDuration, failures-limit, g.cfg.MaxLockoutDuration)
+}
+
+// backoff returns base doubled n times, capped at max
+func backoff(base time.Duration, n int, max time.Duration) time.Duration {
+	d := base
+	for i := 0; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// accountKey normalizes an account the way sign-in looks up emails
+func accountKey(account string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+// ipKey is the key of an IP address
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
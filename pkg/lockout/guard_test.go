@@ -0,0 +1,106 @@
+package lockout
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestGuard() *Guard {
+	return NewGuard(NewMemoryStore(0), Config{
+		MaxAttempts:     3,
+		IPMaxAttempts:   5,
+		BaseDelay:       time.Second,
+		LockoutDuration: time.Hour,
+		Window:          time.Hour,
+	})
+}
+
+// Test Guard - Every failure delays the next attempt exponentially
+func TestGuard_Fail_Backoff(t *testing.T) {
+	guard := newTestGuard()
+
+	assert.NoError(t, guard.Check("john@example.com", "203.0.113.7"))
+
+	assert.NoError(t, guard.Fail("john@example.com", "203.0.113.7"))
+	var locked *LockedError
+	assert.True(t, errors.As(guard.Check("john@example.com", "203.0.113.7"), &locked))
+	assert.Equal(t, time.Second, locked.RetryAfter)
+
+	assert.NoError(t, guard.Fail("john@example.com", "203.0.113.7"))
+	assert.True(t, errors.As(guard.Check("john@example.com", "203.0.113.7"), &locked))
+	assert.Equal(t, 2*time.Second, locked.RetryAfter)
+}
+
+// Test Guard - Reaching the limit locks the account out
+func TestGuard_Fail_Lockout(t *testing.T) {
+	guard := newTestGuard()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, guard.Fail("john@example.com", ""))
+	}
+
+	var locked *LockedError
+	assert.True(t, errors.As(guard.Check("john@example.com", ""), &locked))
+	assert.Equal(t, time.Hour, locked.RetryAfter)
+
+	// Emails are matched the way sign-in looks them up
+	assert.Error(t, guard.Check(" John@Example.com", ""))
+
+	statuses, err := guard.Status("john@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, statuses["john@example.com"].Failures)
+	assert.True(t, statuses["john@example.com"].Locked())
+
+	// Each failure past the limit doubles the lockout
+	assert.NoError(t, guard.Fail("john@example.com", ""))
+	assert.True(t, errors.As(guard.Check("john@example.com", ""), &locked))
+	assert.Equal(t, 2*time.Hour, locked.RetryAfter)
+}
+
+// Test Guard - The IP address is throttled across accounts
+func TestGuard_Fail_PerIP(t *testing.T) {
+	guard := NewGuard(NewMemoryStore(0), Config{MaxAttempts: 100, IPMaxAttempts: 2, BaseDelay: time.Nanosecond, LockoutDuration: time.Hour})
+
+	assert.NoError(t, guard.Fail("a@example.com", "203.0.113.7"))
+	assert.NoError(t, guard.Fail("b@example.com", "203.0.113.7"))
+
+	assert.Error(t, guard.Check("c@example.com", "203.0.113.7"))
+	assert.NoError(t, guard.Check("c@example.com", "198.51.100.1"))
+}
+
+// Test Guard - Success and unlock clear the account but not the IP address
+func TestGuard_Unlock(t *testing.T) {
+	guard := newTestGuard()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, guard.Fail("john@example.com", "203.0.113.7"))
+	}
+	assert.NoError(t, guard.Unlock("john@example.com"))
+
+	assert.NoError(t, guard.Check("john@example.com", ""))
+	assert.Error(t, guard.Check("john@example.com", "203.0.113.7"))
+
+	statuses, err := guard.Status("john@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, Status{}, statuses["john@example.com"])
+}
+
+// Test MemoryStore - Expired entries start counting from zero
+func TestMemoryStore_Expiry(t *testing.T) {
+	store := NewMemoryStore(0)
+
+	entry, err := store.Fail("key", time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, entry.Failures)
+
+	entry, err = store.Fail("key", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, entry.Failures)
+
+	entries, err := store.Get("key", "missing")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,50 @@
+package lockout
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Entry is the failed attempts recorded for one key (an account or an IP address)
+type Entry struct {
+	Failures    int
+	LockedUntil time.Time
+	ExpiresAt   time.Time
+}
+
+// Store keeps failed attempt counters
+// Entries are forgotten once they expire, restarting the count from zero
+type Store interface {
+	// Get returns the unexpired entries of keys, keys without one are absent from the map
+	Get(keys ...string) (map[string]Entry, error)
+
+	// Fail counts a failed attempt and keeps the entry at least until expiresAt
+	Fail(key string, expiresAt time.Time) (Entry, error)
+
+	// Lock rejects attempts on key until the given time and keeps the entry at least until expiresAt
+	Lock(key string, until, expiresAt time.Time) error
+
+	// Reset forgets the failures of key
+	Reset(key string) error
+}
+
+// Store backends
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+// NewStore creates a lockout store for the given backend name
+// memorySize is only used by the in-memory backend
+func NewStore(backend string, db *gorm.DB, memorySize int) (Store, error) {
+	switch backend {
+	case "", BackendMemory:
+		return NewMemoryStore(memorySize), nil
+	case BackendPostgres:
+		return NewPostgresStore(db), nil
+	default:
+		return nil, fmt.Errorf("unknown lockout store %q", backend)
+	}
+}
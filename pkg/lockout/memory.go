@@ -0,0 +1,127 @@
+package lockout
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// defaultMemorySize is used when NewMemoryStore is given a non-positive size
+const defaultMemorySize = 10000
+
+// memoryEntry is a single key tracked by MemoryStore
+type memoryEntry struct {
+	key   string
+	entry Entry
+}
+
+// MemoryStore is an in-memory LRU lockout store
+// It is bounded by size: once full, the least recently failed key is
+// evicted. State is per process and lost on restart, use the Postgres
+// store when running more than one instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// NewMemoryStore creates a new in-memory lockout store
+func NewMemoryStore(size int) *MemoryStore {
+	if size <= 0 {
+		size = defaultMemorySize
+	}
+	return &MemoryStore{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the unexpired entries of keys, keys without one are absent from the map
+func (s *MemoryStore) Get(keys ...string) (map[string]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	found := make(map[string]Entry, len(keys))
+	for _, key := range keys {
+		if el := s.lookup(key, now); el != nil {
+			found[key] = el.Value.(*memoryEntry).entry
+		}
+	}
+	return found, nil
+}
+
+// Fail counts a failed attempt and keeps the entry at least until expiresAt
+func (s *MemoryStore) Fail(key string, expiresAt time.Time) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el := s.lookup(key, time.Now())
+	if el == nil {
+		el = s.order.PushFront(&memoryEntry{key: key})
+		s.entries[key] = el
+
+		for s.order.Len() > s.size {
+			s.remove(s.order.Back())
+		}
+	}
+	s.order.MoveToFront(el)
+
+	entry := &el.Value.(*memoryEntry).entry
+	entry.Failures++
+	if expiresAt.After(entry.ExpiresAt) {
+		entry.ExpiresAt = expiresAt
+	}
+	return *entry, nil
+}
+
+// Lock rejects attempts on key until the given time and keeps the entry at least until expiresAt
+func (s *MemoryStore) Lock(key string, until, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el := s.lookup(key, time.Now())
+	if el == nil {
+		return nil
+	}
+
+	entry := &el.Value.(*memoryEntry).entry
+	entry.LockedUntil = until
+	if expiresAt.After(entry.ExpiresAt) {
+		entry.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+// Reset forgets the failures of key
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[key]; ok {
+		s.remove(el)
+	}
+	return nil
+}
+
+// lookup returns the element of key, dropping it when it has expired
+func (s *MemoryStore) lookup(key string, now time.Time) *list.Element {
+	el, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+
+	if now.After(el.Value.(*memoryEntry).entry.ExpiresAt) {
+		s.remove(el)
+		return nil
+	}
+	return el
+}
+
+// remove drops an element from both the list and the index
+func (s *MemoryStore) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,93 @@
+package lockout
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginAttemptModel represents the database model with GORM tags (infrastructure concern)
+type LoginAttemptModel struct {
+	Key         string `gorm:"type:varchar(255);primary_key"`
+	Failures    int    `gorm:"not null;default:0"`
+	LockedUntil *time.Time
+	ExpiresAt   time.Time `gorm:"index;not null"`
+}
+
+// TableName specifies the table name for GORM
+func (LoginAttemptModel) TableName() string {
+	return "login_attempts"
+}
+
+// PostgresStore is a lockout store shared by every instance through Postgres
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresStore creates a new Postgres-backed lockout store
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Get returns the unexpired entries of keys, keys without one are absent from the map
+func (s *PostgresStore) Get(keys ...string) (map[string]Entry, error) {
+	var models []LoginAttemptModel
+	result := s.db.Where("key IN ? AND expires_at > ?", keys, time.Now()).Find(&models)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	found := make(map[string]Entry, len(models))
+	for i := range models {
+		found[models[i].Key] = toEntry(&models[i])
+	}
+	return found, nil
+}
+
+// Fail counts a failed attempt and keeps the entry at least until expiresAt
+// The upsert is atomic so concurrent failures on several instances are all counted
+func (s *PostgresStore) Fail(key string, expiresAt time.Time) (Entry, error) {
+	var model LoginAttemptModel
+	result := s.db.Raw(`
+		INSERT INTO login_attempts (key, failures, expires_at) VALUES (@key, 1, @expires_at)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.expires_at < @now THEN 1 ELSE login_attempts.failures + 1 END,
+			locked_until = CASE WHEN login_attempts.expires_at < @now THEN NULL ELSE login_attempts.locked_until END,
+			expires_at = GREATEST(login_attempts.expires_at, EXCLUDED.expires_at)
+		RETURNING key, failures, locked_until, expires_at`,
+		map[string]interface{}{"key": key, "expires_at": expiresAt, "now": time.Now()},
+	).Scan(&model)
+	if result.Error != nil {
+		return Entry{}, result.Error
+	}
+	return toEntry(&model), nil
+}
+
+// Lock rejects attempts on key until the given time and keeps the entry at least until expiresAt
+func (s *PostgresStore) Lock(key string, until, expiresAt time.Time) error {
+	return s.db.Model(&LoginAttemptModel{}).
+		Where("key = ? AND expires_at > ?", key, time.Now()).
+		Updates(map[string]interface{}{
+			"locked_until": until,
+			"expires_at":   gorm.Expr("GREATEST(expires_at, ?)", expiresAt),
+		}).Error
+}
+
+// Reset forgets the failures of key
+func (s *PostgresStore) Reset(key string) error {
+	if err := s.db.Where("key = ?", key).Delete(&LoginAttemptModel{}).Error; err != nil {
+		return err
+	}
+
+	// Opportunistically purge counters that have expired anyway
+	return s.db.Where("expires_at < ?", time.Now()).Delete(&LoginAttemptModel{}).Error
+}
+
+// toEntry converts database model to an Entry
+func toEntry(model *LoginAttemptModel) Entry {
+	entry := Entry{Failures: model.Failures, ExpiresAt: model.ExpiresAt}
+	if model.LockedUntil != nil {
+		entry.LockedUntil = *model.LockedUntil
+	}
+	return entry
+}
@@ -0,0 +1,73 @@
+package loglevel
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is the minimum severity of the messages that get logged
+type Level int32
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var names = map[string]Level{"debug": Debug, "info": Info, "warn": Warn, "error": Error}
+
+// current is the process-wide level, changed at runtime by configuration reloads
+var current atomic.Int32
+
+func init() {
+	current.Store(int32(Info))
+}
+
+// Parse parses a level name: debug, info, warn or error
+func Parse(name string) (Level, error) {
+	level, ok := names[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Info, fmt.Errorf("loglevel: unknown level %q", name)
+	}
+	return level, nil
+}
+
+// Set changes the process-wide level
+func Set(level Level) {
+	current.Store(int32(level))
+}
+
+// Get returns the process-wide level
+func Get() Level {
+	return Level(current.Load())
+}
+
+// Enabled reports whether messages of level are logged
+func Enabled(level Level) bool {
+	return level >= Get()
+}
+
+// String returns the level's name
+func (l Level) String() string {
+	for name, level := range names {
+		if level == l {
+			return name
+		}
+	}
+	return fmt.Sprintf("level(%d)", int32(l))
+}
+
+// Printf logs like log.Printf, tagged with level, when level is enabled
+func Printf(level Level, format string, args ...interface{}) {
+	Print(level, fmt.Sprintf(format, args...))
+}
+
+// Print logs msg tagged with level when level is enabled
+func Print(level Level, msg string) {
+	if Enabled(level) {
+		log.Print("level=" + level.String() + " " + msg)
+	}
+}
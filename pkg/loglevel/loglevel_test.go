@@ -0,0 +1,47 @@
+package loglevel
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test Parse - Known names, case-insensitive, unknown names fail
+func TestParse(t *testing.T) {
+	level, err := Parse("WARN")
+	assert.NoError(t, err)
+	assert.Equal(t, Warn, level)
+
+	_, err = Parse("verbose")
+	assert.Error(t, err)
+}
+
+// Test Enabled - Messages below the level are dropped
+func TestEnabled(t *testing.T) {
+	defer Set(Get())
+
+	Set(Warn)
+	assert.False(t, Enabled(Info))
+	assert.True(t, Enabled(Warn))
+	assert.True(t, Enabled(Error))
+
+	Set(Debug)
+	assert.True(t, Enabled(Debug))
+}
+
+// Test Printf - Lines are tagged with their level, disabled levels are not written
+func TestPrintf(t *testing.T) {
+	defer Set(Get())
+	defer log.SetOutput(log.Writer())
+	var out bytes.Buffer
+	log.SetOutput(&out)
+
+	Set(Warn)
+	Printf(Info, "reloaded %s", "LOG_LEVEL")
+	assert.Empty(t, out.String())
+
+	Printf(Error, "store down: %v", "timeout")
+	assert.Contains(t, out.String(), "level=error store down: timeout")
+}
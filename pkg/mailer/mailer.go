@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Mailer backends
+const (
+	BackendLog  = "log"
+	BackendSMTP = "smtp"
+)
+
+// Config holds mailer settings
+type Config struct {
+	Backend string
+	// LogFile is where the log backend appends messages (stdout when empty)
+	LogFile string
+	From    string
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// New creates a mailer for the configured backend
+func New(cfg Config) (Mailer, error) {
+	switch cfg.Backend {
+	case "", BackendLog:
+		if cfg.LogFile == "" {
+			return NewLogMailer(os.Stdout), nil
+		}
+		return NewFileMailer(cfg.LogFile)
+	case BackendSMTP:
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer backend %q", cfg.Backend)
+	}
+}
+
+// LogMailer writes messages to a writer instead of sending them
+// Intended for local development and tests
+type LogMailer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewLogMailer creates a mailer that writes messages to w
+func NewLogMailer(w io.Writer) *LogMailer {
+	return &LogMailer{w: w}
+}
+
+// NewFileMailer creates a mailer that appends messages to the file at path
+func NewFileMailer(path string) (*LogMailer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mail log: %w", err)
+	}
+	return NewLogMailer(f), nil
+}
+
+// Send writes the message to the underlying writer
+func (m *LogMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.w, "----- %s -----\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
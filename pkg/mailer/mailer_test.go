@@ -0,0 +1,102 @@
+package mailer
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTPServer accepts a single SMTP session and records the DATA section
+func fakeSMTPServer(t *testing.T) (addr string, received <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	out := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 localhost fake smtp")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					out <- data.String()
+					reply("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				inData = true
+				reply("354 End data with <CR><LF>.<CR><LF>")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), out
+}
+
+// Test SMTPMailer - Delivers to a local fake server
+func TestSMTPMailer_Send(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+
+	m := NewSMTPMailer(host, port, "", "", "no-reply@example.com")
+	err := m.Send(Message{To: "john@example.com", Subject: "Hello", Body: "Verify me"})
+
+	assert.NoError(t, err)
+	data := <-received
+	assert.Contains(t, data, "To: john@example.com")
+	assert.Contains(t, data, "Subject: Hello")
+	assert.Contains(t, data, "Verify me")
+}
+
+// Test SMTPMailer - Header injection is rejected
+func TestSMTPMailer_RejectsHeaderInjection(t *testing.T) {
+	m := NewSMTPMailer("127.0.0.1", "1", "", "", "no-reply@example.com")
+
+	err := m.Send(Message{To: "john@example.com\r\nBcc: all@example.com", Subject: "Hello"})
+
+	assert.Error(t, err)
+}
+
+// Test LogMailer - Writes the message
+func TestLogMailer_Send(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewLogMailer(&buf)
+
+	err := m.Send(Message{To: "john@example.com", Subject: "Hello", Body: "Verify me"})
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "To: john@example.com")
+	assert.Contains(t, buf.String(), "Verify me")
+}
@@ -0,0 +1,295 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrEmailMissing is returned when the provider profile has no email address
+var ErrEmailMissing = errors.New("oauth: provider did not return an email address")
+
+// Provider is an OAuth2 / OpenID Connect authorization server
+// Profile fields are read from the userinfo response with dotted paths (e.g. "picture.data.url")
+type Provider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+
+	SubjectField       string
+	EmailField         string
+	EmailVerifiedField string // empty means the provider only returns confirmed emails
+	NameField          string
+	PictureField       string
+
+	// HTTPClient is used for token and userinfo requests (defaults to a 10s timeout client)
+	HTTPClient *http.Client
+}
+
+// Token is the token endpoint response
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Profile is the user identity returned by the provider
+type Profile struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+}
+
+// Google returns the Google OpenID Connect provider
+func Google(clientID, clientSecret, redirectURL string) *Provider {
+	return &Provider{
+		Name:               "google",
+		ClientID:           clientID,
+		ClientSecret:       clientSecret,
+		RedirectURL:        redirectURL,
+		Scopes:             []string{"openid", "email", "profile"},
+		AuthURL:            "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:           "https://oauth2.googleapis.com/token",
+		UserInfoURL:        "https://openidconnect.googleapis.com/v1/userinfo",
+		SubjectField:       "sub",
+		EmailField:         "email",
+		EmailVerifiedField: "email_verified",
+		NameField:          "name",
+		PictureField:       "picture",
+	}
+}
+
+// Facebook returns the Facebook OAuth2 provider
+func Facebook(clientID, clientSecret, redirectURL string) *Provider {
+	return &Provider{
+		Name:         "facebook",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"email", "public_profile"},
+		AuthURL:      "https://www.facebook.com/v19.0/dialog/oauth",
+		TokenURL:     "https://graph.facebook.com/v19.0/oauth/access_token",
+		UserInfoURL:  "https://graph.facebook.com/me?fields=id,name,email,picture.type(large)",
+		SubjectField: "id",
+		EmailField:   "email",
+		NameField:    "name",
+		PictureField: "picture.data.url",
+	}
+}
+
+// Registry holds the configured providers by name
+type Registry struct {
+	providers map[string]*Provider
+}
+
+// NewRegistry creates a registry, providers without a client ID are skipped
+func NewRegistry(providers ...*Provider) *Registry {
+	registry := &Registry{providers: make(map[string]*Provider, len(providers))}
+	for _, provider := range providers {
+		if provider == nil || provider.ClientID == "" {
+			continue
+		}
+		registry.providers[strings.ToLower(provider.Name)] = provider
+	}
+	return registry
+}
+
+// Get returns the provider registered under name
+func (r *Registry) Get(name string) (*Provider, bool) {
+	if r == nil {
+		return nil, false
+	}
+	provider, ok := r.providers[strings.ToLower(name)]
+	return provider, ok
+}
+
+// GenerateVerifier returns a random PKCE code verifier
+func GenerateVerifier() (string, error) {
+	return randomString(32)
+}
+
+// GenerateState returns a random state value
+func GenerateState() (string, error) {
+	return randomString(24)
+}
+
+// Challenge returns the S256 PKCE code challenge of verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the URL the user is redirected to for consent
+func (p *Provider) AuthCodeURL(state, verifier string) string {
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(p.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		separator = "&"
+	}
+	return p.AuthURL + separator + params.Encode()
+}
+
+// Exchange trades an authorization code and its PKCE verifier for a token
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (*Token, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"code_verifier": {verifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token Token
+	if err := p.do(req, &token); err != nil {
+		return nil, fmt.Errorf("oauth: token exchange failed: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, errors.New("oauth: token response has no access_token")
+	}
+
+	return &token, nil
+}
+
+// FetchProfile loads the user's identity from the userinfo endpoint
+func (p *Provider) FetchProfile(ctx context.Context, token *Token) (*Profile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.UserInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var claims map[string]interface{}
+	if err := p.do(req, &claims); err != nil {
+		return nil, fmt.Errorf("oauth: userinfo request failed: %w", err)
+	}
+
+	profile := &Profile{
+		Subject:       lookupString(claims, p.SubjectField),
+		Email:         strings.ToLower(strings.TrimSpace(lookupString(claims, p.EmailField))),
+		EmailVerified: true,
+		Name:          lookupString(claims, p.NameField),
+		Picture:       lookupString(claims, p.PictureField),
+	}
+	if p.EmailVerifiedField != "" {
+		profile.EmailVerified = lookupBool(claims, p.EmailVerifiedField)
+	}
+
+	if profile.Subject == "" {
+		return nil, errors.New("oauth: userinfo response has no subject")
+	}
+	if profile.Email == "" {
+		return nil, ErrEmailMissing
+	}
+
+	return profile, nil
+}
+
+// do sends req and decodes a JSON response into out
+func (p *Provider) do(req *http.Request, out interface{}) error {
+	client := p.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// lookup follows a dotted path through nested JSON objects
+func lookup(claims map[string]interface{}, path string) interface{} {
+	if path == "" {
+		return nil
+	}
+
+	var current interface{} = claims
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = object[key]
+	}
+	return current
+}
+
+// lookupString reads a string (or number, e.g. numeric IDs) at path
+func lookupString(claims map[string]interface{}, path string) string {
+	switch value := lookup(claims, path).(type) {
+	case string:
+		return value
+	case float64:
+		return fmt.Sprintf("%.0f", value)
+	default:
+		return ""
+	}
+}
+
+// lookupBool reads a boolean at path, accepting "true" strings some providers send
+func lookupBool(claims map[string]interface{}, path string) bool {
+	switch value := lookup(claims, path).(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	default:
+		return false
+	}
+}
+
+// randomString returns n random bytes encoded as unpadded base64url
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
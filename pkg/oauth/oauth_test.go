@@ -0,0 +1,109 @@
+package oauth_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/golang-fiber-jwt/pkg/oauth"
+	"github.com/golang-fiber-jwt/pkg/oauth/oauthtest"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test Provider - Full authorization code flow with PKCE against the mock server
+func TestProvider_CodeFlow(t *testing.T) {
+	server := oauthtest.NewServer(map[string]interface{}{
+		"sub":            "42",
+		"email":          "John@Example.com",
+		"email_verified": true,
+		"name":           "John Doe",
+		"picture":        "https://example.com/john.png",
+	})
+	defer server.Close()
+	provider := server.Provider("google")
+
+	verifier, err := oauth.GenerateVerifier()
+	assert.NoError(t, err)
+
+	authURL := provider.AuthCodeURL("state-123", verifier)
+	parsed, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, oauth.Challenge(verifier), parsed.Query().Get("code_challenge"))
+	assert.Equal(t, oauthtest.RedirectURL, parsed.Query().Get("redirect_uri"))
+
+	code, state, err := server.Authorize(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "state-123", state)
+
+	token, err := provider.Exchange(context.Background(), code, verifier)
+	assert.NoError(t, err)
+
+	profile, err := provider.FetchProfile(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, &oauth.Profile{
+		Subject:       "42",
+		Email:         "john@example.com",
+		EmailVerified: true,
+		Name:          "John Doe",
+		Picture:       "https://example.com/john.png",
+	}, profile)
+}
+
+// Test Provider - Wrong verifier and replayed codes are rejected
+func TestProvider_Exchange_Rejected(t *testing.T) {
+	server := oauthtest.NewServer(map[string]interface{}{"sub": "42", "email": "john@example.com"})
+	defer server.Close()
+	provider := server.Provider("google")
+
+	verifier, _ := oauth.GenerateVerifier()
+	code, _, err := server.Authorize(provider.AuthCodeURL("state", verifier))
+	assert.NoError(t, err)
+
+	_, err = provider.Exchange(context.Background(), code, "wrong-verifier")
+	assert.Error(t, err)
+
+	// The failed attempt consumed the code
+	_, err = provider.Exchange(context.Background(), code, verifier)
+	assert.Error(t, err)
+}
+
+// Test Provider - Nested fields and missing email
+func TestProvider_FetchProfile_Mapping(t *testing.T) {
+	server := oauthtest.NewServer(map[string]interface{}{
+		"sub":     "42",
+		"name":    "John Doe",
+		"picture": map[string]interface{}{"data": map[string]interface{}{"url": "https://example.com/fb.png"}},
+	})
+	defer server.Close()
+	provider := server.Provider("facebook")
+	provider.PictureField = "picture.data.url"
+
+	verifier, _ := oauth.GenerateVerifier()
+	code, _, _ := server.Authorize(provider.AuthCodeURL("state", verifier))
+	token, err := provider.Exchange(context.Background(), code, verifier)
+	assert.NoError(t, err)
+
+	_, err = provider.FetchProfile(context.Background(), token)
+	assert.ErrorIs(t, err, oauth.ErrEmailMissing)
+
+	server.Profile["email"] = "john@example.com"
+	profile, err := provider.FetchProfile(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/fb.png", profile.Picture)
+	assert.False(t, profile.EmailVerified)
+}
+
+// Test Registry - Providers without credentials are disabled
+func TestRegistry_Get(t *testing.T) {
+	registry := oauth.NewRegistry(
+		oauth.Google("client", "secret", "http://localhost/cb"),
+		oauth.Facebook("", "", ""),
+	)
+
+	_, ok := registry.Get("Google")
+	assert.True(t, ok)
+	_, ok = registry.Get("facebook")
+	assert.False(t, ok)
+	_, ok = registry.Get("github")
+	assert.False(t, ok)
+}
@@ -0,0 +1,154 @@
+// Package oauthtest provides a local OAuth2 authorization server for tests
+package oauthtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+
+	"github.com/golang-fiber-jwt/pkg/oauth"
+)
+
+// Client credentials the mock server accepts
+const (
+	ClientID     = "test-client"
+	ClientSecret = "test-secret"
+	RedirectURL  = "http://localhost/api/auth/mock/callback"
+)
+
+// Server is a mock authorization server enforcing PKCE (S256)
+type Server struct {
+	*httptest.Server
+
+	// Profile is served by the userinfo endpoint
+	Profile map[string]interface{}
+
+	mu     sync.Mutex
+	codes  map[string]grant
+	tokens map[string]bool
+	next   int
+}
+
+// grant is an issued authorization code awaiting exchange
+type grant struct {
+	challenge   string
+	redirectURI string
+}
+
+// NewServer starts a mock authorization server serving profile
+// Close it when done
+func NewServer(profile map[string]interface{}) *Server {
+	s := &Server{Profile: profile, codes: map[string]grant{}, tokens: map[string]bool{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", s.handleToken)
+	mux.HandleFunc("/userinfo", s.handleUserInfo)
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Provider returns a provider pointing at the mock server, using OIDC style claims
+func (s *Server) Provider(name string) *oauth.Provider {
+	return &oauth.Provider{
+		Name:               name,
+		ClientID:           ClientID,
+		ClientSecret:       ClientSecret,
+		RedirectURL:        RedirectURL,
+		Scopes:             []string{"openid", "email", "profile"},
+		AuthURL:            s.URL + "/authorize",
+		TokenURL:           s.URL + "/token",
+		UserInfoURL:        s.URL + "/userinfo",
+		SubjectField:       "sub",
+		EmailField:         "email",
+		EmailVerifiedField: "email_verified",
+		NameField:          "name",
+		PictureField:       "picture",
+		HTTPClient:         s.Client(),
+	}
+}
+
+// Authorize plays the user granting consent on authURL
+// It returns the code and state the provider would pass to the callback
+func (s *Server) Authorize(authURL string) (code, state string, err error) {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	query := parsed.Query()
+
+	if query.Get("client_id") != ClientID || query.Get("response_type") != "code" {
+		return "", "", fmt.Errorf("oauthtest: invalid authorization request")
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		return "", "", fmt.Errorf("oauthtest: PKCE challenge missing")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next++
+	code = fmt.Sprintf("code-%d", s.next)
+	s.codes[code] = grant{challenge: query.Get("code_challenge"), redirectURI: query.Get("redirect_uri")}
+
+	return code, query.Get("state"), nil
+}
+
+// handleToken exchanges a code once, checking client credentials and the PKCE verifier
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code := r.PostForm.Get("code")
+	g, ok := s.codes[code]
+	delete(s.codes, code)
+
+	switch {
+	case !ok, r.PostForm.Get("grant_type") != "authorization_code":
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	case r.PostForm.Get("client_id") != ClientID, r.PostForm.Get("client_secret") != ClientSecret:
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	case r.PostForm.Get("redirect_uri") != g.redirectURI,
+		oauth.Challenge(r.PostForm.Get("code_verifier")) != g.challenge:
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	s.next++
+	accessToken := fmt.Sprintf("access-%d", s.next)
+	s.tokens[accessToken] = true
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	})
+}
+
+// handleUserInfo returns Profile to holders of an issued access token
+func (s *Server) handleUserInfo(w http.ResponseWriter, r *http.Request) {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+
+	s.mu.Lock()
+	valid := len(header) > len(prefix) && s.tokens[header[len(prefix):]]
+	s.mu.Unlock()
+
+	if !valid {
+		http.Error(w, `{"error":"invalid_token"}`, http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.Profile)
+}
@@ -0,0 +1,130 @@
+package passwordpolicy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/golang-fiber-jwt/pkg/apperror"
+)
+
+// Defaults used when a Policy field is left at its zero value
+const (
+	DefaultMinLength = 8
+
+	// DefaultMaxLength is bcrypt's input limit, longer passwords are silently truncated by it
+	DefaultMaxLength = 72
+)
+
+// Policy describes the rules a new password must satisfy
+type Policy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// Violation is returned when a password does not satisfy the policy
+// Its message is safe to show to the user
+type Violation struct {
+	Reason string
+}
+
+func (v *Violation) Error() string {
+	return v.Reason
+}
+
+// AppError maps the violation to a validation error
+func (v *Violation) AppError() *apperror.Error {
+	return apperror.Validation("password_policy", v.Reason)
+}
+
+// Default returns the policy used when nothing is configured
+func Default() Policy {
+	return Policy{MinLength: DefaultMinLength, MaxLength: DefaultMaxLength}
+}
+
+// Validate checks password against the policy
+// identities (typically the user's email and name) are values the password must not equal
+func (p Policy) Validate(password string, identities ...string) error {
+	p = p.withDefaults()
+
+	if password == "" {
+		return violation("password is required")
+	}
+	if len([]rune(password)) < p.MinLength {
+		return violation("password must be at least %d characters", p.MinLength)
+	}
+	// Measured in bytes because that is what the hasher sees
+	if len(password) > p.MaxLength {
+		return violation("password must be at most %d bytes", p.MaxLength)
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	if p.RequireUpper && !upper {
+		return violation("password must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		return violation("password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		return violation("password must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		return violation("password must contain a special character")
+	}
+
+	for _, identity := range expandIdentities(identities) {
+		if strings.EqualFold(password, identity) {
+			return violation("password must not match your email or name")
+		}
+	}
+
+	return nil
+}
+
+// withDefaults fills zero-valued limits
+func (p Policy) withDefaults() Policy {
+	if p.MinLength <= 0 {
+		p.MinLength = DefaultMinLength
+	}
+	if p.MaxLength <= 0 {
+		p.MaxLength = DefaultMaxLength
+	}
+	return p
+}
+
+// expandIdentities adds the local part of email addresses so "john" is rejected for john@example.com
+func expandIdentities(identities []string) []string {
+	expanded := make([]string, 0, len(identities)*2)
+	for _, identity := range identities {
+		identity = strings.TrimSpace(identity)
+		if identity == "" {
+			continue
+		}
+		expanded = append(expanded, identity)
+		if at := strings.LastIndex(identity, "@"); at > 0 {
+			expanded = append(expanded, identity[:at])
+		}
+	}
+	return expanded
+}
+
+func violation(format string, args ...interface{}) error {
+	return &Violation{Reason: fmt.Sprintf(format, args...)}
+}
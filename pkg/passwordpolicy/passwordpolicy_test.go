@@ -0,0 +1,51 @@
+package passwordpolicy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test Policy - Validate against every rule
+func TestPolicy_Validate(t *testing.T) {
+	strict := Policy{MinLength: 10, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		name          string
+		policy        Policy
+		password      string
+		identities    []string
+		expectedError string
+	}{
+		{name: "Default Accepts", policy: Policy{}, password: "password123"},
+		{name: "Empty", policy: Policy{}, password: "", expectedError: "password is required"},
+		{name: "Too Short", policy: Policy{}, password: "short", expectedError: "password must be at least 8 characters"},
+		{name: "Bcrypt Limit", policy: Policy{}, password: strings.Repeat("a", 73), expectedError: "password must be at most 72 bytes"},
+		{name: "Multibyte Counts Bytes", policy: Policy{}, password: strings.Repeat("é", 37), expectedError: "password must be at most 72 bytes"},
+		{name: "Custom Min Length", policy: strict, password: "Ab1!", expectedError: "password must be at least 10 characters"},
+		{name: "Missing Upper", policy: strict, password: "abcdefgh1!", expectedError: "password must contain an uppercase letter"},
+		{name: "Missing Lower", policy: strict, password: "ABCDEFGH1!", expectedError: "password must contain a lowercase letter"},
+		{name: "Missing Digit", policy: strict, password: "Abcdefghi!", expectedError: "password must contain a digit"},
+		{name: "Missing Symbol", policy: strict, password: "Abcdefghi1", expectedError: "password must contain a special character"},
+		{name: "Strict Accepts", policy: strict, password: "Abcdefgh1!"},
+		{name: "Equals Email", policy: Policy{}, password: "John@Example.com", identities: []string{"john@example.com"}, expectedError: "password must not match your email or name"},
+		{name: "Equals Email Local Part", policy: Policy{}, password: "johnsmith", identities: []string{"johnsmith@example.com"}, expectedError: "password must not match your email or name"},
+		{name: "Equals Name", policy: Policy{}, password: "John Smith", identities: []string{"", "John Smith"}, expectedError: "password must not match your email or name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password, tt.identities...)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var violation *Violation
+			assert.True(t, errors.As(err, &violation))
+			assert.Equal(t, tt.expectedError, err.Error())
+		})
+	}
+}
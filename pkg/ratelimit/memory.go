@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// defaultMemorySize is used when NewMemoryStore is given a non-positive size
+const defaultMemorySize = 100000
+
+// memoryEntry is a single key tracked by MemoryStore
+type memoryEntry struct {
+	key       string
+	state     state
+	expiresAt time.Time
+}
+
+// MemoryStore is an in-memory LRU rate limit store
+// It is bounded by size: once full, the least recently seen key is
+// evicted and starts over with a full limit. State is per process, so each
+// instance enforces its own limit; use the Postgres store to share them.
+type MemoryStore struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// NewMemoryStore creates a new in-memory rate limit store
+func NewMemoryStore(size int) *MemoryStore {
+	if size <= 0 {
+		size = defaultMemorySize
+	}
+	return &MemoryStore{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Allow takes one request for key from limit
+func (s *MemoryStore) Allow(key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	el, ok := s.entries[key]
+	if ok && now.After(el.Value.(*memoryEntry).expiresAt) {
+		s.remove(el)
+		ok = false
+	}
+	if !ok {
+		el = s.order.PushFront(&memoryEntry{key: key})
+		s.entries[key] = el
+
+		for s.order.Len() > s.size {
+			s.remove(s.order.Back())
+		}
+	}
+	s.order.MoveToFront(el)
+
+	entry := el.Value.(*memoryEntry)
+	result, expiresAt := limit.take(&entry.state, now)
+	entry.expiresAt = expiresAt
+	return result, nil
+}
+
+// remove drops an element from both the list and the index
+func (s *MemoryStore) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RateLimitModel represents the database model with GORM tags (infrastructure concern)
+type RateLimitModel struct {
+	Key       string    `gorm:"type:varchar(255);primary_key"`
+	Value     float64   `gorm:"not null;default:0"`
+	Previous  float64   `gorm:"not null;default:0"`
+	Stamp     time.Time `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+}
+
+// TableName specifies the table name for GORM
+func (RateLimitModel) TableName() string {
+	return "rate_limits"
+}
+
+// PostgresStore is a rate limit store shared by every instance through Postgres
+// Each key is updated under a row lock, so instances never both spend the same request
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresStore creates a new Postgres-backed rate limit store
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Allow takes one request for key from limit
+func (s *PostgresStore) Allow(key string, limit Limit) (Result, error) {
+	var result Result
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// Make sure the row exists so it can be locked
+		created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&RateLimitModel{Key: key, ExpiresAt: now})
+		if created.Error != nil {
+			return created.Error
+		}
+
+		var model RateLimitModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&model).Error; err != nil {
+			return err
+		}
+
+		current := state{Value: model.Value, Previous: model.Previous, Stamp: model.Stamp}
+		if created.RowsAffected > 0 || now.After(model.ExpiresAt) {
+			current = state{}
+		}
+
+		var expiresAt time.Time
+		result, expiresAt = limit.take(&current, now)
+
+		if err := tx.Model(&RateLimitModel{}).Where("key = ?", key).Updates(map[string]interface{}{
+			"value":      current.Value,
+			"previous":   current.Previous,
+			"stamp":      current.Stamp,
+			"expires_at": expiresAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Opportunistically purge expired keys whenever a new one shows up
+		if created.RowsAffected > 0 {
+			return tx.Where("expires_at < ?", now).Delete(&RateLimitModel{}).Error
+		}
+		return nil
+	})
+
+	return result, err
+}
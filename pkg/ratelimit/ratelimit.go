@@ -0,0 +1,192 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Algorithms
+const (
+	// TokenBucket allows bursts of up to Requests, refilled evenly over Window
+	TokenBucket = "token_bucket"
+	// SlidingWindow allows Requests per Window, weighting the previous window by its overlap
+	SlidingWindow = "sliding_window"
+)
+
+// Limit is how many requests a key may make per window, and how they are counted
+// The zero Limit is disabled
+type Limit struct {
+	Algorithm string
+	Requests  int
+	Window    time.Duration
+}
+
+// Disabled reports whether the limit lets every request through
+func (l Limit) Disabled() bool {
+	return l.Requests <= 0 || l.Window <= 0
+}
+
+// String returns the limit in the form accepted by ParseLimit
+func (l Limit) String() string {
+	if l.Disabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%s:%d/%s", l.Algorithm, l.Requests, l.Window)
+}
+
+// ParseLimit parses "<algorithm>:<requests>/<window>", e.g. "sliding_window:10/1m"
+// The algorithm defaults to sliding_window, "off" disables the limit
+func ParseLimit(spec string) (Limit, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "off" {
+		return Limit{}, nil
+	}
+
+	algorithm, rate, ok := strings.Cut(spec, ":")
+	if !ok {
+		algorithm, rate = SlidingWindow, spec
+	}
+	if algorithm != TokenBucket && algorithm != SlidingWindow {
+		return Limit{}, fmt.Errorf("ratelimit: unknown algorithm %q", algorithm)
+	}
+
+	requests, window, ok := strings.Cut(rate, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("ratelimit: invalid limit %q, expected <requests>/<window>", spec)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid request count in %q", spec)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("ratelimit: invalid window in %q", spec)
+	}
+
+	return Limit{Algorithm: algorithm, Requests: n, Window: d}, nil
+}
+
+// Result is the outcome of taking a request from a limit
+type Result struct {
+	Allowed bool
+	// Limit is the number of requests allowed per window (the bucket size for TokenBucket)
+	Limit int
+	// Remaining is the number of requests left
+	Remaining int
+	// Reset is the time until the limit is fully available again
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, zero when Allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the state of every limited key
+type Store interface {
+	// Allow takes one request for key from limit
+	Allow(key string, limit Limit) (Result, error)
+}
+
+// Store backends
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+// NewStore creates a rate limit store for the given backend name
+// memorySize is only used by the in-memory backend
+func NewStore(backend string, db *gorm.DB, memorySize int) (Store, error) {
+	switch backend {
+	case "", BackendMemory:
+		return NewMemoryStore(memorySize), nil
+	case BackendPostgres:
+		return NewPostgresStore(db), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", backend)
+	}
+}
+
+// state is what a store keeps per key, interpreted by the algorithm:
+// TokenBucket: Value is the tokens left at Stamp
+// SlidingWindow: Value and Previous are the counts of the window starting at Stamp and the one before
+type state struct {
+	Value    float64
+	Previous float64
+	Stamp    time.Time
+}
+
+// take applies the algorithm of l to s at now and returns the outcome and when s can be forgotten
+func (l Limit) take(s *state, now time.Time) (Result, time.Time) {
+	if l.Algorithm == TokenBucket {
+		return l.takeToken(s, now)
+	}
+	return l.takeSlidingWindow(s, now)
+}
+
+// takeToken refills the bucket for the time elapsed since the last request, then takes a token
+func (l Limit) takeToken(s *state, now time.Time) (Result, time.Time) {
+	capacity := float64(l.Requests)
+	perSecond := capacity / l.Window.Seconds()
+
+	if s.Stamp.IsZero() {
+		s.Value = capacity
+	} else if elapsed := now.Sub(s.Stamp).Seconds(); elapsed > 0 {
+		s.Value = min(capacity, s.Value+elapsed*perSecond)
+	}
+	s.Stamp = now
+
+	result := Result{Limit: l.Requests}
+	if s.Value >= 1 {
+		s.Value--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - s.Value) / perSecond)
+	}
+
+	result.Remaining = int(s.Value)
+	result.Reset = seconds((capacity - s.Value) / perSecond)
+	return result, now.Add(result.Reset)
+}
+
+// takeSlidingWindow estimates the requests of the last Window from the current and previous fixed windows
+func (l Limit) takeSlidingWindow(s *state, now time.Time) (Result, time.Time) {
+	start := now.Truncate(l.Window)
+	if !s.Stamp.Equal(start) {
+		if s.Stamp.Equal(start.Add(-l.Window)) {
+			s.Previous = s.Value
+		} else {
+			s.Previous = 0
+		}
+		s.Value = 0
+		s.Stamp = start
+	}
+
+	end := start.Add(l.Window)
+	overlap := 1 - float64(now.Sub(start))/float64(l.Window)
+	used := s.Previous*overlap + s.Value
+	limit := float64(l.Requests)
+
+	result := Result{Limit: l.Requests, Reset: end.Sub(now)}
+	if used+1 <= limit {
+		s.Value++
+		used++
+		result.Allowed = true
+	} else if s.Previous > 0 && s.Value+1 <= limit {
+		// Wait until enough of the previous window has slid out
+		overlapNeeded := (limit - 1 - s.Value) / s.Previous
+		result.RetryAfter = start.Add(time.Duration((1 - overlapNeeded) * float64(l.Window))).Sub(now)
+	} else {
+		result.RetryAfter = end.Sub(now)
+	}
+
+	result.Remaining = max(0, int(limit-used))
+	// The current window is still needed as the previous one during the next window
+	return result, end.Add(l.Window)
+}
+
+// seconds converts a number of seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
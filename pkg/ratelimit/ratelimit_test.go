@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test ParseLimit - Valid and invalid specs
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected Limit
+		wantErr  bool
+	}{
+		{spec: "token_bucket:100/1m", expected: Limit{Algorithm: TokenBucket, Requests: 100, Window: time.Minute}},
+		{spec: "sliding_window:5/15m", expected: Limit{Algorithm: SlidingWindow, Requests: 5, Window: 15 * time.Minute}},
+		{spec: "10/1s", expected: Limit{Algorithm: SlidingWindow, Requests: 10, Window: time.Second}},
+		{spec: "off", expected: Limit{}},
+		{spec: "leaky_bucket:10/1m", wantErr: true},
+		{spec: "token_bucket:10", wantErr: true},
+		{spec: "token_bucket:0/1m", wantErr: true},
+		{spec: "token_bucket:10/soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			limit, err := ParseLimit(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, limit)
+		})
+	}
+}
+
+// Test TokenBucket - Bursts up to the bucket size, then refills evenly
+func TestLimit_TokenBucket(t *testing.T) {
+	limit := Limit{Algorithm: TokenBucket, Requests: 3, Window: 3 * time.Second}
+	now := time.Now()
+	s := &state{}
+
+	for i := 2; i >= 0; i-- {
+		result, _ := limit.take(s, now)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, _ := limit.take(s, now)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.Reset)
+
+	// One token per second
+	result, _ = limit.take(s, now.Add(time.Second))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+}
+
+// Test SlidingWindow - The previous window counts by its remaining overlap
+func TestLimit_SlidingWindow(t *testing.T) {
+	limit := Limit{Algorithm: SlidingWindow, Requests: 4, Window: time.Minute}
+	start := time.Now().Truncate(time.Minute)
+	s := &state{}
+
+	for i := 0; i < 4; i++ {
+		result, _ := limit.take(s, start.Add(time.Second))
+		assert.True(t, result.Allowed)
+	}
+
+	result, _ := limit.take(s, start.Add(time.Second))
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 59*time.Second, result.RetryAfter)
+	assert.Equal(t, 0, result.Remaining)
+
+	// Half way through the next window half of the previous one still counts: 2 requests left
+	middle := start.Add(90 * time.Second)
+	for i := 0; i < 2; i++ {
+		result, _ = limit.take(s, middle)
+		assert.True(t, result.Allowed)
+	}
+	result, _ = limit.take(s, middle)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 15*time.Second, result.RetryAfter) // Once a quarter of the previous window remains
+
+	// Windows further back are forgotten
+	result, _ = limit.take(s, start.Add(5*time.Minute))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 3, result.Remaining)
+}
+
+// Test MemoryStore - Keys are limited independently
+func TestMemoryStore_Allow(t *testing.T) {
+	store := NewMemoryStore(0)
+	limit := Limit{Algorithm: TokenBucket, Requests: 1, Window: time.Hour}
+
+	result, err := store.Allow("a", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = store.Allow("a", limit)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+
+	result, err = store.Allow("b", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
@@ -0,0 +1,154 @@
+package rbac
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Permission is an action on a resource in "resource:action" form (e.g. users:read)
+type Permission string
+
+// Permissions known by the application
+const (
+	UsersRead    Permission = "users:read"
+	UsersCreate  Permission = "users:create"
+	UsersUpdate  Permission = "users:update"
+	UsersDelete  Permission = "users:delete"
+	UsersRestore Permission = "users:restore"
+	UsersStats   Permission = "users:stats"
+	UsersUnlock  Permission = "users:unlock"
+
+	SessionsRead   Permission = "sessions:read"
+	SessionsRevoke Permission = "sessions:revoke"
+)
+
+// Built-in roles (mirrors the values accepted for user.User.Role)
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// wildcard grants every permission, "<resource>:*" grants every action on a resource
+const wildcard = "*"
+
+// Policy maps roles to the permissions they are granted
+type Policy struct {
+	roles map[string]map[Permission]struct{}
+}
+
+// policyFile is the on-disk shape of a policy file
+type policyFile struct {
+	Roles map[string][]string `mapstructure:"roles"`
+}
+
+// DefaultPolicy returns the policy used when no policy file is configured
+func DefaultPolicy() *Policy {
+	policy, _ := NewPolicy(map[string][]string{
+		RoleAdmin: {wildcard},
+		RoleUser:  {string(UsersRead)},
+	})
+	return policy
+}
+
+// NewPolicy builds a policy from a role -> permissions mapping
+func NewPolicy(mapping map[string][]string) (*Policy, error) {
+	policy := &Policy{roles: make(map[string]map[Permission]struct{}, len(mapping))}
+
+	for role, perms := range mapping {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if role == "" {
+			return nil, fmt.Errorf("rbac: role name is required")
+		}
+
+		granted := make(map[Permission]struct{}, len(perms))
+		for _, perm := range perms {
+			perm = strings.TrimSpace(perm)
+			if perm != wildcard && !strings.Contains(perm, ":") {
+				return nil, fmt.Errorf("rbac: invalid permission %q for role %q", perm, role)
+			}
+			granted[Permission(perm)] = struct{}{}
+		}
+		policy.roles[role] = granted
+	}
+
+	return policy, nil
+}
+
+// LoadPolicy reads a policy from a YAML/JSON/TOML file, e.g.
+//
+//	roles:
+//	  admin: ["*"]
+//	  user: ["users:read"]
+func LoadPolicy(path string) (*Policy, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("rbac: failed to read policy: %w", err)
+	}
+
+	var file policyFile
+	if err := v.Unmarshal(&file); err != nil {
+		return nil, fmt.Errorf("rbac: failed to parse policy: %w", err)
+	}
+
+	if len(file.Roles) == 0 {
+		return nil, fmt.Errorf("rbac: policy %s defines no roles", path)
+	}
+
+	return NewPolicy(file.Roles)
+}
+
+// Allows reports whether the role is granted every one of the permissions
+func (p *Policy) Allows(role string, perms ...Permission) bool {
+	granted, ok := p.roles[strings.ToLower(role)]
+	if !ok {
+		return false
+	}
+
+	for _, perm := range perms {
+		if !grants(granted, perm) {
+			return false
+		}
+	}
+	return true
+}
+
+// grants checks a single permission against exact, resource and global wildcards
+func grants(granted map[Permission]struct{}, perm Permission) bool {
+	if _, ok := granted[wildcard]; ok {
+		return true
+	}
+
+	if _, ok := granted[perm]; ok {
+		return true
+	}
+
+	resource, _, _ := strings.Cut(string(perm), ":")
+	_, ok := granted[Permission(resource+":"+wildcard)]
+	return ok
+}
+
+// ScopesAllow reports whether scopes (e.g. of an API key) grant every one of the permissions
+// Scopes use the same syntax as policy permissions, including wildcards
+func ScopesAllow(scopes []string, perms ...Permission) bool {
+	granted := make(map[Permission]struct{}, len(scopes))
+	for _, scope := range scopes {
+		granted[Permission(scope)] = struct{}{}
+	}
+
+	for _, perm := range perms {
+		if !grants(granted, perm) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidPermission reports whether perm is "*" or in "resource:action" form
+func ValidPermission(perm string) bool {
+	resource, action, ok := strings.Cut(perm, ":")
+	return perm == wildcard || (ok && resource != "" && action != "")
+}
@@ -0,0 +1,74 @@
+package rbac
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test Policy - Allows with exact, resource and global wildcards
+func TestPolicy_Allows(t *testing.T) {
+	policy, err := NewPolicy(map[string][]string{
+		"admin":     {"*"},
+		"moderator": {"users:*"},
+		"user":      {"users:read"},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		role     string
+		perms    []Permission
+		expected bool
+	}{
+		{name: "Admin Wildcard", role: "admin", perms: []Permission{UsersDelete}, expected: true},
+		{name: "Resource Wildcard", role: "moderator", perms: []Permission{UsersRead, UsersRestore}, expected: true},
+		{name: "Exact Match", role: "user", perms: []Permission{UsersRead}, expected: true},
+		{name: "Missing Permission", role: "user", perms: []Permission{UsersDelete}, expected: false},
+		{name: "All Permissions Required", role: "user", perms: []Permission{UsersRead, UsersUpdate}, expected: false},
+		{name: "Unknown Role", role: "guest", perms: []Permission{UsersRead}, expected: false},
+		{name: "Role Is Case Insensitive", role: "Admin", perms: []Permission{UsersCreate}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, policy.Allows(tt.role, tt.perms...))
+		})
+	}
+}
+
+// Test NewPolicy - Invalid permission
+func TestNewPolicy_InvalidPermission(t *testing.T) {
+	_, err := NewPolicy(map[string][]string{"user": {"read"}})
+	assert.Error(t, err)
+}
+
+// Test LoadPolicy - YAML file
+func TestLoadPolicy_YAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rbac.yaml")
+	content := "roles:\n  admin:\n    - \"*\"\n  support:\n    - users:read\n    - users:restore\n"
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	policy, err := LoadPolicy(path)
+
+	assert.NoError(t, err)
+	assert.True(t, policy.Allows("support", UsersRestore))
+	assert.False(t, policy.Allows("support", UsersDelete))
+	assert.True(t, policy.Allows("admin", UsersDelete))
+}
+
+// Test ScopesAllow - Scopes narrow access with the same wildcard rules
+func TestScopesAllow(t *testing.T) {
+	assert.True(t, ScopesAllow([]string{"users:read"}, UsersRead))
+	assert.False(t, ScopesAllow([]string{"users:read"}, UsersRead, UsersDelete))
+	assert.True(t, ScopesAllow([]string{"users:*"}, UsersDelete))
+	assert.True(t, ScopesAllow([]string{"*"}, UsersStats))
+	assert.False(t, ScopesAllow(nil, UsersRead))
+
+	assert.True(t, ValidPermission("users:read"))
+	assert.True(t, ValidPermission("*"))
+	assert.False(t, ValidPermission("read"))
+	assert.False(t, ValidPermission("users:"))
+}
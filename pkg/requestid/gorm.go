@@ -0,0 +1,52 @@
+package requestid
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm/logger"
+)
+
+// gormLogger tags GORM logs with the request ID of the query's context
+type gormLogger struct {
+	logger.Interface
+}
+
+// GormLogger wraps a GORM logger so every line of a query run with db.WithContext(ctx)
+// carries the request ID of ctx
+func GormLogger(l logger.Interface) logger.Interface {
+	return gormLogger{Interface: l}
+}
+
+// LogMode sets the log level of the wrapped logger
+func (l gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return gormLogger{Interface: l.Interface.LogMode(level)}
+}
+
+// Info logs at info level
+func (l gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.Interface.Info(ctx, prefix(ctx)+msg, args...)
+}
+
+// Warn logs at warn level
+func (l gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.Interface.Warn(ctx, prefix(ctx)+msg, args...)
+}
+
+// Error logs at error level
+func (l gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.Interface.Error(ctx, prefix(ctx)+msg, args...)
+}
+
+// Trace logs a query, the request ID goes in front of the SQL
+func (l gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	p := prefix(ctx)
+	if p == "" {
+		l.Interface.Trace(ctx, begin, fc, err)
+		return
+	}
+	l.Interface.Trace(ctx, begin, func() (string, int64) {
+		sql, rows := fc()
+		return p + sql, rows
+	}, err)
+}
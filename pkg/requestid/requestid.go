@@ -0,0 +1,66 @@
+package requestid
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang-fiber-jwt/pkg/loglevel"
+	"github.com/google/uuid"
+)
+
+// Header is the header a request ID is accepted from and returned in
+const Header = "X-Request-ID"
+
+// LocalsKey is the c.Locals key of the request ID
+const LocalsKey = "requestid"
+
+// maxLength bounds accepted request IDs, longer ones are replaced
+const maxLength = 128
+
+type contextKey struct{}
+
+// New generates a request ID
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid reports whether a client supplied request ID can be used as is
+// Only short, printable ASCII IDs are accepted so they cannot forge log lines
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext returns a copy of ctx carrying the request ID
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID of ctx, empty when there is none
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Printf logs like loglevel.Printf, prefixed with the request ID of ctx
+func Printf(ctx context.Context, level loglevel.Level, format string, args ...interface{}) {
+	loglevel.Print(level, prefix(ctx)+fmt.Sprintf(format, args...))
+}
+
+// prefix is the log prefix of ctx's request ID, empty outside requests
+func prefix(ctx context.Context) string {
+	if id := FromContext(ctx); id != "" {
+		return "request_id=" + id + " "
+	}
+	return ""
+}
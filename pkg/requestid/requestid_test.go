@@ -0,0 +1,49 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm/logger"
+)
+
+// Test Valid - Client IDs must be short printable ASCII
+func TestValid(t *testing.T) {
+	assert.True(t, Valid("3f2b6c1e-trace-42"))
+	assert.False(t, Valid(""))
+	assert.False(t, Valid("id with spaces"))
+	assert.False(t, Valid("forged\nrequest_id=other"))
+	assert.False(t, Valid(strings.Repeat("a", maxLength+1)))
+	assert.True(t, Valid(New()))
+}
+
+// Test FromContext - The ID travels with the context
+func TestFromContext(t *testing.T) {
+	ctx := NewContext(context.Background(), "abc")
+	assert.Equal(t, "abc", FromContext(ctx))
+	assert.Equal(t, "", FromContext(context.Background()))
+}
+
+type traceRecorder struct {
+	logger.Interface
+	sql string
+}
+
+func (r *traceRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	r.sql, _ = fc()
+}
+
+// Test GormLogger - Queries are logged with the request ID of their context
+func TestGormLogger_Trace(t *testing.T) {
+	recorder := &traceRecorder{Interface: logger.Discard}
+	l := GormLogger(recorder)
+
+	l.Trace(NewContext(context.Background(), "abc"), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	assert.Equal(t, "request_id=abc SELECT 1", recorder.sql)
+
+	l.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	assert.Equal(t, "SELECT 1", recorder.sql)
+}
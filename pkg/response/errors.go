@@ -0,0 +1,148 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-fiber-jwt/pkg/apperror"
+	"github.com/golang-fiber-jwt/pkg/loglevel"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// ErrorConfig configures ErrorHandler
+type ErrorConfig struct {
+	// ProblemDetails renders every error as problem details
+	// Otherwise only clients that ask for application/problem+json get them
+	ProblemDetails bool
+	// ProblemTypeBaseURL is joined with the error code to build the problem type URI
+	// Types are "about:blank" when empty
+	ProblemTypeBaseURL string
+	// HideInternalErrors replaces the message of unexpected errors with a generic one (production)
+	// Otherwise the error text is returned to ease debugging
+	HideInternalErrors bool
+}
+
+// Problem is an RFC 7807 problem details object, extended with the error code
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code,omitempty"`
+	// Errors lists the failed fields of validation errors
+	Errors interface{} `json:"errors,omitempty"`
+	// RequestID correlates the problem with the server logs
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ErrorHandler returns the app's error handler, the single place errors returned by
+// handlers and middleware become HTTP responses
+// *apperror.Error (and apperror.Coder) values map to the status of their kind,
+// *fiber.Error values (405, 413, ...) keep their status and anything else is an internal error
+func ErrorHandler(cfg ErrorConfig) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		status, code, message := fiber.StatusInternalServerError, "", "Internal server error"
+		var details interface{}
+
+		var fiberErr *fiber.Error
+		if appErr, ok := apperror.As(err); ok {
+			status, code, message, details = StatusOf(appErr.Kind), appErr.Code, appErr.Message, appErr.Details
+			if appErr.RetryAfter > 0 {
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+			}
+		} else if errors.As(err, &fiberErr) {
+			status, message = fiberErr.Code, fiberErr.Message
+		} else if errors.Is(err, context.DeadlineExceeded) {
+			// The request deadline expired while the database was still working
+			status, code, message = fiber.StatusGatewayTimeout, "timeout", "The request took too long to complete"
+		} else if !cfg.HideInternalErrors {
+			message = err.Error()
+		}
+
+		if status >= fiber.StatusInternalServerError {
+			loglevel.Printf(loglevel.Error, "error: request_id=%s %s %s: %v", RequestID(c), c.Method(), c.OriginalURL(), err)
+		}
+		if code == "" {
+			code = statusCode(status)
+		}
+
+		if cfg.ProblemDetails || c.Accepts(fiber.MIMEApplicationJSON, ProblemContentType) == ProblemContentType {
+			return sendProblem(c, Problem{
+				Type:      problemType(cfg, code),
+				Title:     http.StatusText(status),
+				Status:    status,
+				Detail:    message,
+				Instance:  c.OriginalURL(),
+				Code:      code,
+				Errors:    details,
+				RequestID: RequestID(c),
+			})
+		}
+
+		envelope := "fail"
+		if status >= fiber.StatusInternalServerError {
+			envelope = "error"
+		}
+		return send(c, status, APIResponse{
+			Status:  envelope,
+			Code:    code,
+			Message: message,
+			Errors:  details,
+		})
+	}
+}
+
+// StatusOf returns the HTTP status of an error kind
+func StatusOf(kind apperror.Kind) int {
+	switch kind {
+	case apperror.KindValidation:
+		return fiber.StatusBadRequest
+	case apperror.KindUnauthorized:
+		return fiber.StatusUnauthorized
+	case apperror.KindForbidden:
+		return fiber.StatusForbidden
+	case apperror.KindNotFound:
+		return fiber.StatusNotFound
+	case apperror.KindConflict:
+		return fiber.StatusConflict
+	case apperror.KindTooManyRequests:
+		return fiber.StatusTooManyRequests
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// sendProblem writes p as application/problem+json
+func sendProblem(c *fiber.Ctx, p Problem) error {
+	body, err := c.App().Config().JSONEncoder(p)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, ProblemContentType)
+	return c.Status(p.Status).Send(body)
+}
+
+// problemType builds the problem type URI of an error code
+func problemType(cfg ErrorConfig, code string) string {
+	if cfg.ProblemTypeBaseURL == "" {
+		return "about:blank"
+	}
+	return strings.TrimSuffix(cfg.ProblemTypeBaseURL, "/") + "/" + strings.ReplaceAll(code, "_", "-")
+}
+
+// statusCode derives an error code from a status for errors that have none (e.g. "method_not_allowed")
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
@@ -0,0 +1,102 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-fiber-jwt/pkg/apperror"
+	"github.com/golang-fiber-jwt/pkg/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newErrorApp(cfg ErrorConfig, err error) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(cfg)})
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return err
+	})
+	return app
+}
+
+// Test ErrorHandler - Typed errors map to their status and code in the envelope
+func TestErrorHandler_Envelope(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      ErrorConfig
+		err      error
+		status   int
+		expected APIResponse
+	}{
+		{name: "Not Found", err: apperror.NotFound("user_not_found", "user not found"), status: 404, expected: APIResponse{Status: "fail", Code: "user_not_found", Message: "user not found"}},
+		{name: "Validation", err: apperror.Validation("name_required", "name is required"), status: 400, expected: APIResponse{Status: "fail", Code: "name_required", Message: "name is required"}},
+		{name: "Fiber Error", err: fiber.ErrMethodNotAllowed, status: 405, expected: APIResponse{Status: "fail", Code: "method_not_allowed", Message: "Method Not Allowed"}},
+		{name: "Untyped Error", cfg: ErrorConfig{HideInternalErrors: true}, err: errors.New("pq: connection refused"), status: 500, expected: APIResponse{Status: "error", Code: "internal_server_error", Message: "Internal server error"}},
+		{name: "Deadline Exceeded", cfg: ErrorConfig{HideInternalErrors: true}, err: fmt.Errorf("failed to load user: %w", context.DeadlineExceeded), status: 504, expected: APIResponse{Status: "error", Code: "timeout", Message: "The request took too long to complete"}},
+		{name: "Untyped Error In Development", err: errors.New("pq: connection refused"), status: 500, expected: APIResponse{Status: "error", Code: "internal_server_error", Message: "pq: connection refused"}},
+		{name: "Validation Details", err: &apperror.Error{Kind: apperror.KindValidation, Code: "validation_failed", Message: "Validation failed", Details: []string{"Email"}}, status: 400, expected: APIResponse{Status: "fail", Code: "validation_failed", Message: "Validation failed", Errors: []interface{}{"Email"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := newErrorApp(tt.cfg, tt.err).Test(httptest.NewRequest("GET", "/fail", nil))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+
+			var body APIResponse
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, tt.expected, body)
+		})
+	}
+}
+
+// Test ErrorHandler - Problem details when the client asks for them, with Retry-After
+func TestErrorHandler_ProblemDetails(t *testing.T) {
+	app := newErrorApp(ErrorConfig{ProblemTypeBaseURL: "https://errors.example.com/"}, apperror.TooManyRequests("too_many_attempts", "try again later", 1500*time.Millisecond))
+
+	req := httptest.NewRequest("GET", "/fail?x=1", nil)
+	req.Header.Set("Accept", ProblemContentType)
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 429, resp.StatusCode)
+	assert.Equal(t, ProblemContentType, resp.Header.Get("Content-Type"))
+	assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+
+	raw, _ := io.ReadAll(resp.Body)
+	var problem Problem
+	assert.NoError(t, json.Unmarshal(raw, &problem))
+	assert.Equal(t, Problem{
+		Type:     "https://errors.example.com/too-many-attempts",
+		Title:    "Too Many Requests",
+		Status:   429,
+		Detail:   "try again later",
+		Instance: "/fail?x=1",
+		Code:     "too_many_attempts",
+	}, problem)
+
+	// Plain JSON clients keep the envelope unless problem details are forced
+	resp, err = newErrorApp(ErrorConfig{ProblemDetails: true}, apperror.NotFound("user_not_found", "user not found")).Test(httptest.NewRequest("GET", "/fail", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, ProblemContentType, resp.Header.Get("Content-Type"))
+}
+
+// Test ErrorHandler - Responses carry the request ID for log correlation
+func TestErrorHandler_RequestID(t *testing.T) {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler(ErrorConfig{})})
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		c.Locals(requestid.LocalsKey, "abc")
+		return apperror.NotFound("user_not_found", "user not found")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/fail", nil))
+	assert.NoError(t, err)
+
+	var body APIResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "abc", body.RequestID)
+}
@@ -2,19 +2,30 @@ package response
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-fiber-jwt/pkg/requestid"
 )
 
 // APIResponse represents standard API response wrapper
 type APIResponse struct {
-	Status  string      `json:"status"`
+	Status string `json:"status"`
+	// Code is the machine-readable error code of failed requests
+	Code    string      `json:"code,omitempty"`
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
 	Errors  interface{} `json:"errors,omitempty"`
+	// RequestID correlates the response with the server logs
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// send writes body with the ID of the current request
+func send(c *fiber.Ctx, statusCode int, body APIResponse) error {
+	body.RequestID = RequestID(c)
+	return c.Status(statusCode).JSON(body)
 }
 
 // Success sends a successful response with optional data
 func Success(c *fiber.Ctx, statusCode int, data interface{}) error {
-	return c.Status(statusCode).JSON(APIResponse{
+	return send(c, statusCode, APIResponse{
 		Status: "success",
 		Data:   data,
 	})
@@ -22,7 +33,7 @@ func Success(c *fiber.Ctx, statusCode int, data interface{}) error {
 
 // SuccessWithMessage sends a successful response with a message
 func SuccessWithMessage(c *fiber.Ctx, statusCode int, message string) error {
-	return c.Status(statusCode).JSON(APIResponse{
+	return send(c, statusCode, APIResponse{
 		Status:  "success",
 		Message: message,
 	})
@@ -30,7 +41,7 @@ func SuccessWithMessage(c *fiber.Ctx, statusCode int, message string) error {
 
 // Error sends an error response with a message
 func Error(c *fiber.Ctx, statusCode int, message string) error {
-	return c.Status(statusCode).JSON(APIResponse{
+	return send(c, statusCode, APIResponse{
 		Status:  "fail",
 		Message: message,
 	})
@@ -38,7 +49,7 @@ func Error(c *fiber.Ctx, statusCode int, message string) error {
 
 // ValidationError sends a validation error response with field errors
 func ValidationError(c *fiber.Ctx, errors interface{}) error {
-	return c.Status(fiber.StatusBadRequest).JSON(APIResponse{
+	return send(c, fiber.StatusBadRequest, APIResponse{
 		Status: "fail",
 		Errors: errors,
 	})
@@ -46,7 +57,7 @@ func ValidationError(c *fiber.Ctx, errors interface{}) error {
 
 // InternalError sends an internal server error response
 func InternalError(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusInternalServerError).JSON(APIResponse{
+	return send(c, fiber.StatusInternalServerError, APIResponse{
 		Status:  "error",
 		Message: message,
 	})
@@ -57,7 +68,7 @@ func Unauthorized(c *fiber.Ctx, message string) error {
 	if message == "" {
 		message = "Unauthorized"
 	}
-	return c.Status(fiber.StatusUnauthorized).JSON(APIResponse{
+	return send(c, fiber.StatusUnauthorized, APIResponse{
 		Status:  "fail",
 		Message: message,
 	})
@@ -68,7 +79,7 @@ func NotFound(c *fiber.Ctx, message string) error {
 	if message == "" {
 		message = "Resource not found"
 	}
-	return c.Status(fiber.StatusNotFound).JSON(APIResponse{
+	return send(c, fiber.StatusNotFound, APIResponse{
 		Status:  "fail",
 		Message: message,
 	})
@@ -76,7 +87,7 @@ func NotFound(c *fiber.Ctx, message string) error {
 
 // BadRequest sends a bad request response
 func BadRequest(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusBadRequest).JSON(APIResponse{
+	return send(c, fiber.StatusBadRequest, APIResponse{
 		Status:  "fail",
 		Message: message,
 	})
@@ -84,7 +95,7 @@ func BadRequest(c *fiber.Ctx, message string) error {
 
 // Conflict sends a conflict response (e.g., duplicate entries)
 func Conflict(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusConflict).JSON(APIResponse{
+	return send(c, fiber.StatusConflict, APIResponse{
 		Status:  "fail",
 		Message: message,
 	})
@@ -92,7 +103,7 @@ func Conflict(c *fiber.Ctx, message string) error {
 
 // Created sends a created response with the created resource
 func Created(c *fiber.Ctx, data interface{}) error {
-	return c.Status(fiber.StatusCreated).JSON(APIResponse{
+	return send(c, fiber.StatusCreated, APIResponse{
 		Status: "success",
 		Data:   data,
 	})
@@ -100,7 +111,7 @@ func Created(c *fiber.Ctx, data interface{}) error {
 
 // OK sends an OK response with data
 func OK(c *fiber.Ctx, data interface{}) error {
-	return c.Status(fiber.StatusOK).JSON(APIResponse{
+	return send(c, fiber.StatusOK, APIResponse{
 		Status: "success",
 		Data:   data,
 	})
@@ -116,8 +127,14 @@ func Forbidden(c *fiber.Ctx, message string) error {
 	if message == "" {
 		message = "Forbidden"
 	}
-	return c.Status(fiber.StatusForbidden).JSON(APIResponse{
+	return send(c, fiber.StatusForbidden, APIResponse{
 		Status:  "fail",
 		Message: message,
 	})
 }
+
+// RequestID returns the ID of the current request, empty when none was assigned
+func RequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(requestid.LocalsKey).(string)
+	return id
+}
@@ -0,0 +1,84 @@
+package revocation
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// defaultMemorySize is used when NewMemoryStore is given a non-positive size
+const defaultMemorySize = 10000
+
+// memoryEntry is a single revoked token tracked by MemoryStore
+type memoryEntry struct {
+	jti       string
+	expiresAt time.Time
+}
+
+// MemoryStore is an in-memory LRU revocation store
+// It is bounded by size: once full, the least recently touched entry is
+// evicted, so pick a size larger than the number of tokens revoked within
+// one access-token lifetime. State is per process and lost on restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// NewMemoryStore creates a new in-memory revocation store
+func NewMemoryStore(size int) *MemoryStore {
+	if size <= 0 {
+		size = defaultMemorySize
+	}
+	return &MemoryStore{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Revoke marks a token ID as revoked until expiresAt
+func (s *MemoryStore) Revoke(jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[jti]; ok {
+		el.Value.(*memoryEntry).expiresAt = expiresAt
+		s.order.MoveToFront(el)
+		return nil
+	}
+
+	s.entries[jti] = s.order.PushFront(&memoryEntry{jti: jti, expiresAt: expiresAt})
+
+	for s.order.Len() > s.size {
+		s.remove(s.order.Back())
+	}
+	return nil
+}
+
+// IsRevoked reports whether a token ID has been revoked
+func (s *MemoryStore) IsRevoked(jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[jti]
+	if !ok {
+		return false, nil
+	}
+
+	// The token has expired on its own, no need to remember it any longer
+	if time.Now().After(el.Value.(*memoryEntry).expiresAt) {
+		s.remove(el)
+		return false, nil
+	}
+
+	s.order.MoveToFront(el)
+	return true, nil
+}
+
+// remove drops an element from both the list and the index
+func (s *MemoryStore) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*memoryEntry).jti)
+}
@@ -0,0 +1,59 @@
+package revocation
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokedTokenModel represents the database model with GORM tags (infrastructure concern)
+type RevokedTokenModel struct {
+	JTI       string    `gorm:"column:jti;type:varchar(64);primary_key"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	RevokedAt time.Time `gorm:"not null;default:now()"`
+}
+
+// TableName specifies the table name for GORM
+func (RevokedTokenModel) TableName() string {
+	return "revoked_tokens"
+}
+
+// PostgresStore is a revocation store shared by every instance through Postgres
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresStore creates a new Postgres-backed revocation store
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Revoke marks a token ID as revoked until expiresAt
+func (s *PostgresStore) Revoke(jti string, expiresAt time.Time) error {
+	model := RevokedTokenModel{
+		JTI:       jti,
+		ExpiresAt: expiresAt,
+		RevokedAt: time.Now(),
+	}
+
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// Opportunistically purge entries for tokens that have expired anyway
+	return s.db.Where("expires_at < ?", time.Now()).Delete(&RevokedTokenModel{}).Error
+}
+
+// IsRevoked reports whether a token ID has been revoked
+func (s *PostgresStore) IsRevoked(jti string) (bool, error) {
+	var count int64
+	result := s.db.Model(&RevokedTokenModel{}).
+		Where("jti = ? AND expires_at > ?", jti, time.Now()).
+		Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,40 @@
+package revocation
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Store keeps track of revoked access tokens by their jti claim
+// Entries only need to live until the token itself expires
+type Store interface {
+	// Revoke marks a token ID as revoked until expiresAt
+	Revoke(jti string, expiresAt time.Time) error
+
+	// IsRevoked reports whether a token ID has been revoked
+	IsRevoked(jti string) (bool, error)
+}
+
+// MaxIDLength is the longest token ID a store accepts (revoked_tokens.jti is a VARCHAR(64))
+const MaxIDLength = 64
+
+// Store backends
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+// NewStore creates a revocation store for the given backend name
+// memorySize is only used by the in-memory backend
+func NewStore(backend string, db *gorm.DB, memorySize int) (Store, error) {
+	switch backend {
+	case "", BackendMemory:
+		return NewMemoryStore(memorySize), nil
+	case BackendPostgres:
+		return NewPostgresStore(db), nil
+	default:
+		return nil, fmt.Errorf("unknown token revocation store %q", backend)
+	}
+}
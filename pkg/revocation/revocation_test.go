@@ -0,0 +1,17 @@
+package revocation
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test MaxIDLength - Matches the width of the revoked_tokens.jti column
+func TestMaxIDLength(t *testing.T) {
+	migration, err := os.ReadFile("../../migrations/000003_create_revoked_tokens_table.up.sql")
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(migration), fmt.Sprintf("jti VARCHAR(%d)", MaxIDLength)))
+}
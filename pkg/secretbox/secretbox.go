@@ -0,0 +1,79 @@
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the AES-256 key length in bytes
+const KeySize = 32
+
+// ErrDecrypt is returned for ciphertexts that are malformed or were not sealed with this key
+var ErrDecrypt = errors.New("secretbox: decryption failed")
+
+// Box encrypts small secrets (e.g. MFA seeds) for storage with AES-256-GCM
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New creates a box from a 32 byte key
+func New(key []byte) (*Box, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("secretbox: key must be %d bytes, got %d", KeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Box{aead: aead}, nil
+}
+
+// NewFromString creates a box from a base64 encoded 32 byte key
+// Any other non-empty value is stretched with SHA-256, which is only as strong as the passphrase
+func NewFromString(key string) (*Box, error) {
+	if key == "" {
+		return nil, errors.New("secretbox: key is required")
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(key); err == nil && len(decoded) == KeySize {
+		return New(decoded)
+	}
+	sum := sha256.Sum256([]byte(key))
+	return New(sum[:])
+}
+
+// Seal encrypts plaintext and returns base64(nonce || ciphertext)
+func (b *Box) Seal(plaintext []byte) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := b.aead.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value produced by Seal
+func (b *Box) Open(sealed string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < b.aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	nonce, ciphertext := raw[:b.aead.NonceSize()], raw[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
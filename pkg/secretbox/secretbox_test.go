@@ -0,0 +1,59 @@
+package secretbox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test Box - Seal and open round trip, tampering and wrong keys
+func TestBox_SealOpen(t *testing.T) {
+	box, err := New(bytes.Repeat([]byte{1}, KeySize))
+	assert.NoError(t, err)
+
+	sealed, err := box.Seal([]byte("JBSWY3DPEHPK3PXP"))
+	assert.NoError(t, err)
+	assert.NotContains(t, sealed, "JBSWY3DPEHPK3PXP")
+
+	plaintext, err := box.Open(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", string(plaintext))
+
+	// Nonces are random, so sealing twice differs
+	again, _ := box.Seal([]byte("JBSWY3DPEHPK3PXP"))
+	assert.NotEqual(t, sealed, again)
+
+	raw, _ := base64.StdEncoding.DecodeString(sealed)
+	raw[len(raw)-1] ^= 0xff
+	_, err = box.Open(base64.StdEncoding.EncodeToString(raw))
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	other, _ := New(bytes.Repeat([]byte{2}, KeySize))
+	_, err = other.Open(sealed)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = box.Open("not base64")
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+// Test NewFromString - base64 keys are used as is, passphrases are stretched
+func TestNewFromString(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, KeySize)
+	fromBase64, err := NewFromString(base64.StdEncoding.EncodeToString(key))
+	assert.NoError(t, err)
+	direct, _ := New(key)
+
+	sealed, _ := direct.Seal([]byte("secret"))
+	plaintext, err := fromBase64.Open(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	_, err = NewFromString("a passphrase")
+	assert.NoError(t, err)
+	_, err = NewFromString("")
+	assert.Error(t, err)
+	_, err = New([]byte("short"))
+	assert.Error(t, err)
+}
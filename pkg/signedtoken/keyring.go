@@ -0,0 +1,78 @@
+package signedtoken
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Keyring signs tokens with its current secret and verifies them with the current
+// secret or, until their grace window ends, the secrets it replaced
+// Rotating the secret therefore does not invalidate tokens already handed out
+type Keyring struct {
+	mu       sync.RWMutex
+	current  []byte
+	previous []retiredSecret
+	now      func() time.Time
+}
+
+// retiredSecret is a replaced secret that still verifies until the given time
+type retiredSecret struct {
+	secret []byte
+	until  time.Time
+}
+
+// NewKeyring creates a keyring signing with secret
+func NewKeyring(secret []byte) *Keyring {
+	return &Keyring{current: secret, now: time.Now}
+}
+
+// Rotate makes secret the signing secret, the replaced one keeps verifying for grace
+// Rotating to the current secret does nothing
+func (k *Keyring) Rotate(secret []byte, grace time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if bytes.Equal(secret, k.current) {
+		return
+	}
+
+	now := k.now()
+	previous := []retiredSecret{{secret: k.current, until: now.Add(grace)}}
+	for _, r := range k.previous {
+		if now.Before(r.until) && !bytes.Equal(r.secret, secret) {
+			previous = append(previous, r)
+		}
+	}
+	k.current, k.previous = secret, previous
+}
+
+// Sign creates a token with the current secret, see Sign
+func (k *Keyring) Sign(purpose, subject string, expiresAt time.Time) (string, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return Sign(k.current, purpose, subject, expiresAt)
+}
+
+// Verify checks a token against the current secret, then the retired ones still in their grace window
+func (k *Keyring) Verify(purpose, token string) (string, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	subject, err := Verify(k.current, purpose, token)
+	if !errors.Is(err, ErrInvalid) {
+		return subject, err
+	}
+
+	now := k.now()
+	for _, r := range k.previous {
+		if !now.Before(r.until) {
+			continue
+		}
+		if subject, retiredErr := Verify(r.secret, purpose, token); !errors.Is(retiredErr, ErrInvalid) {
+			return subject, retiredErr
+		}
+	}
+	return "", err
+}
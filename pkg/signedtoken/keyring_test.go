@@ -0,0 +1,60 @@
+package signedtoken
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test Keyring - Tokens of a rotated secret verify until the grace window ends
+func TestKeyring_Rotate(t *testing.T) {
+	now := time.Now()
+	keys := NewKeyring([]byte("old-secret"))
+	keys.now = func() time.Time { return now }
+
+	oldToken, err := keys.Sign("verify", "user-1", now.Add(time.Hour))
+	assert.NoError(t, err)
+
+	keys.Rotate([]byte("new-secret"), 10*time.Minute)
+
+	newToken, err := keys.Sign("verify", "user-2", now.Add(time.Hour))
+	assert.NoError(t, err)
+	_, err = Verify([]byte("new-secret"), "verify", newToken)
+	assert.NoError(t, err, "new tokens are signed with the new secret")
+
+	subject, err := keys.Verify("verify", oldToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", subject)
+
+	now = now.Add(11 * time.Minute)
+	_, err = keys.Verify("verify", oldToken)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	subject, err = keys.Verify("verify", newToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "user-2", subject)
+}
+
+// Test Keyring - Expired tokens of a retired secret report expiry, unknown secrets stay invalid
+func TestKeyring_Verify(t *testing.T) {
+	keys := NewKeyring([]byte("old-secret"))
+	expired, err := keys.Sign("verify", "user-1", time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+	keys.Rotate([]byte("new-secret"), time.Hour)
+
+	_, err = keys.Verify("verify", expired)
+	assert.ErrorIs(t, err, ErrExpired)
+
+	forged, err := Sign([]byte("other-secret"), "verify", "user-1", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	_, err = keys.Verify("verify", forged)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	// Rotating to the current secret keeps the retired one
+	keys.Rotate([]byte("new-secret"), 0)
+	oldValid, err := Sign([]byte("old-secret"), "verify", "user-1", time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	_, err = keys.Verify("verify", oldValid)
+	assert.NoError(t, err)
+}
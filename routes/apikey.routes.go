@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-fiber-jwt/internal/apikey"
+	"github.com/golang-fiber-jwt/internal/middleware"
+)
+
+func APIKeyRoutes(router fiber.Router, handler *apikey.Handler, mw *middleware.AuthMiddleware, limits middleware.RateLimits, features middleware.Features) {
+	// Managed with a user session only, an API key cannot mint or revoke keys
+	// Bounded by the users deadline UserRoutes registers on the /users prefix
+	router.Route("/users/me/api-keys", func(keyRouter fiber.Router) {
+		keyRouter.Use(features.APIKeys)
+		keyRouter.Post("/", mw.DeserializeUser, limits.Users, handler.CreateKey)
+		keyRouter.Get("/", mw.DeserializeUser, limits.Users, handler.ListKeys)
+		keyRouter.Delete("/:id", mw.DeserializeUser, limits.Users, handler.RevokeKey)
+	})
+}
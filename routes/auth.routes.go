@@ -4,15 +4,40 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-fiber-jwt/internal/auth"
 	"github.com/golang-fiber-jwt/internal/middleware"
+	"github.com/golang-fiber-jwt/pkg/rbac"
 )
 
-func AuthRoutes(router fiber.Router, handler *auth.Handler) {
+func AuthRoutes(router fiber.Router, handler *auth.Handler, mw *middleware.AuthMiddleware, limits middleware.RateLimits, deadlines middleware.Deadlines, features middleware.Features) {
 	router.Route("/auth", func(authRouter fiber.Router) {
-		authRouter.Post("/register", handler.SignUpUser)
-		authRouter.Post("/login", handler.SignInUser)
-		authRouter.Get("/logout", middleware.DeserializeUser, handler.LogoutUser)
+		authRouter.Use(deadlines.Auth)
+
+		// Credential and email endpoints are strictly rate limited per IP address
+		authRouter.Post("/register", features.Signup, limits.Auth, handler.SignUpUser)
+		authRouter.Post("/login", limits.Auth, handler.SignInUser)
+		authRouter.Post("/refresh", handler.RefreshAccessToken)
+		authRouter.Get("/verify/:token", handler.VerifyEmail)
+		authRouter.Post("/verify/resend", limits.Auth, handler.ResendVerification)
+		authRouter.Post("/forgot-password", limits.Auth, handler.ForgotPassword)
+		authRouter.Post("/reset-password", limits.Auth, handler.ResetPassword)
+		authRouter.Post("/mfa/verify", limits.Auth, handler.VerifyMFA)
+		authRouter.Post("/mfa/enroll", mw.DeserializeUser, handler.EnrollMFA)
+		authRouter.Post("/mfa/confirm", mw.DeserializeUser, handler.ConfirmMFA)
+		authRouter.Post("/mfa/disable", mw.DeserializeUser, handler.DisableMFA)
+		authRouter.Get("/:provider/login", features.OAuthLogin, handler.OAuthLogin)
+		authRouter.Get("/:provider/callback", features.OAuthLogin, handler.OAuthCallback)
+		authRouter.Get("/logout", mw.DeserializeUser, handler.LogoutUser)
+		authRouter.Post("/logout-all", mw.DeserializeUser, handler.LogoutAllUser)
+
+		// Signed-in devices of the current user
+		authRouter.Get("/sessions", mw.DeserializeUser, handler.ListSessions)
+		authRouter.Delete("/sessions/:id", mw.DeserializeUser, handler.RevokeSession)
+
+		// Sessions of any user (authorized by the RBAC policy, API keys also need a matching scope)
+		authRouter.Get("/users/:userId/sessions", mw.DeserializeUserOrAPIKey, mw.Require(rbac.SessionsRead), handler.ListUserSessions)
+		authRouter.Delete("/users/:userId/sessions", mw.DeserializeUserOrAPIKey, mw.Require(rbac.SessionsRevoke), handler.RevokeUserSessions)
+		authRouter.Delete("/users/:userId/sessions/:id", mw.DeserializeUserOrAPIKey, mw.Require(rbac.SessionsRevoke), handler.RevokeUserSession)
 	})
 
 	// User routes within auth domain
-	router.Get("/user/me", middleware.DeserializeUser, handler.GetMe)
+	router.Get("/user/me", deadlines.Users, mw.DeserializeUserOrAPIKey, handler.GetMe)
 }
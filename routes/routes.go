@@ -4,17 +4,21 @@ import (
 	"fmt"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-fiber-jwt/internal/auth"
-	"github.com/golang-fiber-jwt/internal/user"
+	"github.com/golang-fiber-jwt/internal/container"
+	"github.com/golang-fiber-jwt/pkg/apperror"
 )
 
-func SetupRoutes(app *fiber.App, authHandler *auth.Handler, userHandler *user.Handler) {
-	micro := fiber.New()
+func SetupRoutes(app *fiber.App, c *container.Container) {
+	// Public keys for verifying access tokens, served at the well-known root path
+	app.Get("/.well-known/jwks.json", c.AuthHandler.JWKS)
+
+	micro := fiber.New(fiber.Config{ErrorHandler: app.Config().ErrorHandler})
 	app.Mount("/api", micro)
 
 	// Setup all module routes
-	AuthRoutes(micro, authHandler)
-	UserRoutes(micro, userHandler)
+	AuthRoutes(micro, c.AuthHandler, c.AuthMiddleware, c.RateLimits, c.Deadlines, c.Features)
+	UserRoutes(micro, c.UserHandler, c.AuthMiddleware, c.RateLimits, c.Deadlines)
+	APIKeyRoutes(micro, c.APIKeyHandler, c.AuthMiddleware, c.RateLimits, c.Features)
 
 	// Health check
 	micro.Get("/healthchecker", func(c *fiber.Ctx) error {
@@ -26,10 +30,6 @@ func SetupRoutes(app *fiber.App, authHandler *auth.Handler, userHandler *user.Ha
 
 	// 404 handler
 	micro.All("*", func(c *fiber.Ctx) error {
-		path := c.Path()
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"status":  "fail",
-			"message": fmt.Sprintf("Path: %v does not exists on this server", path),
-		})
+		return apperror.NotFound("route_not_found", fmt.Sprintf("Path: %v does not exists on this server", c.Path()))
 	})
 }
@@ -4,15 +4,28 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-fiber-jwt/internal/middleware"
 	"github.com/golang-fiber-jwt/internal/user"
+	"github.com/golang-fiber-jwt/pkg/rbac"
 )
 
-func UserRoutes(router fiber.Router, handler *user.Handler) {
+func UserRoutes(router fiber.Router, handler *user.Handler, mw *middleware.AuthMiddleware, limits middleware.RateLimits, deadlines middleware.Deadlines) {
 	router.Route("/users", func(userRouter fiber.Router) {
-		userRouter.Get("/", middleware.DeserializeUser, handler.ListUsers)
-		userRouter.Get("/:id", middleware.DeserializeUser, handler.GetUserByID)
-		// userRouter.Post("/", middleware.DeserializeUser, middleware.RequireAdminRole, handler.CreateUser)
-		// userRouter.Put("/:id", middleware.DeserializeUser, middleware.RequireAdminRole, handler.UpdateUser)
-		// userRouter.Delete("/:id", middleware.DeserializeUser, middleware.RequireAdminRole, handler.DeleteUser)
-		// userRouter.Patch("/:id/restore", middleware.DeserializeUser, middleware.RequireAdminRole, handler.RestoreUser)
+		// Registered on the /users prefix, so it also bounds the API key routes
+		userRouter.Use(deadlines.Users)
+
+		// Every route is rate limited after authentication, per API key or user
+
+		// Self-service routes (any authenticated user, acting on their own account)
+		userRouter.Patch("/me", mw.DeserializeUser, limits.Users, handler.UpdateMe)
+		userRouter.Post("/me/password", mw.DeserializeUser, limits.Users, handler.ChangePassword)
+
+		// Admin routes (authorized by the RBAC policy, API keys also need a matching scope)
+		userRouter.Get("/", mw.DeserializeUserOrAPIKey, limits.Users, mw.Require(rbac.UsersRead), handler.ListUsers)
+		userRouter.Get("/stats", mw.DeserializeUserOrAPIKey, limits.Users, mw.Require(rbac.UsersStats), handler.GetUserStats)
+		userRouter.Get("/:id", mw.DeserializeUserOrAPIKey, limits.Users, mw.Require(rbac.UsersRead), handler.GetUserByID)
+		userRouter.Post("/", mw.DeserializeUserOrAPIKey, limits.Users, mw.Require(rbac.UsersCreate), handler.CreateUser)
+		userRouter.Put("/:id", mw.DeserializeUserOrAPIKey, limits.Users, mw.Require(rbac.UsersUpdate), handler.UpdateUser)
+		userRouter.Delete("/:id", mw.DeserializeUserOrAPIKey, limits.Users, mw.Require(rbac.UsersDelete), handler.DeleteUser)
+		userRouter.Patch("/:id/restore", mw.DeserializeUserOrAPIKey, limits.Users, mw.Require(rbac.UsersRestore), handler.RestoreUser)
+		userRouter.Post("/:id/unlock", mw.DeserializeUserOrAPIKey, limits.Users, mw.Require(rbac.UsersUnlock), handler.UnlockUser)
 	})
 }